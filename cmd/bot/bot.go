@@ -6,18 +6,31 @@ import (
 	tele "gopkg.in/telebot.v3"
 )
 
+// Option - дополнительная настройка бота
+type Option func(*tele.Settings)
+
 // InitBot - инициализация бота и его подключение к телеграму
-func InitBot(token string) *tele.Bot {
+func InitBot(token string, opts ...Option) *tele.Bot {
+	b, err := NewBot(token, opts...)
+	if err != nil {
+		log.Fatalf("Ошибка при инициализации бота %v", err)
+	}
+
+	return b
+}
+
+// NewBot - как InitBot, но возвращает ошибку (для пересоздания бота при ротации токена)
+func NewBot(token string, opts ...Option) (*tele.Bot, error) {
+	// бот создается не в Offline: getMe заполняет Me.Username, без него ссылки на Mini App
+	// (приглашения, кнопки, inline, картинки раскладов) получаются пустыми
 	pref := tele.Settings{
 		Token:  token,
 		Poller: &tele.LongPoller{Timeout: 10},
-		Offline: true, // DEV ONLY
 	}
 
-	b, err := tele.NewBot(pref)
-	if err != nil {
-		log.Fatalf("Ошибка при инициализации бота %v", err)
+	for _, opt := range opts {
+		opt(&pref)
 	}
 
-	return b
+	return tele.NewBot(pref)
 }
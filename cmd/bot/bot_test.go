@@ -0,0 +1,40 @@
+package bot_test
+
+import (
+	"net/http"
+	"taro-api/cmd/bot"
+	"taro-api/internal/helpers/telegram/telegramtest"
+	"testing"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const testToken = "123456:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+func TestNewBotFetchesMe(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	b, err := bot.NewBot(testToken, func(s *tele.Settings) { s.URL = srv.URL })
+	if err != nil {
+		t.Fatalf("NewBot: %v", err)
+	}
+
+	// по username строятся ссылки на Mini App
+	if b.Me == nil || b.Me.Username != "taroki_test_bot" {
+		t.Errorf("Me = %+v, want the bot from getMe", b.Me)
+	}
+	if n := srv.Calls("getMe"); n != 1 {
+		t.Errorf("getMe called %d times, want 1", n)
+	}
+}
+
+func TestNewBotInvalidToken(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+	srv.FailNext("getMe", telegramtest.Failure{Code: http.StatusUnauthorized, Description: "Unauthorized"})
+
+	if _, err := bot.NewBot(testToken, func(s *tele.Settings) { s.URL = srv.URL }); err == nil {
+		t.Error("NewBot succeeded with a token rejected by getMe")
+	}
+}
@@ -4,9 +4,15 @@ import tele "gopkg.in/telebot.v3"
 
 // TaroBot - объект бота
 type TaroBot struct {
-	Bot         *tele.Bot
+	Bot *tele.Bot
+	// Name - имя бота из конфигурации (основной или дополнительный)
+	Name string
+	// Primary - основной бот, ему принадлежат пользователи, пришедшие до появления нескольких ботов
+	Primary     bool
 	BotID       string
 	ChannelID   int64
 	AdminUserID int64
 	TmaURL      string
+	// CardImagesURL - каталог изображений карт, пусто - картинок нет
+	CardImagesURL string
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"taro-api/internal/config"
+)
+
+const usage = `usage:
+  taro               start the server
+  taro config check  validate config and print effective values (secrets redacted)`
+
+// runCommand - служебные подкоманды, возвращает код выхода
+func runCommand(args []string) int {
+	switch {
+	case len(args) == 2 && args[0] == "config" && args[1] == "check":
+		return configCheck()
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+}
+
+// configCheck - проверяет конфигурацию так же, как при старте, и печатает итоговые значения
+func configCheck() int {
+	fmt.Printf("# config file: %s\n", config.Path())
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := config.Print(os.Stdout, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
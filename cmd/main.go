@@ -2,119 +2,295 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"taro-api/cmd/bot"
 	"taro-api/internal/config"
-	"taro-api/internal/handlers/api/getuser"
 	chat "taro-api/internal/handlers/bot"
+	"taro-api/internal/lib/broadcast"
+	"taro-api/internal/lib/lifecycle"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/lib/metrics"
+	"taro-api/internal/lib/sharecard"
+	"taro-api/internal/lib/sharetoken"
+	tokens "taro-api/internal/lib/session"
 	"taro-api/internal/middlewares"
+	"taro-api/internal/router"
 	"taro-api/internal/storage/db"
 	"time"
+	// база часовых поясов для проверки настроек, если в образе нет zoneinfo
+	_ "time/tzdata"
 
 	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
-	"github.com/go-chi/cors"
-	"github.com/go-chi/httprate"
+	tele "gopkg.in/telebot.v3"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
 
 	cfg := config.MustLoad()
 
-	taroBot := bot.TaroBot{
-		Bot:         bot.InitBot(cfg.BotToken),
-		BotID:       cfg.BotID,
-		ChannelID:   cfg.ChannelID,
-		AdminUserID: cfg.AdminUserID,
-		TmaURL:      cfg.TmaURL,
+	log, err := logger.New(os.Stdout, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		slog.Error("invalid logger config", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	// токены ботов входят в адреса Bot API и могут попасть в текст ошибок
+	redactor := logger.NewRedactor()
+	log = logger.Redact(log, redactor)
+	// стандартный log (в том числе ошибки telebot) тоже идет через этот логгер
+	slog.SetDefault(log)
+
+	// запросы к Bot API вне бота (аватары, проверка готовности) идут от бота пользователя
+	botCfgs := cfg.AllBots()
+	botMgr := newBotManager(log, redactor, botCfgs, &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: metrics.Transport(http.DefaultTransport),
+	})
+
+	telegramHTTP := &http.Client{
+		Timeout:   time.Minute,
+		Transport: metrics.Transport(http.DefaultTransport),
 	}
 
-	storage, err := db.New(context.TODO())
+	authOpts, err := authOptions(cfg.InitData)
 	if err != nil {
-		slog.Error("failed to init storage", err.Error(), "err")
+		log.Error("invalid init data config", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
-	defer func() {
-		if err := storage.CloseDatabaseConnection(); err != nil {
-			slog.Error("failed to close storage", err.Error(), "err")
-			return
-		}
-		slog.Info("storage closed")
-	}()
-
-	router := chi.NewRouter()
-	router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300,
-	}))
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.URLFormat)
-	router.Use(httprate.LimitByIP(500, 1*time.Second))
-	router.Use(middleware.ThrottleBacklog(250, 1500, time.Second*10))
+	storage, err := db.New(context.TODO(), cfg.DBPath)
+	if err != nil {
+		log.Error("failed to init storage", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
 
-	router.Use(middlewares.AuthMiddleware(cfg.BotToken))
+	if err := storage.RegisterMetrics(metrics.Registry); err != nil {
+		log.Error("failed to register storage metrics", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	tokenManager := tokens.NewManager([]byte(cfg.Session.Secret), cfg.Session.AccessTTL, cfg.Session.RefreshTTL)
+	shareTokens := sharetoken.NewSigner([]byte(cfg.Session.Secret), cfg.Session.ShareTTL)
+	authOpts.Tokens = tokenManager
+	authOpts.Store = storage
 
-	// прокси для получения аватара пользователя
-	router.Get("/me/photo", getuser.Photo(slog.Default(), cfg.BotToken))
+	var cardImages fs.FS
+	if cfg.CardImagesDir != "" {
+		cardImages = os.DirFS(cfg.CardImagesDir)
+	}
+	shareCards := sharecard.New(cardImages)
 
-	router.Get("/me", getuser.New(slog.Default(), storage, cfg.BotToken))
+	// фоновые задачи обработчиков (поздравления с достижениями), их дожидаются при остановке
+	jobs := &lifecycle.InFlight{}
 
-	done := make(chan os.Signal, 1)
-	sigterm := make(chan os.Signal, 1)
-	signal.Notify(sigterm, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	api := router.New(router.Deps{
+		Log:               log,
+		Config:            cfg,
+		Storage:           storage,
+		Bots:              botMgr.registry,
+		Telegram:          botMgr.telegramClient,
+		Auth:              authOpts,
+		Tokens:            tokenManager,
+		ShareCards:        shareCards,
+		ShareTokens:       shareTokens,
+		ReferralLink:      botMgr.referralLink,
+		NotifyAchievement: botMgr.notifyAchievement,
+		Jobs:              jobs,
+	})
 
 	srv := &http.Server{
-		Addr:         "0.0.0.0:8088",
-		Handler:      router,
-		ReadTimeout:  time.Second * 6,
-		WriteTimeout: time.Second * 6,
-		IdleTimeout:  time.Second * 30,
+		Addr:         cfg.Server.Address,
+		Handler:      api,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
-	go func() {
-		defer close(done)
+	metricsRouter := chi.NewRouter()
+	metricsRouter.Handle("/metrics", metrics.Handler())
+
+	metricsSrv := &http.Server{
+		Addr:              cfg.MetricsAddr,
+		Handler:           metricsRouter,
+		ReadHeaderTimeout: time.Second * 5,
+	}
 
-		<-sigterm
-		slog.Info("stopping http-server")
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+	broadcasts := broadcast.NewWorker(log, storage, botMgr.bot, cfg.BroadcastRate)
 
-		if err := srv.Shutdown(ctx); err != nil {
-			slog.Error("failed to stop http-server", slog.String("Error", err.Error()))
+	botMgr.startBot = func(c config.BotConfig) (*tele.Bot, error) {
+		b, err := bot.NewBot(c.Token, func(s *tele.Settings) {
+			s.Client = telegramHTTP
+		})
+		if err != nil {
+			return nil, err
 		}
-	}()
+		b.Use(botMgr.track, chat.Language(storage))
 
-	slog.Info("starting http-server", slog.String("address", "0.0.0.0:8088"))
+		// обработчики регистрируются до запуска поллера
+		registerBotHandlers(bot.TaroBot{
+			Bot:           b,
+			Name:          c.Name,
+			Primary:       c.Name == botMgr.registry.Primary().Name,
+			BotID:         cfg.BotID,
+			ChannelID:     cfg.ChannelID,
+			AdminUserID:   cfg.AdminUserID,
+			TmaURL:        c.TmaURL,
+			CardImagesURL: cfg.CardImagesURL,
+		}, storage, broadcasts, shareCards)
+		go b.Start()
 
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			signal.Stop(sigterm)
-			slog.Error("failed to start http-server")
-			return
-		}
-	}()
+		// меню команд не критично для работы бота, ошибка только логируется
+		go func() {
+			if err := chat.SetCommands(b); err != nil {
+				log.Warn("failed to set bot commands", slog.String("bot", c.Name), slog.String("error", err.Error()))
+			}
+		}()
+
+		return b, nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// запускаются по порядку, каждый после готовности предыдущего; останавливаются в обратном:
+	// сначала боты перестают получать обновления и дожидаются обработчиков, затем HTTP
+	// дожидается запросов, затем фоновые задачи запросов, метрики - последними
+	runner := lifecycle.New(log, cfg.Server.ShutdownTimeout)
+	runner.Add(httpComponent(log, "metrics", metricsSrv))
+	runner.Add(jobs.Component("jobs"))
+	runner.Add(httpComponent(log, "http", srv))
+	runner.Add(lifecycle.Periodic(log, "idempotency-cleanup", time.Hour, func(context.Context) error {
+		_, err := storage.DeleteExpiredIdempotencyKeys()
+		return err
+	}))
+	runner.Add(lifecycle.Component{
+		Name: "bots",
+		Start: func(ctx context.Context) error {
+			return botMgr.run(ctx, botCfgs)
+		},
+		Stop:  botMgr.stop,
+		Ready: botMgr.started,
+	})
+	runner.Add(lifecycle.Component{
+		Name:  "broadcasts",
+		Start: broadcasts.Run,
+	})
+
+	exitCode := 0
+	if err := runner.Run(ctx); err != nil {
+		log.Error("application stopped with error", slog.String("error", err.Error()))
+		exitCode = 1
+	}
+
+	// хранилище закрывается после остановки всех, кто им пользуется
+	if err := storage.CloseDatabaseConnection(); err != nil {
+		log.Error("failed to close storage", slog.String("error", err.Error()))
+		exitCode = 1
+	} else {
+		log.Info("storage closed")
+	}
+
+	os.Exit(exitCode)
+}
+
+// httpComponent - HTTP сервер как часть приложения: готов, когда слушает порт,
+// Shutdown дожидается текущих запросов
+func httpComponent(log *slog.Logger, name string, srv *http.Server) lifecycle.Component {
+	ready := make(chan struct{})
+
+	return lifecycle.Component{
+		Name: name,
+		Start: func(context.Context) error {
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			close(ready)
+
+			log.Info("starting http server", slog.String("server", name), slog.String("address", srv.Addr))
+			if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		Stop:  srv.Shutdown,
+		Ready: ready,
+	}
+}
+
+func registerBotHandlers(taroBot bot.TaroBot, storage *db.Storage, broadcasts chat.Waker, shareCards chat.ShareRenderer) {
+	commandHandler := chat.NewCommandHandler(&taroBot, storage)
+	taroBot.Bot.Handle("/start", commandHandler.StartHandler, metrics.BotHandler("/start"))
+
+	menuHandler := chat.NewMenuHandler(&taroBot, storage)
+	taroBot.Bot.Handle("/help", menuHandler.HelpHandler, metrics.BotHandler("/help"))
+	taroBot.Bot.Handle("/balance", menuHandler.BalanceHandler, metrics.BotHandler("/balance"))
+	taroBot.Bot.Handle("/invite", menuHandler.InviteHandler, metrics.BotHandler("/invite"))
+	taroBot.Bot.Handle("/language", menuHandler.LanguageHandler, metrics.BotHandler("/language"))
+	taroBot.Bot.Handle(&chat.BtnLanguage, menuHandler.LanguageCallback, metrics.BotHandler("language"))
 
-	go func() {
-		taroBot.Bot.Start()
-	}()
+	tarotHandler := chat.NewTarotHandler(&taroBot, storage, shareCards)
+	taroBot.Bot.Handle("/card", tarotHandler.CardHandler, metrics.BotHandler("/card"))
+	taroBot.Bot.Handle("/daily", tarotHandler.DailyHandler, metrics.BotHandler("/daily"))
+	taroBot.Bot.Handle("/spread", tarotHandler.SpreadHandler, metrics.BotHandler("/spread"))
+	taroBot.Bot.Handle(&chat.BtnCard, tarotHandler.CardCallback, metrics.BotHandler("card"))
+	taroBot.Bot.Handle(&chat.BtnSpread, tarotHandler.SpreadCallback, metrics.BotHandler("spread"))
+	taroBot.Bot.Handle(&chat.BtnSpreads, tarotHandler.SpreadHandler, metrics.BotHandler("spreads"))
+	taroBot.Bot.Handle(&chat.BtnShareImage, tarotHandler.ShareImageCallback, metrics.BotHandler("share_image"))
 
-	defer taroBot.Bot.Stop()
-	registerBotHandlers(taroBot)
-	<-done
-	slog.Info("server http-stopped")
+	// inline режим включается у @BotFather командой /setinline
+	inlineHandler := chat.NewInlineHandler(&taroBot, storage)
+	taroBot.Bot.Handle(tele.OnQuery, inlineHandler.QueryHandler, metrics.BotHandler("inline"))
 
+	adminHandler := chat.NewAdminHandler(&taroBot, storage)
+	taroBot.Bot.Handle("/block", adminHandler.BlockHandler, metrics.BotHandler("/block"), adminHandler.OnlyAdmin)
+	taroBot.Bot.Handle("/unblock", adminHandler.UnblockHandler, metrics.BotHandler("/unblock"), adminHandler.OnlyAdmin)
+
+	promoHandler := chat.NewPromoHandler(&taroBot, storage)
+	taroBot.Bot.Handle("/promo", promoHandler.RedeemHandler, metrics.BotHandler("/promo"))
+	taroBot.Bot.Handle("/newpromo", promoHandler.CreateHandler, metrics.BotHandler("/newpromo"), adminHandler.OnlyAdmin)
+
+	broadcastHandler := chat.NewBroadcastHandler(&taroBot, storage, broadcasts)
+	taroBot.Bot.Handle("/broadcast", broadcastHandler.CreateHandler, metrics.BotHandler("/broadcast"), adminHandler.OnlyAdmin)
+	taroBot.Bot.Handle("/bcstatus", broadcastHandler.StatusHandler, metrics.BotHandler("/bcstatus"), adminHandler.OnlyAdmin)
+	taroBot.Bot.Handle("/bcpause", broadcastHandler.PauseHandler, metrics.BotHandler("/bcpause"), adminHandler.OnlyAdmin)
+	taroBot.Bot.Handle("/bcresume", broadcastHandler.ResumeHandler, metrics.BotHandler("/bcresume"), adminHandler.OnlyAdmin)
+	taroBot.Bot.Handle("/bccancel", broadcastHandler.CancelHandler, metrics.BotHandler("/bccancel"), adminHandler.OnlyAdmin)
+	taroBot.Bot.Handle(&chat.BtnBroadcastSend, broadcastHandler.SendHandler, metrics.BotHandler("bc_send"), adminHandler.OnlyAdmin)
+	taroBot.Bot.Handle(&chat.BtnBroadcastPause, broadcastHandler.PauseHandler, metrics.BotHandler("bc_pause"), adminHandler.OnlyAdmin)
+	taroBot.Bot.Handle(&chat.BtnBroadcastResume, broadcastHandler.ResumeHandler, metrics.BotHandler("bc_resume"), adminHandler.OnlyAdmin)
+	taroBot.Bot.Handle(&chat.BtnBroadcastCancel, broadcastHandler.CancelHandler, metrics.BotHandler("bc_cancel"), adminHandler.OnlyAdmin)
 }
 
-func registerBotHandlers(taroBot bot.TaroBot) {
-	commandHandler := chat.NewCommandHandler(&taroBot)
-	taroBot.Bot.Handle("/start", commandHandler.StartHandler)
+func authOptions(cfg config.InitDataConfig) (middlewares.AuthOptions, error) {
+	opts := middlewares.AuthOptions{
+		MaxAge:           cfg.MaxAge,
+		Nonces:           middlewares.NewNonceCache(cfg.MaxAge, cfg.NonceLimit),
+		RequireNonce:     cfg.RequireNonce,
+		ThirdPartyBotIDs: cfg.ThirdPartyBotIDs,
+	}
+
+	if len(cfg.ThirdPartyBotIDs) == 0 {
+		return opts, nil
+	}
+
+	key, err := hex.DecodeString(cfg.TelegramPublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return opts, fmt.Errorf("TELEGRAM_PUBLIC_KEY must be a %d-byte hex string", ed25519.PublicKeySize)
+	}
+	opts.PublicKey = ed25519.PublicKey(key)
+
+	return opts, nil
 }
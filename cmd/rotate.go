@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"taro-api/internal/config"
+	"taro-api/internal/helpers/telegram"
+	"taro-api/internal/lib/bots"
+	"taro-api/internal/lib/deeplink"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/lifecycle"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/lib/secret"
+	"taro-api/internal/storage/db"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// botManager - запускает поллеры всех ботов и применяет новые токены по SIGHUP:
+// HTTP сервер продолжает работать, пересоздается только поллер бота с новым токеном
+type botManager struct {
+	log      *slog.Logger
+	redactor *logger.Redactor
+	registry *bots.Registry
+	// clients - клиенты Bot API по именам ботов, для запросов вне поллера (аватары, проверка готовности)
+	clients map[string]*telegram.Client
+	// startBot - создает бота с обработчиками и запускает его поллер
+	startBot func(cfg config.BotConfig) (*tele.Bot, error)
+
+	// inflight - обработчики обновлений, которые выполняются сейчас
+	inflight lifecycle.InFlight
+	// started - закрывается, когда запущены все боты
+	started chan struct{}
+
+	mu      sync.Mutex
+	running map[string]*tele.Bot
+	stopped bool
+}
+
+// newBotManager - создает реестр ботов и клиенты Bot API из конфигурации
+func newBotManager(log *slog.Logger, redactor *logger.Redactor, cfgs []config.BotConfig, httpClient *http.Client) *botManager {
+	list := make([]*bots.Bot, 0, len(cfgs))
+	clients := make(map[string]*telegram.Client, len(cfgs))
+	for _, c := range cfgs {
+		list = append(list, &bots.Bot{Name: c.Name, Token: secret.NewToken(c.Token)})
+		clients[c.Name] = telegram.New(c.Token, telegram.WithHTTPClient(httpClient))
+		redactor.Add(c.Token)
+	}
+
+	return &botManager{
+		log:      log,
+		redactor: redactor,
+		registry: bots.NewRegistry(list...),
+		clients:  clients,
+		started:  make(chan struct{}),
+		running:  make(map[string]*tele.Bot),
+	}
+}
+
+// start - запускает всех ботов
+func (m *botManager) start(cfgs []config.BotConfig) error {
+	for _, c := range cfgs {
+		b, err := m.startBot(c)
+		if err != nil {
+			return fmt.Errorf("bot %s: %w", c.Name, err)
+		}
+
+		m.mu.Lock()
+		if m.stopped {
+			// остановка началась, пока бот создавался
+			m.mu.Unlock()
+			b.Stop()
+			return nil
+		}
+		m.running[c.Name] = b
+		m.mu.Unlock()
+
+		m.log.Info("bot started", slog.String("bot", c.Name))
+	}
+
+	return nil
+}
+
+// run - запускает ботов и до остановки применяет новые токены по SIGHUP
+func (m *botManager) run(ctx context.Context, cfgs []config.BotConfig) error {
+	if err := m.start(cfgs); err != nil {
+		return err
+	}
+	close(m.started)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			m.rotate()
+		}
+	}
+}
+
+// track - учитывает выполняющиеся обработчики, чтобы дождаться их при остановке
+func (m *botManager) track(next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		done, ok := m.inflight.Track()
+		if !ok {
+			// остановка уже началась, обновление не обрабатывается
+			return nil
+		}
+		defer done()
+		return next(c)
+	}
+}
+
+func (m *botManager) rotate() {
+	m.log.Info("reloading bot tokens")
+
+	cfg, err := config.Load()
+	if err != nil {
+		m.log.Error("failed to reload config, keeping current tokens", slog.String("error", err.Error()))
+		return
+	}
+
+	rotated := 0
+	for _, c := range cfg.AllBots() {
+		log := m.log.With(slog.String("bot", c.Name))
+
+		b, ok := m.registry.Get(c.Name)
+		if !ok {
+			log.Warn("new bot in config is ignored until restart")
+			continue
+		}
+		if c.Token == b.Token.Get() {
+			continue
+		}
+
+		// новый токен скрывается в логах до первого его использования
+		m.redactor.Add(c.Token)
+
+		newBot, err := m.startBot(c)
+		if err != nil {
+			log.Error("failed to start bot with new token, keeping current token", slog.String("error", err.Error()))
+			continue
+		}
+
+		b.Token.Set(c.Token)
+		m.clients[c.Name].SetToken(c.Token)
+
+		m.mu.Lock()
+		if m.stopped {
+			m.mu.Unlock()
+			newBot.Stop()
+			return
+		}
+		oldBot := m.running[c.Name]
+		m.running[c.Name] = newBot
+		m.mu.Unlock()
+
+		if oldBot != nil {
+			oldBot.Stop()
+		}
+
+		rotated++
+		log.Info("bot token rotated")
+	}
+
+	if rotated == 0 {
+		m.log.Info("bot tokens are unchanged")
+	}
+}
+
+// bot - запущенный бот по имени, после ротации токена - новый экземпляр
+func (m *botManager) bot(name string) (*tele.Bot, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.running[name]
+	return b, ok
+}
+
+// telegramClient - клиент Bot API бота name; для сторонних ботов, токенов которых у нас нет,
+// - клиент основного бота
+func (m *botManager) telegramClient(name string) *telegram.Client {
+	if c, ok := m.clients[name]; ok {
+		return c
+	}
+	return m.clients[m.registry.Primary().Name]
+}
+
+// referralLink - реферальная ссылка пользователя в основном боте; пусто, пока бот не запущен
+func (m *botManager) referralLink(telegramID int64) string {
+	b, ok := m.bot(m.registry.Primary().Name)
+	if !ok {
+		return ""
+	}
+	return deeplink.StartApp(b.Me.Username, deeplink.Referral(telegramID))
+}
+
+// notifyAchievement - поздравляет пользователя с достижением в боте, через который он пришел,
+// а если тот не запущен - в основном боте
+func (m *botManager) notifyAchievement(name string, telegramID int64, l i18n.Lang, a db.Achievement) error {
+	b, ok := m.bot(name)
+	if !ok {
+		b, ok = m.bot(m.registry.Primary().Name)
+	}
+	if !ok {
+		return fmt.Errorf("bot %q is not running", name)
+	}
+
+	text := i18n.T(l, i18n.BotAchievement,
+		i18n.T(l, i18n.AchievementKey(a.Code)), i18n.T(l, i18n.AchievementDescriptionKey(a.Code)))
+	if _, err := b.Send(tele.ChatID(telegramID), text); err != nil {
+		return fmt.Errorf("send achievement: %w", err)
+	}
+	return nil
+}
+
+// stop - перестает получать обновления и дожидается обработчиков, которые уже выполняются
+func (m *botManager) stop(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopped = true
+	for _, b := range m.running {
+		b.Stop()
+	}
+	m.mu.Unlock()
+
+	return m.inflight.Wait(ctx)
+}
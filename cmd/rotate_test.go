@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"taro-api/cmd/bot"
+	"taro-api/internal/config"
+	"taro-api/internal/helpers/telegram/telegramtest"
+	"taro-api/internal/lib/logger"
+	"testing"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const testToken = "123456:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+func TestStartedBotHasUsername(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	cfgs := []config.BotConfig{{Name: "main", Token: testToken}}
+	m := newBotManager(slog.New(slog.NewTextHandler(io.Discard, nil)), logger.NewRedactor(), cfgs, http.DefaultClient)
+	m.startBot = func(c config.BotConfig) (*tele.Bot, error) {
+		return bot.NewBot(c.Token, func(s *tele.Settings) { s.URL = srv.URL })
+	}
+
+	if link := m.referralLink(42); link != "" {
+		t.Errorf("referral link before start = %q, want empty", link)
+	}
+
+	if err := m.start(cfgs); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	b, ok := m.bot("main")
+	if !ok {
+		t.Fatal("bot is not running after start")
+	}
+	if b.Me.Username == "" {
+		t.Fatal("started bot has no username")
+	}
+	if link := m.referralLink(42); !strings.HasPrefix(link, "https://t.me/taroki_test_bot?startapp=") {
+		t.Errorf("referral link = %q, want a t.me link to the bot", link)
+	}
+}
@@ -1,57 +1,209 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 // Config - Структура конфигурации приложения
 type Config struct {
-	BotToken    string `yaml:"BOT_TOKEN" env-required:"true" env:"BOT_TOKEN"`
-	BotID       string `yaml:"BOT_ID" env:"BOT_ID"`
-	ChannelID   int64  `yaml:"CHANNEL_ID" env-required:"true" env:"CHANNEL_ID"`
-	AdminUserID int64  `yaml:"ADMIN_USER_ID" env-required:"true" env:"ADMIN_USER_ID"`
-	TmaURL      string `yaml:"TMA_URL" env-required:"true" env:"TMA_URL"`
+	// Имя основного бота, которым помечаются пришедшие через него пользователи
+	BotName string `yaml:"BOT_NAME" env:"BOT_NAME" env-default:"main"`
+	// Токен бота: из BOT_TOKEN или из файла BOT_TOKEN_FILE (секреты Docker/K8s)
+	BotToken     string `yaml:"BOT_TOKEN" env:"BOT_TOKEN" secret:"true"`
+	BotTokenFile string `yaml:"BOT_TOKEN_FILE" env:"BOT_TOKEN_FILE"`
+	// Дополнительные боты (DEV, региональные), задаются только в файле конфигурации
+	Bots        []BotConfig `yaml:"BOTS"`
+	BotID       string      `yaml:"BOT_ID" env:"BOT_ID"`
+	ChannelID   int64       `yaml:"CHANNEL_ID" env-required:"true" env:"CHANNEL_ID"`
+	AdminUserID int64       `yaml:"ADMIN_USER_ID" env-required:"true" env:"ADMIN_USER_ID"`
+	TmaURL      string      `yaml:"TMA_URL" env-required:"true" env:"TMA_URL"`
+	// Каталог изображений карт (<url>/<id карты>.jpg) для inline режима, пусто - результаты без картинок
+	CardImagesURL string `yaml:"CARD_IMAGES_URL" env:"CARD_IMAGES_URL"`
+	// Локальный каталог тех же изображений (<id карты>.png или .jpg) для картинок раскладов,
+	// пусто - карты на картинках рисуются без изображений
+	CardImagesDir string `yaml:"CARD_IMAGES_DIR" env:"CARD_IMAGES_DIR"`
+	// Адрес внутреннего листенера /metrics, недоступного через публичный роутер
+	MetricsAddr string `yaml:"METRICS_ADDR" env:"METRICS_ADDR" env-default:"127.0.0.1:9090"`
+	// Формат логов: text или json
+	LogFormat string `yaml:"LOG_FORMAT" env:"LOG_FORMAT" env-default:"text"`
+	// Уровень логов: debug, info, warn, error
+	LogLevel string `yaml:"LOG_LEVEL" env:"LOG_LEVEL" env-default:"info"`
+	// Скорость рассылок, сообщений в секунду на все рассылки всех ботов вместе
+	// (лимит Telegram - около 30 в секунду на бота)
+	BroadcastRate int `yaml:"BROADCAST_RATE" env:"BROADCAST_RATE" env-default:"25"`
+	// Путь к файлу базы SQLite
+	DBPath string `yaml:"DB_PATH" env:"DB_PATH" env-default:"appdb.db"`
+
+	Server   ServerConfig   `yaml:"SERVER"`
+	InitData InitDataConfig `yaml:"INIT_DATA"`
+	Session  SessionConfig  `yaml:"SESSION"`
+}
+
+// BotConfig - настройки дополнительного бота
+type BotConfig struct {
+	Name      string `yaml:"NAME"`
+	Token     string `yaml:"TOKEN" secret:"true"`
+	TokenFile string `yaml:"TOKEN_FILE"`
+	// Адрес Mini App, если он отличается от TMA_URL
+	TmaURL string `yaml:"TMA_URL"`
+}
+
+// ServerConfig - настройки HTTP сервера
+type ServerConfig struct {
+	Address         string        `yaml:"ADDRESS" env:"HTTP_ADDRESS" env-default:"0.0.0.0:8088"`
+	ReadTimeout     time.Duration `yaml:"READ_TIMEOUT" env:"HTTP_READ_TIMEOUT" env-default:"6s"`
+	WriteTimeout    time.Duration `yaml:"WRITE_TIMEOUT" env:"HTTP_WRITE_TIMEOUT" env-default:"6s"`
+	IdleTimeout     time.Duration `yaml:"IDLE_TIMEOUT" env:"HTTP_IDLE_TIMEOUT" env-default:"30s"`
+	ShutdownTimeout time.Duration `yaml:"SHUTDOWN_TIMEOUT" env:"HTTP_SHUTDOWN_TIMEOUT" env-default:"10s"`
+	// Разрешенные CORS источники, через запятую в env
+	CORSOrigins []string `yaml:"CORS_ORIGINS" env:"HTTP_CORS_ORIGINS" env-default:"https://*,http://*"`
+	// Лимит запросов с одного IP за окно RateWindow
+	RateLimit  int           `yaml:"RATE_LIMIT" env:"HTTP_RATE_LIMIT" env-default:"500"`
+	RateWindow time.Duration `yaml:"RATE_WINDOW" env:"HTTP_RATE_WINDOW" env-default:"1s"`
+	// Одновременно обрабатываемые запросы, очередь ожидания и время ожидания в ней
+	ThrottleLimit   int           `yaml:"THROTTLE_LIMIT" env:"HTTP_THROTTLE_LIMIT" env-default:"250"`
+	ThrottleBacklog int           `yaml:"THROTTLE_BACKLOG" env:"HTTP_THROTTLE_BACKLOG" env-default:"1500"`
+	ThrottleTimeout time.Duration `yaml:"THROTTLE_TIMEOUT" env:"HTTP_THROTTLE_TIMEOUT" env-default:"10s"`
+	// GET /me регистрирует пользователя и принимает referralID, как ждут старые версии Mini App.
+	// Устарело: включено, пока клиенты переходят на POST /users/register, затем выключается
+	LegacyGetMe bool `yaml:"LEGACY_GET_ME" env:"HTTP_LEGACY_GET_ME" env-default:"true"`
+	// Сколько хранятся ответы на запросы с заголовком Idempotency-Key
+	IdempotencyTTL time.Duration `yaml:"IDEMPOTENCY_TTL" env:"HTTP_IDEMPOTENCY_TTL" env-default:"24h"`
+}
+
+// SessionConfig - настройки токенов сессий
+type SessionConfig struct {
+	// Секрет подписи токенов доступа, обязателен: от токена бота не зависит, поэтому
+	// утечка или ротация токена не затрагивает выданные сессии
+	Secret     string        `yaml:"SECRET" env:"SESSION_SECRET" secret:"true"`
+	AccessTTL  time.Duration `yaml:"ACCESS_TTL" env:"SESSION_ACCESS_TTL" env-default:"15m"`
+	RefreshTTL time.Duration `yaml:"REFRESH_TTL" env:"SESSION_REFRESH_TTL" env-default:"720h"`
+	// Максимальный возраст данных Telegram Login Widget
+	LoginWidgetMaxAge time.Duration `yaml:"LOGIN_WIDGET_MAX_AGE" env:"SESSION_LOGIN_WIDGET_MAX_AGE" env-default:"24h"`
+	// Срок действия ссылок на картинки раскладов, подписываются тем же секретом
+	ShareTTL time.Duration `yaml:"SHARE_TTL" env:"SESSION_SHARE_TTL" env-default:"24h"`
+}
+
+// InitDataConfig - настройки проверки init data Telegram Mini App
+type InitDataConfig struct {
+	// Максимальный возраст init data по auth_date
+	MaxAge time.Duration `yaml:"MAX_AGE" env:"INIT_DATA_MAX_AGE" env-default:"24h"`
+	// Требовать заголовок X-Request-Nonce с уникальным значением для каждого запроса
+	RequireNonce bool `yaml:"REQUIRE_NONCE" env:"INIT_DATA_REQUIRE_NONCE" env-default:"false"`
+	// Сколько запросов принимается с одними init data за MAX_AGE, включая запросы без nonce;
+	// ограничивает повтор перехваченных init data, 0 - без ограничения
+	NonceLimit int `yaml:"NONCE_LIMIT" env:"INIT_DATA_NONCE_LIMIT" env-default:"1000"`
+	// ID сторонних ботов, init data которых принимается по подписи Ed25519
+	ThirdPartyBotIDs []int64 `yaml:"THIRD_PARTY_BOT_IDS" env:"INIT_DATA_THIRD_PARTY_BOT_IDS"`
+	// Публичный ключ Telegram (hex) для проверки подписи сторонних ботов
+	TelegramPublicKey string `yaml:"TELEGRAM_PUBLIC_KEY" env:"INIT_DATA_TELEGRAM_PUBLIC_KEY"`
+	// Как часто профиль Telegram пользователя (имя, username, фото) сохраняется из init data
+	ProfileSyncInterval time.Duration `yaml:"PROFILE_SYNC_INTERVAL" env:"INIT_DATA_PROFILE_SYNC_INTERVAL" env-default:"10m"`
 }
 
 const (
-	configPath = "cfg.yaml"
+	defaultConfigPath = "cfg.yaml"
+	configPathEnv     = "CONFIG_PATH"
 )
 
-// loadConfigFromFile загружает конфигурацию из файла
-func loadConfigFromFile(path string) (*Config, error) {
-	var cfg Config
-	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
-		return nil, fmt.Errorf("cannot read config from file: %w", err)
+// Path - путь к файлу конфигурации (CONFIG_PATH или cfg.yaml)
+func Path() string {
+	if path := os.Getenv(configPathEnv); path != "" {
+		return path
 	}
-	return &cfg, nil
+	return defaultConfigPath
 }
 
-// loadConfigFromEnv загружает конфигурацию из переменных окружения
-func loadConfigFromEnv() (*Config, error) {
+// Load загружает конфигурацию из файла (если он есть) с переопределением из
+// переменных окружения, либо только из переменных окружения, и проверяет ее
+func Load() (*Config, error) {
+	path := Path()
+
 	var cfg Config
-	if err := cleanenv.ReadEnv(&cfg); err != nil {
-		return nil, fmt.Errorf("cannot read config from environment: %w", err)
+
+	_, statErr := os.Stat(path)
+	switch {
+	case statErr == nil:
+		if err := checkFilePermissions(path); err != nil {
+			return nil, err
+		}
+		if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+			return nil, fmt.Errorf("cannot read config from file %s: %w", path, err)
+		}
+	case errors.Is(statErr, os.ErrNotExist):
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			return nil, fmt.Errorf("config file %s does not exist and environment is incomplete: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("cannot access config file %s: %w", path, statErr)
+	}
+
+	if cfg.BotTokenFile != "" {
+		token, err := readTokenFile(cfg.BotTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read BOT_TOKEN_FILE: %w", err)
+		}
+		cfg.BotToken = token
 	}
+
+	for i := range cfg.Bots {
+		if cfg.Bots[i].TokenFile == "" {
+			continue
+		}
+		token, err := readTokenFile(cfg.Bots[i].TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read TOKEN_FILE of bot %q: %w", cfg.Bots[i].Name, err)
+		}
+		cfg.Bots[i].Token = token
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
-// MustLoad загружает конфигурацию из файла или из переменных окружения
-func MustLoad() *Config {
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.Fatalf("config file does not exist: %s", configPath)
+// AllBots - основной бот и дополнительные, адрес Mini App по умолчанию берется из TMA_URL
+func (c *Config) AllBots() []BotConfig {
+	bots := make([]BotConfig, 0, len(c.Bots)+1)
+	bots = append(bots, BotConfig{
+		Name:      c.BotName,
+		Token:     c.BotToken,
+		TokenFile: c.BotTokenFile,
+		TmaURL:    c.TmaURL,
+	})
+
+	for _, b := range c.Bots {
+		if b.TmaURL == "" {
+			b.TmaURL = c.TmaURL
+		}
+		bots = append(bots, b)
 	}
 
-	cfg, err := loadConfigFromEnv()
+	return bots
+}
+
+func readTokenFile(path string) (string, error) {
+	token, err := os.ReadFile(path)
 	if err != nil {
-		fmt.Printf("Failed to get environment variables: %v. Trying to load from file %s\n", err, configPath)
-		cfg, err = loadConfigFromFile(configPath)
-		if err != nil {
-			log.Fatalf("cannot load config: %v", err)
-		}
+		return "", err
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// MustLoad загружает конфигурацию и завершает процесс при ошибке
+func MustLoad() *Config {
+	cfg, err := Load()
+	if err != nil {
+		log.Fatalf("cannot load config: %v", err)
 	}
 
 	return cfg
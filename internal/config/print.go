@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// redacted - значение, которое выводится вместо секретов
+const redacted = "<redacted>"
+
+// Print выводит действующую конфигурацию в виде ENV=value, секреты заменяются на <redacted>
+func Print(w io.Writer, cfg *Config) error {
+	return printStruct(w, reflect.ValueOf(cfg).Elem(), "")
+}
+
+// printStruct - поля без env (элементы списков) выводятся как PREFIX.YAML_NAME
+func printStruct(w io.Writer, v reflect.Value, prefix string) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fv := v.Field(i)
+
+		if f.Type.Kind() == reflect.Struct {
+			if err := printStruct(w, fv, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct {
+			for j := 0; j < fv.Len(); j++ {
+				if err := printStruct(w, fv.Index(j), fmt.Sprintf("%s[%d].", f.Tag.Get("yaml"), j)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		name := f.Tag.Get("env")
+		if prefix != "" {
+			name = prefix + f.Tag.Get("yaml")
+		}
+		if name == "" {
+			continue
+		}
+
+		value := formatValue(fv)
+		if f.Tag.Get("secret") == "true" && value != "" {
+			value = redacted
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatValue(v reflect.Value) string {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+
+	if v.Kind() == reflect.Slice {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	}
+
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// checkFilePermissions - не дает запуститься, если файл с секретами доступен на чтение всем
+func checkFilePermissions(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot access config file %s: %w", path, err)
+	}
+
+	if info.Mode().Perm()&0o004 == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read config file %s: %w", path, err)
+	}
+
+	var fileCfg Config
+	if err := yaml.Unmarshal(data, &fileCfg); err != nil {
+		return fmt.Errorf("cannot parse config file %s: %w", path, err)
+	}
+
+	if hasSecrets(reflect.ValueOf(fileCfg)) {
+		return fmt.Errorf("config file %s is world-readable and contains secrets: "+
+			"run chmod o-r on it or move secrets to BOT_TOKEN_FILE/env", path)
+	}
+
+	return nil
+}
+
+// hasSecrets - заполнено ли хоть одно поле с тегом secret:"true"
+func hasSecrets(v reflect.Value) bool {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fv := v.Field(i)
+
+		if f.Type.Kind() == reflect.Struct {
+			if hasSecrets(fv) {
+				return true
+			}
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct {
+			for j := 0; j < fv.Len(); j++ {
+				if hasSecrets(fv.Index(j)) {
+					return true
+				}
+			}
+			continue
+		}
+
+		if f.Tag.Get("secret") == "true" && !fv.IsZero() {
+			return true
+		}
+	}
+
+	return false
+}
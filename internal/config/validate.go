@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// формат токена Bot API: <bot_id>:<35 символов>
+var botTokenRe = regexp.MustCompile(`^\d+:[A-Za-z0-9_-]{30,}$`)
+
+// Validate проверяет значения конфигурации и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	switch {
+	case c.BotToken == "":
+		errs = append(errs, fmt.Errorf("BOT_TOKEN or BOT_TOKEN_FILE must be set"))
+	case !botTokenRe.MatchString(c.BotToken):
+		errs = append(errs, fmt.Errorf("BOT_TOKEN has invalid format"))
+	}
+	errs = append(errs, c.validateBots()...)
+	check(c.ChannelID != 0, "CHANNEL_ID must be set")
+	check(c.AdminUserID > 0, "ADMIN_USER_ID must be positive")
+	if u, err := url.Parse(c.TmaURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("TMA_URL must be an absolute URL"))
+	}
+	if u, err := url.Parse(c.CardImagesURL); c.CardImagesURL != "" && (err != nil || u.Scheme != "https") {
+		errs = append(errs, fmt.Errorf("CARD_IMAGES_URL must be an https URL"))
+	}
+	if c.CardImagesDir != "" {
+		info, err := os.Stat(c.CardImagesDir)
+		check(err == nil && info.IsDir(), "CARD_IMAGES_DIR must be an existing directory")
+	}
+
+	errs = append(errs, checkAddr("METRICS_ADDR", c.MetricsAddr))
+	check(oneOf(c.LogFormat, "text", "json"), "LOG_FORMAT must be text or json, got %q", c.LogFormat)
+	check(oneOf(c.LogLevel, "debug", "info", "warn", "error"),
+		"LOG_LEVEL must be debug, info, warn or error, got %q", c.LogLevel)
+	check(c.DBPath != "", "DB_PATH must be set")
+	check(c.BroadcastRate > 0 && c.BroadcastRate <= 30, "BROADCAST_RATE must be between 1 and 30")
+
+	s := c.Server
+	errs = append(errs, checkAddr("HTTP_ADDRESS", s.Address))
+	check(s.ReadTimeout > 0, "HTTP_READ_TIMEOUT must be positive")
+	check(s.WriteTimeout > 0, "HTTP_WRITE_TIMEOUT must be positive")
+	check(s.IdleTimeout > 0, "HTTP_IDLE_TIMEOUT must be positive")
+	check(s.ShutdownTimeout > 0, "HTTP_SHUTDOWN_TIMEOUT must be positive")
+	check(len(s.CORSOrigins) > 0, "HTTP_CORS_ORIGINS must not be empty")
+	for _, origin := range s.CORSOrigins {
+		check(strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://") || origin == "*",
+			"HTTP_CORS_ORIGINS: invalid origin %q", origin)
+	}
+	check(s.RateLimit > 0, "HTTP_RATE_LIMIT must be positive")
+	check(s.RateWindow > 0, "HTTP_RATE_WINDOW must be positive")
+	check(s.ThrottleLimit > 0, "HTTP_THROTTLE_LIMIT must be positive")
+	check(s.ThrottleBacklog >= 0, "HTTP_THROTTLE_BACKLOG must not be negative")
+	check(s.ThrottleTimeout > 0, "HTTP_THROTTLE_TIMEOUT must be positive")
+	check(s.IdempotencyTTL > 0, "HTTP_IDEMPOTENCY_TTL must be positive")
+
+	check(c.InitData.MaxAge >= 0, "INIT_DATA_MAX_AGE must not be negative")
+	check(c.InitData.ProfileSyncInterval >= 0, "INIT_DATA_PROFILE_SYNC_INTERVAL must not be negative")
+	if len(c.InitData.ThirdPartyBotIDs) > 0 {
+		key, err := hex.DecodeString(c.InitData.TelegramPublicKey)
+		check(err == nil && len(key) == 32,
+			"INIT_DATA_TELEGRAM_PUBLIC_KEY must be a 32-byte hex key when INIT_DATA_THIRD_PARTY_BOT_IDS is set")
+	}
+
+	check(c.Session.AccessTTL > 0, "SESSION_ACCESS_TTL must be positive")
+	check(c.Session.RefreshTTL > c.Session.AccessTTL, "SESSION_REFRESH_TTL must be greater than SESSION_ACCESS_TTL")
+	check(c.Session.LoginWidgetMaxAge > 0, "SESSION_LOGIN_WIDGET_MAX_AGE must be positive")
+	check(len(c.Session.Secret) >= 32, "SESSION_SECRET must be set and at least 32 characters")
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+
+	return nil
+}
+
+// validateBots - имена и токены ботов должны быть заданы и не повторяться
+func (c *Config) validateBots() []error {
+	var errs []error
+
+	names := make(map[string]bool)
+	tokens := make(map[string]bool)
+
+	for i, b := range c.AllBots() {
+		// основной бот проверяется по BOT_TOKEN выше
+		if i > 0 {
+			switch {
+			case b.Token == "":
+				errs = append(errs, fmt.Errorf("BOTS[%d]: TOKEN or TOKEN_FILE must be set", i-1))
+			case !botTokenRe.MatchString(b.Token):
+				errs = append(errs, fmt.Errorf("BOTS[%d]: TOKEN has invalid format", i-1))
+			}
+			if u, err := url.Parse(b.TmaURL); err != nil || u.Scheme == "" || u.Host == "" {
+				errs = append(errs, fmt.Errorf("BOTS[%d]: TMA_URL must be an absolute URL", i-1))
+			}
+		}
+
+		if b.Name == "" {
+			errs = append(errs, fmt.Errorf("bot #%d: name must be set", i))
+		} else if names[b.Name] {
+			errs = append(errs, fmt.Errorf("bot name %q is used more than once", b.Name))
+		}
+		names[b.Name] = true
+
+		if b.Token != "" && tokens[b.Token] {
+			errs = append(errs, fmt.Errorf("bot %q: token is used by another bot", b.Name))
+		}
+		tokens[b.Token] = true
+	}
+
+	return errs
+}
+
+func checkAddr(name, addr string) error {
+	if _, port, err := net.SplitHostPort(addr); err != nil || port == "" {
+		return fmt.Errorf("%s must be host:port, got %q", name, addr)
+	}
+	return nil
+}
+
+func oneOf(v string, allowed ...string) bool {
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
@@ -3,76 +3,64 @@ package getuser
 import (
 	"context"
 	"errors"
-	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"taro-api/internal/helpers/telegram"
+	"taro-api/internal/lib/api/dto"
 	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/logger"
 	"taro-api/internal/middlewares"
 	"taro-api/internal/storage"
 	"taro-api/internal/storage/db"
 
-	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/render"
 	initdata "github.com/telegram-mini-apps/init-data-golang"
 )
 
-// UserResponse - структура ответа
-type UserResponse struct {
-	*db.User
+// UserGetter - интерфейс для получения пользователя без побочных эффектов
+type UserGetter interface {
+	GetUser(telegramID int64) (*db.User, error)
 }
 
-type photoResponse []byte
+// UserRegistrar - интерфейс для регистрации пользователя
+type UserRegistrar interface {
+	RegisterUser(telegramID int64, bot string, referrerID int64) (*db.User, bool, error)
+}
 
-// UserGetter - интерфейс для получения пользователя
-type UserGetter interface {
-	GetUserByTelegramID(id, referralID int64, botToken string) (*db.User, error)
+// AvatarGetter - интерфейс для получения аватара пользователя из Telegram
+type AvatarGetter interface {
+	GetUserAvatar(ctx context.Context, telegramID int64) ([]byte, string, error)
 }
 
-// New - создает новый обработчик запроса пользователя
-func New(log *slog.Logger, getter UserGetter, botToken string) http.HandlerFunc {
+// New - создает новый обработчик запроса пользователя; незарегистрированный пользователь
+// получает 404 и должен вызвать POST /users/register
+func New(log *slog.Logger, getter UserGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.user.getByTelegramID"
 
-		log = log.With(
-			slog.String("op", op),
-			slog.String("request_id", middleware.GetReqID(r.Context())),
-		)
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
 
 		initData, ok := ctxInitData(r.Context())
 		if !ok {
-			http.Error(w, "Init data not found", http.StatusUnauthorized)
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
 			return
 		}
 
-		var referralID int64 = 0
-
-		referralIDStr := r.URL.Query().Get("referralID")
-
-		if referralIDStr != "" {
-			var err error
-			referralID, err = strconv.ParseInt(referralIDStr, 10, 64)
-			if err != nil {
-				http.Error(w, "Invalid referralID", http.StatusBadRequest)
-				return
-			}
-		}
-
-		user, err := getter.GetUserByTelegramID(initData.User.ID, referralID, botToken)
+		user, err := getter.GetUser(initData.User.ID)
 
 		if errors.Is(err, storage.ErrUserNotFound) {
 			log.Info("User not found", "slug", initData.User.ID)
 
-			render.JSON(w, r, resp.Error("not found"))
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "user not found")
 
 			return
 		}
 
 		if err != nil {
-			log.Error("failed to get user")
+			log.Error("failed to get user", slog.String("error", err.Error()))
 
-			render.JSON(w, r, resp.Error(err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get user")
 
 			return
 		}
@@ -82,31 +70,75 @@ func New(log *slog.Logger, getter UserGetter, botToken string) http.HandlerFunc
 
 }
 
-// Photo - возвращает аватар пользователя (прокси)
-func Photo(log *slog.Logger, botToken string) http.HandlerFunc {
+// Legacy - GET /me для текущей версии Mini App: регистрирует пользователя при первом запросе
+// и принимает пригласившего в параметре referralID. Включается настройкой HTTP_LEGACY_GET_ME
+// до перехода Mini App на POST /users/register
+func Legacy(log *slog.Logger, registrar UserRegistrar) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.user.legacyGetByTelegramID"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
 		initData, ok := ctxInitData(r.Context())
 		if !ok {
-			http.Error(w, "Init data not found", http.StatusUnauthorized)
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
 			return
 		}
-		originalLink, err := telegram.GetUserAvatarOriginalURL(initData.User.ID, botToken)
+
+		var referralID int64 = 0
+
+		referralIDStr := r.URL.Query().Get("referralID")
+
+		if referralIDStr != "" {
+			var err error
+			referralID, err = strconv.ParseInt(referralIDStr, 10, 64)
+			if err != nil {
+				resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "invalid referralID")
+				return
+			}
+		}
+
+		user, _, err := registrar.RegisterUser(initData.User.ID, middlewares.Bot(r.Context()), referralID)
 		if err != nil {
-			slog.Error("Error getting avatar URL", "error", err)
+			log.Error("failed to get user", slog.String("error", err.Error()))
+
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get user")
+
 			return
 		}
-		imageResp, err := http.Get(originalLink)
-		if err != nil {
-			slog.Error("Error fetching image", "error", err)
+
+		w.Header().Set("Deprecation", "true")
+		responseUser(w, r, user)
+	}
+}
+
+// Photo - возвращает аватар пользователя (прокси). Аватар запрашивается от бота, через который
+// пришел пользователь: avatars возвращает клиент Bot API по имени бота
+func Photo(log *slog.Logger, avatars func(bot string) AvatarGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.user.photo"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		avatar := avatars(middlewares.Bot(r.Context()))
+		imageData, contentType, err := avatar.GetUserAvatar(r.Context(), initData.User.ID)
+		if errors.Is(err, telegram.ErrNoPhoto) {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "photo not found")
 			return
 		}
-		defer imageResp.Body.Close()
-		imageData, err := io.ReadAll(imageResp.Body)
 		if err != nil {
-			slog.Error("Error reading image data", "error", err)
+			log.Error("failed to get avatar", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusBadGateway, resp.CodeUpstream, "failed to get photo")
 			return
 		}
-		responsePhoto(w, imageData)
+
+		responsePhoto(w, contentType, imageData)
 	}
 }
 
@@ -116,13 +148,10 @@ func ctxInitData(ctx context.Context) (initdata.InitData, bool) {
 }
 
 func responseUser(w http.ResponseWriter, r *http.Request, user *db.User) {
-	render.JSON(w, r, UserResponse{
-		User: user,
-	})
+	render.JSON(w, r, dto.NewUser(user))
 }
 
-func responsePhoto(w http.ResponseWriter, imageData []byte) {
-	w.Header().Set("Content-Type", "image/jpeg")
+func responsePhoto(w http.ResponseWriter, contentType string, imageData []byte) {
+	w.Header().Set("Content-Type", contentType)
 	w.Write(imageData)
-	// render.JSON(w, r, photoResponse(imageData))
 }
@@ -0,0 +1,127 @@
+package getuser_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"taro-api/internal/handlers/api/getuser"
+	"taro-api/internal/helpers/telegram"
+	"taro-api/internal/helpers/telegram/telegramtest"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/middlewares"
+	"testing"
+	"time"
+
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+const testToken = "123456:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+func TestPhoto(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	avatar := []byte("\x89PNG\r\n\x1a\nfake png")
+	srv.SetUserAvatar(42, avatar)
+	srv.SetChat(telegram.Chat{ID: 43, Type: "private"})
+
+	client := srv.Client(telegram.WithRetries(0, time.Millisecond))
+	handler := getuser.Photo(slog.New(slog.NewTextHandler(io.Discard, nil)),
+		func(string) getuser.AvatarGetter { return client })
+
+	tests := []struct {
+		name        string
+		userID      int64
+		status      int
+		code        string
+		contentType string
+	}{
+		{name: "avatar", userID: 42, status: http.StatusOK, contentType: "image/png"},
+		{name: "no photo", userID: 43, status: http.StatusNotFound, code: resp.CodeNotFound},
+		// getChat неизвестного чата возвращает ok:false
+		{name: "telegram error", userID: 44, status: http.StatusBadGateway, code: resp.CodeUpstream},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/me/photo", nil)
+			r = r.WithContext(context.WithValue(r.Context(), middlewares.InitDataKey,
+				initdata.InitData{User: initdata.User{ID: tt.userID}}))
+			w := httptest.NewRecorder()
+
+			handler(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.code == "" {
+				if got := w.Header().Get("Content-Type"); got != tt.contentType {
+					t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+				}
+				if w.Body.String() != string(avatar) {
+					t.Errorf("body = %q, want avatar", w.Body.String())
+				}
+				return
+			}
+
+			var body resp.Response
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("decode error response: %v", err)
+			}
+			if body.Code != tt.code {
+				t.Errorf("code = %q, want %q", body.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestPhotoWithoutInitData(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	client := srv.Client()
+	handler := getuser.Photo(slog.New(slog.NewTextHandler(io.Discard, nil)),
+		func(string) getuser.AvatarGetter { return client })
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/me/photo", nil))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if calls := srv.Calls("getChat"); calls != 0 {
+		t.Errorf("getChat called %d times without init data", calls)
+	}
+}
+
+func TestPhotoUsesUserBot(t *testing.T) {
+	primary := telegramtest.NewServer(testToken)
+	defer primary.Close()
+	dev := telegramtest.NewServer("654321:BBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB")
+	defer dev.Close()
+
+	// пользователь dev бота не виден основному боту
+	dev.SetUserAvatar(42, []byte("\x89PNG\r\n\x1a\ndev avatar"))
+
+	clients := map[string]*telegram.Client{"main": primary.Client(), "dev": dev.Client()}
+	handler := getuser.Photo(slog.New(slog.NewTextHandler(io.Discard, nil)),
+		func(bot string) getuser.AvatarGetter { return clients[bot] })
+
+	r := httptest.NewRequest(http.MethodGet, "/me/photo", nil)
+	ctx := context.WithValue(r.Context(), middlewares.InitDataKey, initdata.InitData{User: initdata.User{ID: 42}})
+	ctx = context.WithValue(ctx, middlewares.BotKey, "dev")
+	w := httptest.NewRecorder()
+
+	handler(w, r.WithContext(ctx))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if primary.Calls("getChat") != 0 || dev.Calls("getChat") != 1 {
+		t.Errorf("getChat calls: primary %d, dev %d; want the avatar from the dev bot",
+			primary.Calls("getChat"), dev.Calls("getChat"))
+	}
+}
@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"taro-api/internal/lib/api/dto"
+	"taro-api/internal/lib/buildinfo"
+	"taro-api/internal/lib/logger"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// Статусы проверок
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+const checkTimeout = 3 * time.Second
+
+// Check - именованная проверка зависимости
+type Check struct {
+	Name string
+	Func func(ctx context.Context) error
+}
+
+// Cached - проверка, результат которой используется повторно в течение ttl. Для проверок
+// через внешние API: пробы балансировщика и pm2 не должны каждый раз ходить в Telegram.
+// Одновременные пробы дожидаются одной проверки, а не запускают свои
+func Cached(check Check, ttl time.Duration) Check {
+	var (
+		mu        sync.Mutex
+		err       error
+		checkedAt time.Time
+	)
+
+	return Check{
+		Name: check.Name,
+		Func: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if !checkedAt.IsZero() && time.Since(checkedAt) < ttl {
+				return err
+			}
+
+			err = check.Func(ctx)
+			// отмененная клиентом проба ничего не говорит о зависимости
+			if errors.Is(err, context.Canceled) {
+				checkedAt = time.Time{}
+				return err
+			}
+			checkedAt = time.Now()
+			return err
+		},
+	}
+}
+
+// Live - процесс жив и обслуживает HTTP
+func Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, dto.Health{Status: StatusOK})
+	}
+}
+
+// Ready - все зависимости доступны, иначе 503
+func Ready(log *slog.Logger, checks ...Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.ready"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		result := dto.Health{
+			Status: StatusOK,
+			Checks: make(map[string]string, len(checks)),
+		}
+
+		for _, check := range checks {
+			ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+			err := check.Func(ctx)
+			cancel()
+
+			if err != nil {
+				log.Warn("readiness check failed",
+					slog.String("check", check.Name),
+					slog.String("error", err.Error()),
+				)
+				result.Status = StatusFail
+				result.Checks[check.Name] = StatusFail
+				continue
+			}
+			result.Checks[check.Name] = StatusOK
+		}
+
+		if result.Status != StatusOK {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+		render.JSON(w, r, result)
+	}
+}
+
+// Version - сведения о сборке
+func Version() http.HandlerFunc {
+	info := buildinfo.Get()
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, info)
+	}
+}
@@ -0,0 +1,150 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"taro-api/internal/handlers/api/health"
+	"taro-api/internal/lib/api/dto"
+	"testing"
+	"time"
+)
+
+func TestReady(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		result dto.Health
+	}{
+		{
+			name:   "ready",
+			status: http.StatusOK,
+			result: dto.Health{Status: health.StatusOK, Checks: map[string]string{"db": "ok", "bot": "ok"}},
+		},
+		{
+			name:   "bot unavailable",
+			err:    errors.New("telegram getMe: 401 Unauthorized"),
+			status: http.StatusServiceUnavailable,
+			result: dto.Health{Status: health.StatusFail, Checks: map[string]string{"db": "ok", "bot": "fail"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := health.Ready(log,
+				health.Check{Name: "db", Func: func(context.Context) error { return nil }},
+				health.Check{Name: "bot", Func: func(context.Context) error { return tt.err }},
+			)
+
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+
+			var got dto.Health
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.result.Status || len(got.Checks) != len(tt.result.Checks) {
+				t.Fatalf("result = %+v, want %+v", got, tt.result)
+			}
+			for name, status := range tt.result.Checks {
+				if got.Checks[name] != status {
+					t.Errorf("check %s = %q, want %q", name, got.Checks[name], status)
+				}
+			}
+		})
+	}
+}
+
+func TestCached(t *testing.T) {
+	var calls atomic.Int32
+	fail := errors.New("unavailable")
+
+	check := health.Cached(health.Check{Name: "bot", Func: func(context.Context) error {
+		if calls.Add(1) == 1 {
+			return fail
+		}
+		return nil
+	}}, 50*time.Millisecond)
+
+	if check.Name != "bot" {
+		t.Errorf("name = %q, want bot", check.Name)
+	}
+
+	// ошибка тоже кэшируется: упавший Telegram не должен получать запрос на каждую пробу
+	for i := 0; i < 3; i++ {
+		if err := check.Func(context.Background()); !errors.Is(err, fail) {
+			t.Fatalf("call %d: err = %v, want cached failure", i, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("check ran %d times within ttl, want 1", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if err := check.Func(context.Background()); err != nil {
+		t.Fatalf("after ttl: err = %v, want fresh result", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("check ran %d times, want 2 after ttl", n)
+	}
+}
+
+func TestCachedConcurrent(t *testing.T) {
+	var calls atomic.Int32
+
+	check := health.Cached(health.Check{Name: "bot", Func: func(context.Context) error {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}}, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check.Func(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("concurrent probes ran the check %d times, want 1", n)
+	}
+}
+
+func TestCachedCanceled(t *testing.T) {
+	var calls atomic.Int32
+
+	check := health.Cached(health.Check{Name: "bot", Func: func(ctx context.Context) error {
+		calls.Add(1)
+		return ctx.Err()
+	}}, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := check.Func(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if err := check.Func(context.Background()); err != nil {
+		t.Fatalf("err = %v, want result of a new check", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("check ran %d times, want 2: canceled probe must not be cached", n)
+	}
+}
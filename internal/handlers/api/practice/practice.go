@@ -0,0 +1,232 @@
+package practice
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"taro-api/internal/lib/api/dto"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/lib/practice"
+	"taro-api/internal/lib/tarot"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// PracticeScheduler - интерфейс для выдачи тренировочных раскладов
+type PracticeScheduler interface {
+	GetOpenPractice(telegramID int64) (*db.Practice, error)
+	GetPracticeProgress(telegramID int64) ([]practice.Progress, error)
+	CreatePractice(p *db.Practice) error
+}
+
+// PracticeAnswerer - интерфейс для ответа на тренировочный расклад
+type PracticeAnswerer interface {
+	GetPractice(id uuid.UUID, telegramID int64) (*db.Practice, error)
+	AnswerPractice(id uuid.UUID, telegramID int64, a db.PracticeAnswer) (*db.User, error)
+}
+
+// PracticeStatsGetter - интерфейс для получения успехов в тренировках
+type PracticeStatsGetter interface {
+	GetPracticeStats(telegramID int64) (*db.PracticeStats, error)
+}
+
+// validate - общий валидатор: validator.New разбирает теги заново на каждый вызов
+var validate = validator.New()
+
+// Next - тренировочный расклад текущего пользователя: неотвеченный, если он есть,
+// иначе новый с картами, которые пора повторить
+func Next(log *slog.Logger, scheduler PracticeScheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.practice.next"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		p, err := scheduler.GetOpenPractice(initData.User.ID)
+		if err == nil {
+			render.JSON(w, r, dto.NewPractice(p, i18n.FromContext(r.Context())))
+			return
+		}
+		if !errors.Is(err, storage.ErrPracticeNotFound) {
+			log.Error("failed to get open practice", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get practice")
+			return
+		}
+
+		progress, err := scheduler.GetPracticeProgress(initData.User.ID)
+		if err != nil {
+			log.Error("failed to get practice progress", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get practice")
+			return
+		}
+
+		exercise := practice.Next(progress, time.Now())
+		p = &db.Practice{
+			TelegramID: initData.User.ID,
+			Spread:     exercise.Spread.ID,
+			Question:   exercise.Question.ID,
+			Cards:      make([]db.ReadingCard, 0, len(exercise.Cards)),
+		}
+		for _, d := range exercise.Cards {
+			p.Cards = append(p.Cards, db.ReadingCard{Card: d.Card.ID, Reversed: d.Reversed})
+		}
+
+		err = scheduler.CreatePractice(p)
+		if errors.Is(err, storage.ErrUserNotFound) {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "user not found")
+			return
+		}
+		if err != nil {
+			log.Error("failed to create practice", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to create practice")
+			return
+		}
+
+		render.JSON(w, r, dto.NewPractice(p, i18n.FromContext(r.Context())))
+	}
+}
+
+// Answer - оценивает толкования позиций тренировочного расклада по ключевым словам значений
+// карт, переносит карты по ящикам повторения и продлевает серию дней
+func Answer(log *slog.Logger, answerer PracticeAnswerer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.practice.answer"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "practice not found")
+			return
+		}
+
+		var req dto.PracticeAnswerRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "empty request")
+			return
+		}
+		if err != nil {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "failed to decode request")
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			resp.Render(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		p, err := answerer.GetPractice(id, initData.User.ID)
+		if errors.Is(err, storage.ErrPracticeNotFound) {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "practice not found")
+			return
+		}
+		if err != nil {
+			log.Error("failed to get practice", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get practice")
+			return
+		}
+		if p.AnsweredAt != nil {
+			resp.RenderError(w, r, http.StatusConflict, resp.CodePracticeAnswered, "practice is already answered")
+			return
+		}
+		if len(req.Interpretations) != len(p.Cards) {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "interpretations do not match positions")
+			return
+		}
+
+		// оценивается на языке пользователя: на нем он видел названия карт и позиций
+		l := i18n.FromContext(r.Context())
+
+		grades := make([]practice.Grade, 0, len(p.Cards))
+		answer := db.PracticeAnswer{Answers: req.Interpretations, Correct: make([]bool, 0, len(p.Cards))}
+		for i, c := range p.Cards {
+			card, _ := tarot.ByID(c.Card)
+			g := practice.GradeAnswer(l, tarot.Drawn{Card: card, Reversed: c.Reversed}, req.Interpretations[i])
+			grades = append(grades, g)
+			answer.Correct = append(answer.Correct, g.Correct)
+			answer.Score += g.Score
+		}
+		answer.Score /= len(grades)
+
+		user, err := answerer.AnswerPractice(id, initData.User.ID, answer)
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "user not found")
+			return
+		case errors.Is(err, storage.ErrPracticeNotFound):
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "practice not found")
+			return
+		case errors.Is(err, storage.ErrPracticeAnswered):
+			resp.RenderError(w, r, http.StatusConflict, resp.CodePracticeAnswered, "practice is already answered")
+			return
+		case err != nil:
+			log.Error("failed to answer practice", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to answer practice")
+			return
+		}
+
+		log.Info("practice answered", slog.String("practice_id", id.String()), slog.Int("score", answer.Score))
+
+		render.JSON(w, r, dto.NewPracticeResult(p, user, req.Interpretations, grades, answer.Score, l))
+	}
+}
+
+// Stats - серия дней с тренировкой, средняя оценка и успехи по картам
+func Stats(log *slog.Logger, getter PracticeStatsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.practice.stats"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		stats, err := getter.GetPracticeStats(initData.User.ID)
+		if errors.Is(err, storage.ErrUserNotFound) {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "user not found")
+			return
+		}
+		if err != nil {
+			log.Error("failed to get practice stats", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get practice stats")
+			return
+		}
+
+		render.JSON(w, r, dto.NewPracticeStats(stats, i18n.FromContext(r.Context())))
+	}
+}
+
+func ctxInitData(ctx context.Context) (initdata.InitData, bool) {
+	initData, ok := ctx.Value(middlewares.InitDataKey).(initdata.InitData)
+	return initData, ok
+}
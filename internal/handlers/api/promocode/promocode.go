@@ -0,0 +1,100 @@
+package promocode
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"taro-api/internal/lib/api/dto"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// PromoRedeemer - интерфейс для активации промокодов
+type PromoRedeemer interface {
+	RedeemPromoCode(telegramID int64, code string) (*db.PromoRedemption, *db.User, error)
+}
+
+// Redeem - активирует промокод текущего пользователя
+func Redeem(log *slog.Logger, redeemer PromoRedeemer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.promocode.redeem"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		var req dto.PromoRedeemRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "empty request")
+			return
+		}
+		if err != nil {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "failed to decode request")
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			resp.Render(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		redemption, user, err := redeemer.RedeemPromoCode(initData.User.ID, req.Code)
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "user not found")
+			return
+		case errors.Is(err, storage.ErrUserBlocked):
+			resp.RenderError(w, r, http.StatusForbidden, resp.CodeForbidden, "user is blocked")
+			return
+		case errors.Is(err, storage.ErrPromoNotFound):
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodePromoNotFound, "promo code not found")
+			return
+		case errors.Is(err, storage.ErrPromoInactive):
+			resp.RenderError(w, r, http.StatusConflict, resp.CodePromoInactive, "promo code is not active")
+			return
+		case errors.Is(err, storage.ErrPromoExhausted):
+			resp.RenderError(w, r, http.StatusConflict, resp.CodePromoExhausted, "promo code is exhausted")
+			return
+		case errors.Is(err, storage.ErrPromoAlreadyRedeemed):
+			resp.RenderError(w, r, http.StatusConflict, resp.CodePromoRedeemed, "promo code is already redeemed")
+			return
+		case err != nil:
+			log.Error("failed to redeem promo code", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to redeem promo code")
+			return
+		}
+
+		log.Info("promo code redeemed", slog.String("code", redemption.Code))
+
+		render.JSON(w, r, dto.PromoRedemption{
+			Code:             redemption.Code,
+			RewardType:       redemption.RewardType,
+			Amount:           redemption.Amount,
+			Entitlement:      redemption.Entitlement,
+			EntitlementUntil: redemption.EntitlementUntil,
+			Balance:          user.Balance,
+		})
+	}
+}
+
+func ctxInitData(ctx context.Context) (initdata.InitData, bool) {
+	initData, ok := ctx.Value(middlewares.InitDataKey).(initdata.InitData)
+	return initData, ok
+}
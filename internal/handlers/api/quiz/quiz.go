@@ -0,0 +1,270 @@
+package quiz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"taro-api/internal/lib/api/dto"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/lib/quiz"
+	"taro-api/internal/lib/tarot"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// QuizScheduler - интерфейс для выдачи вопросов карточек
+type QuizScheduler interface {
+	GetOpenQuizQuestion(telegramID int64) (*db.QuizQuestion, error)
+	GetQuizItems(telegramID int64) ([]quiz.Item, error)
+	CreateQuizQuestion(q *db.QuizQuestion) error
+}
+
+// QuizAnswerer - интерфейс для ответа на вопрос карточки
+type QuizAnswerer interface {
+	GetQuizQuestion(id uuid.UUID, telegramID int64) (*db.QuizQuestion, error)
+	AnswerQuiz(id uuid.UUID, telegramID int64, a db.QuizAnswer) (*db.QuizOutcome, error)
+}
+
+// AchievementNotifier - поздравляет пользователя с достижением в боте bot
+type AchievementNotifier func(bot string, telegramID int64, l i18n.Lang, a db.Achievement) error
+
+// Jobs - фоновые задачи, которые дожидаются при остановке приложения (lifecycle.InFlight);
+// Go возвращает false, если остановка уже началась
+type Jobs interface {
+	Go(fn func()) bool
+}
+
+// validate - общий валидатор: validator.New разбирает теги заново на каждый вызов
+var validate = validator.New()
+
+// Next - следующий вопрос карточки текущего пользователя. Неотвеченный вопрос
+// выдается снова, если подходит под запрошенные вид и формат
+func Next(log *slog.Logger, scheduler QuizScheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.quiz.next"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		format := quiz.Format(r.URL.Query().Get("format"))
+		if format == "" {
+			format = quiz.FormatChoice
+		}
+		if format != quiz.FormatChoice && format != quiz.FormatText {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "unknown format")
+			return
+		}
+
+		var kinds []quiz.Kind
+		if kind := quiz.Kind(r.URL.Query().Get("kind")); kind != "" {
+			if !slices.Contains(quiz.Kinds, kind) {
+				resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "unknown kind")
+				return
+			}
+			kinds = []quiz.Kind{kind}
+		}
+
+		l := i18n.FromContext(r.Context())
+
+		q, err := scheduler.GetOpenQuizQuestion(initData.User.ID)
+		switch {
+		case err == nil:
+			if matches(q, kinds, format) {
+				render.JSON(w, r, dto.NewQuizQuestion(q, l))
+				return
+			}
+		case !errors.Is(err, storage.ErrQuizNotFound):
+			log.Error("failed to get open quiz question", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get quiz question")
+			return
+		}
+
+		items, err := scheduler.GetQuizItems(initData.User.ID)
+		if err != nil {
+			log.Error("failed to get quiz items", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get quiz question")
+			return
+		}
+
+		next := quiz.Next(items, kinds, format, time.Now())
+		q = &db.QuizQuestion{
+			TelegramID: initData.User.ID,
+			Kind:       string(next.Kind),
+			Format:     string(next.Format),
+			Card:       next.Card.Card.ID,
+			Reversed:   next.Card.Reversed,
+			Answer:     next.Answer,
+		}
+		for _, o := range next.Options {
+			q.Options = append(q.Options, db.ReadingCard{Card: o.Card.ID, Reversed: o.Reversed})
+		}
+
+		err = scheduler.CreateQuizQuestion(q)
+		if errors.Is(err, storage.ErrUserNotFound) {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "user not found")
+			return
+		}
+		if err != nil {
+			log.Error("failed to create quiz question", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to create quiz question")
+			return
+		}
+
+		render.JSON(w, r, dto.NewQuizQuestion(q, l))
+	}
+}
+
+// Answer - проверяет ответ на вопрос карточки и переносит ее повторение по SM-2.
+// Полученные ответом достижения возвращаются в ответе и приходят сообщением в бот;
+// сообщения отправляются задачами jobs, чтобы остановка приложения их дождалась
+func Answer(log *slog.Logger, answerer QuizAnswerer, notify AchievementNotifier, jobs Jobs) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.quiz.answer"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		var req dto.QuizAnswerRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "empty request")
+			return
+		}
+		if err != nil {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "failed to decode request")
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			resp.Render(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		id := uuid.MustParse(req.QuestionID)
+
+		q, err := answerer.GetQuizQuestion(id, initData.User.ID)
+		if errors.Is(err, storage.ErrQuizNotFound) {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "quiz question not found")
+			return
+		}
+		if err != nil {
+			log.Error("failed to get quiz question", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get quiz question")
+			return
+		}
+		if q.AnsweredAt != nil {
+			resp.RenderError(w, r, http.StatusConflict, resp.CodeQuizAnswered, "quiz question is already answered")
+			return
+		}
+
+		option, text := -1, strings.TrimSpace(req.Answer)
+		switch quiz.Format(q.Format) {
+		case quiz.FormatChoice:
+			if req.Option == nil || *req.Option < 0 || *req.Option >= len(q.Options) {
+				resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "option is required")
+				return
+			}
+			option, text = *req.Option, strconv.Itoa(*req.Option)
+		default:
+			if text == "" {
+				resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "answer is required")
+				return
+			}
+		}
+
+		l := i18n.FromContext(r.Context())
+		result := quiz.Check(question(q), l, option, text)
+
+		outcome, err := answerer.AnswerQuiz(id, initData.User.ID, db.QuizAnswer{
+			Response: text,
+			Correct:  result.Correct,
+			Quality:  result.Quality,
+		})
+		switch {
+		case errors.Is(err, storage.ErrQuizNotFound):
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "quiz question not found")
+			return
+		case errors.Is(err, storage.ErrQuizAnswered):
+			resp.RenderError(w, r, http.StatusConflict, resp.CodeQuizAnswered, "quiz question is already answered")
+			return
+		case err != nil:
+			log.Error("failed to answer quiz question", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to answer quiz question")
+			return
+		}
+
+		bot := middlewares.Bot(r.Context())
+		for _, a := range outcome.Achievements {
+			log.Info("achievement unlocked", slog.String("achievement", a.Code))
+
+			// уведомление не должно задерживать ответ, а его ошибка - отменять достижение
+			started := jobs.Go(func() {
+				if err := notify(bot, initData.User.ID, l, a); err != nil {
+					log.Warn("failed to notify about achievement", slog.String("error", err.Error()))
+				}
+			})
+			if !started {
+				log.Warn("shutting down, achievement notification skipped", slog.String("achievement", a.Code))
+			}
+		}
+
+		render.JSON(w, r, dto.NewQuizResult(q, result.Correct, outcome, l))
+	}
+}
+
+// matches - подходит ли вопрос под запрошенные виды и формат, см. quiz.Normalize
+func matches(q *db.QuizQuestion, kinds []quiz.Kind, format quiz.Format) bool {
+	kinds, format = quiz.Normalize(kinds, format)
+	return slices.Contains(kinds, quiz.Kind(q.Kind)) && quiz.Format(q.Format) == format
+}
+
+// question - вопрос из модели хранилища для проверки ответа
+func question(q *db.QuizQuestion) quiz.Question {
+	card, _ := tarot.ByID(q.Card)
+
+	options := make([]tarot.Drawn, 0, len(q.Options))
+	for _, o := range q.Options {
+		c, _ := tarot.ByID(o.Card)
+		options = append(options, tarot.Drawn{Card: c, Reversed: o.Reversed})
+	}
+
+	return quiz.Question{
+		Kind:    quiz.Kind(q.Kind),
+		Format:  quiz.Format(q.Format),
+		Card:    tarot.Drawn{Card: card, Reversed: q.Reversed},
+		Options: options,
+		Answer:  q.Answer,
+	}
+}
+
+func ctxInitData(ctx context.Context) (initdata.InitData, bool) {
+	initData, ok := ctx.Value(middlewares.InitDataKey).(initdata.InitData)
+	return initData, ok
+}
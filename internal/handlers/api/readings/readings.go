@@ -0,0 +1,63 @@
+package readings
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"taro-api/internal/lib/api/dto"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+// ReadingGetter - интерфейс для получения расклада
+type ReadingGetter interface {
+	GetReading(id uuid.UUID) (*db.Reading, error)
+}
+
+// Get - расклад по ID. Доступен любому авторизованному пользователю: ссылками на расклады
+// делятся, а ID невозможно подобрать. Автор и вопрос видны только автору
+func Get(log *slog.Logger, getter ReadingGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.readings.get"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "reading not found")
+			return
+		}
+
+		reading, err := getter.GetReading(id)
+		if errors.Is(err, storage.ErrReadingNotFound) {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "reading not found")
+			return
+		}
+		if err != nil {
+			log.Error("failed to get reading", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get reading")
+			return
+		}
+
+		l := i18n.FromContext(r.Context())
+		if reading.TelegramID != initData.User.ID {
+			render.JSON(w, r, dto.NewPublicReading(reading, l))
+			return
+		}
+
+		render.JSON(w, r, dto.NewReading(reading, l))
+	}
+}
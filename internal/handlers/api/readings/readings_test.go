@@ -0,0 +1,68 @@
+package readings_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"taro-api/internal/handlers/api/readings"
+	"taro-api/internal/lib/api/dto"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage/db"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+func TestGet(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	reading := &db.Reading{ID: uuid.New(), TelegramID: 42, Spread: "three", Question: "secret",
+		Cards: []db.ReadingCard{{Card: "fool"}, {Card: "star"}, {Card: "sun"}}}
+
+	router := chi.NewRouter()
+	router.Get("/readings/{id}", readings.Get(log, readingGetter{reading.ID: reading}))
+
+	get := func(userID int64) map[string]any {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, "/readings/"+reading.ID.String(), nil)
+		r = r.WithContext(context.WithValue(r.Context(), middlewares.InitDataKey,
+			initdata.InitData{User: initdata.User{ID: userID}}))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var body map[string]any
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	own := get(42)
+	if own["question"] != "secret" || own["telegram_id"] != float64(42) {
+		t.Errorf("own reading = %v, want question and telegram_id", own)
+	}
+
+	// тот, с кем поделились, видит карты, но не автора и вопрос
+	shared := get(43)
+	if _, ok := shared["question"]; ok {
+		t.Errorf("shared reading has question: %v", shared)
+	}
+	if _, ok := shared["telegram_id"]; ok {
+		t.Errorf("shared reading has telegram_id: %v", shared)
+	}
+	if cards, _ := shared["cards"].([]any); len(cards) != 3 {
+		t.Errorf("shared reading cards = %v, want 3", shared["cards"])
+	}
+
+	public := dto.NewPublicReading(reading, i18n.EN)
+	if public.Question != "" || public.TelegramID != 0 || reading.Question != "secret" {
+		t.Errorf("public reading = %+v, source = %+v", public, reading)
+	}
+}
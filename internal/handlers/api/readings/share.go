@@ -0,0 +1,234 @@
+package readings
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"taro-api/internal/lib/api/dto"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/lib/sharecard"
+	"taro-api/internal/lib/sharetoken"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+const (
+	// maxConcurrentRenders - сколько картинок рисуется одновременно, остальные запросы ждут:
+	// картинка рисуется десятки миллисекунд процессорного времени
+	maxConcurrentRenders = 2
+	// shareCacheSize - сколько готовых картинок хранится в памяти (около 100 КБ каждая)
+	shareCacheSize = 128
+)
+
+// ShareRenderer - интерфейс для картинки расклада
+type ShareRenderer interface {
+	Render(w io.Writer, l i18n.Lang, r *db.Reading, opts sharecard.Options) error
+}
+
+// ShareLink - ссылка на картинку расклада для автора: токен с ограниченным сроком действия,
+// вопрос показывается только с include_question. Чужие расклады - 404, как несуществующие
+func ShareLink(log *slog.Logger, getter ReadingGetter, tokens *sharetoken.Signer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.readings.shareLink"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		// тело необязательно: картинка без вопроса
+		var req dto.ShareImageRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "failed to decode request")
+			return
+		}
+
+		id, err := uuid.Parse(chi.URLParam(r, "id"))
+		if err != nil {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "reading not found")
+			return
+		}
+
+		reading, err := getter.GetReading(id)
+		if errors.Is(err, storage.ErrReadingNotFound) ||
+			(err == nil && reading.TelegramID != initData.User.ID) {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "reading not found")
+			return
+		}
+		if err != nil {
+			log.Error("failed to get reading", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get reading")
+			return
+		}
+
+		token, expiresAt := tokens.Issue(reading.ID, req.IncludeQuestion)
+
+		render.JSON(w, r, dto.ShareImage{
+			URL:       "/readings/" + reading.ID.String() + "/share.png?" + url.Values{"token": {token}}.Encode(),
+			ExpiresAt: expiresAt,
+		})
+	}
+}
+
+// Share - PNG картинка расклада для сторис и чатов. Доступна без авторизации: картинку
+// загружают серверы Telegram (shareToStory, превью ссылок), доступ дает токен из ShareLink.
+// Язык - параметр lang, иначе Accept-Language. link возвращает ссылку для QR по автору расклада
+func Share(log *slog.Logger, getter ReadingGetter, renderer ShareRenderer, tokens *sharetoken.Signer,
+	link func(telegramID int64) string) http.HandlerFunc {
+	cache := newShareCache(shareCacheSize)
+	renders := make(chan struct{}, maxConcurrentRenders)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.readings.share"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		// неверный и истекший токен не отличаются от несуществующего расклада
+		claims, err := tokens.Parse(r.URL.Query().Get("token"))
+		if err != nil || claims.ReadingID.String() != chi.URLParam(r, "id") {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "reading not found")
+			return
+		}
+
+		l := i18n.FromContext(r.Context())
+		if code := r.URL.Query().Get("lang"); code != "" {
+			l = i18n.Match(code)
+		}
+
+		key := shareKey{id: claims.ReadingID, lang: l, question: claims.Question}
+		image, ok := cache.get(key)
+		if !ok {
+			image, err = renderShare(r.Context(), renders, cache, key, getter, renderer, link)
+			if errors.Is(err, storage.ErrReadingNotFound) {
+				resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "reading not found")
+				return
+			}
+			if err != nil && r.Context().Err() != nil {
+				// клиент не дождался очереди
+				return
+			}
+			if err != nil {
+				log.Error("failed to render share image", slog.String("error", err.Error()))
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to render image")
+				return
+			}
+		}
+
+		// на картинке может быть вопрос автора, общие кэши ее хранить не должны
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "private, max-age=3600")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write(image)
+	}
+}
+
+// renderShare - рисует картинку, дождавшись очереди; картинка рисуется целиком до ответа,
+// чтобы при ошибке вернуть JSON, а не обрывок PNG
+func renderShare(ctx context.Context, renders chan struct{}, cache *shareCache, key shareKey,
+	getter ReadingGetter, renderer ShareRenderer, link func(telegramID int64) string) ([]byte, error) {
+	select {
+	case renders <- struct{}{}:
+		defer func() { <-renders }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// пока запрос ждал очереди, ту же картинку мог нарисовать другой
+	if image, ok := cache.get(key); ok {
+		return image, nil
+	}
+
+	reading, err := getter.GetReading(key.id)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = renderer.Render(&buf, key.lang, reading, sharecard.Options{
+		Link:     link(reading.TelegramID),
+		Question: key.question,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache.put(key, buf.Bytes())
+	return buf.Bytes(), nil
+}
+
+// shareKey - картинка зависит только от расклада, языка и того, показан ли вопрос:
+// расклад после сохранения не меняется
+type shareKey struct {
+	id       uuid.UUID
+	lang     i18n.Lang
+	question bool
+}
+
+// shareCache - готовые картинки, при переполнении вытесняются давно не запрошенные
+type shareCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[shareKey]*list.Element
+}
+
+type shareEntry struct {
+	key   shareKey
+	image []byte
+}
+
+func newShareCache(size int) *shareCache {
+	return &shareCache{size: size, order: list.New(), items: make(map[shareKey]*list.Element)}
+}
+
+func (c *shareCache) get(key shareKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*shareEntry).image, true
+}
+
+func (c *shareCache) put(key shareKey, image []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		e.Value.(*shareEntry).image = image
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&shareEntry{key: key, image: image})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*shareEntry).key)
+	}
+}
+
+func ctxInitData(ctx context.Context) (initdata.InitData, bool) {
+	initData, ok := ctx.Value(middlewares.InitDataKey).(initdata.InitData)
+	return initData, ok
+}
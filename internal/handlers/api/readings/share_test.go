@@ -0,0 +1,125 @@
+package readings_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"taro-api/internal/handlers/api/readings"
+	"taro-api/internal/lib/api/dto"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/sharecard"
+	"taro-api/internal/lib/sharetoken"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+type readingGetter map[uuid.UUID]*db.Reading
+
+func (g readingGetter) GetReading(id uuid.UUID) (*db.Reading, error) {
+	if reading, ok := g[id]; ok {
+		return reading, nil
+	}
+	return nil, storage.ErrReadingNotFound
+}
+
+// shareRenderer - запоминает параметры картинок вместо рисования
+type shareRenderer struct {
+	mu    sync.Mutex
+	calls []sharecard.Options
+}
+
+func (r *shareRenderer) Render(w io.Writer, _ i18n.Lang, _ *db.Reading, opts sharecard.Options) error {
+	r.mu.Lock()
+	r.calls = append(r.calls, opts)
+	r.mu.Unlock()
+	_, err := w.Write([]byte("\x89PNG\r\n\x1a\nfake png"))
+	return err
+}
+
+func TestShare(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	reading := &db.Reading{ID: uuid.New(), TelegramID: 42, Question: "secret"}
+	getter := readingGetter{reading.ID: reading}
+	renderer := &shareRenderer{}
+	tokens := sharetoken.NewSigner([]byte(strings.Repeat("s", 32)), time.Hour)
+
+	router := chi.NewRouter()
+	router.Post("/readings/{id}/share", readings.ShareLink(log, getter, tokens))
+	router.Get("/readings/{id}/share.png", readings.Share(log, getter, renderer, tokens,
+		func(int64) string { return "https://t.me/taroki_test_bot" }))
+
+	do := func(method, target, body string, userID int64) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if userID != 0 {
+			r = r.WithContext(context.WithValue(r.Context(), middlewares.InitDataKey,
+				initdata.InitData{User: initdata.User{ID: userID}}))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+	link := func(body string) string {
+		t.Helper()
+		w := do(http.MethodPost, "/readings/"+reading.ID.String()+"/share", body, 42)
+		if w.Code != http.StatusOK {
+			t.Fatalf("share link: status %d: %s", w.Code, w.Body.String())
+		}
+		var image dto.ShareImage
+		if err := json.NewDecoder(w.Body).Decode(&image); err != nil {
+			t.Fatal(err)
+		}
+		return image.URL
+	}
+
+	// чужой расклад не отличается от несуществующего
+	if w := do(http.MethodPost, "/readings/"+reading.ID.String()+"/share", "", 43); w.Code != http.StatusNotFound {
+		t.Errorf("share link for another user: status %d, want 404", w.Code)
+	}
+
+	withoutQuestion := link("")
+	withQuestion := link(`{"include_question":true}`)
+
+	for _, target := range []string{withoutQuestion, withoutQuestion, withQuestion} {
+		w := do(http.MethodGet, target, "", 0)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", target, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Cache-Control"); got != "private, max-age=3600" {
+			t.Errorf("Cache-Control = %q", got)
+		}
+	}
+
+	// повторная картинка берется из кэша
+	if len(renderer.calls) != 2 {
+		t.Fatalf("rendered %d times, want 2", len(renderer.calls))
+	}
+	if renderer.calls[0].Question || !renderer.calls[1].Question {
+		t.Errorf("question shown = %v, %v, want false, true", renderer.calls[0].Question, renderer.calls[1].Question)
+	}
+
+	other := uuid.New()
+	otherToken, _ := tokens.Issue(other, true)
+	expiredToken, _ := sharetoken.NewSigner([]byte(strings.Repeat("s", 32)), -time.Second).Issue(reading.ID, false)
+	for name, target := range map[string]string{
+		"no token":      "/readings/" + reading.ID.String() + "/share.png",
+		"other reading": "/readings/" + reading.ID.String() + "/share.png?token=" + otherToken,
+		"expired":       "/readings/" + reading.ID.String() + "/share.png?token=" + expiredToken,
+		"bare id":       "/readings/" + reading.ID.String() + "/share.png?token=" + reading.ID.String(),
+	} {
+		if w := do(http.MethodGet, target, "", 0); w.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", name, w.Code)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package register
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"taro-api/internal/lib/api/dto"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/lib/referral"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// UserRegistrar - интерфейс для регистрации пользователя
+type UserRegistrar interface {
+	RegisterUser(telegramID int64, bot string, referrerID int64) (*db.User, bool, error)
+}
+
+// ReferralClaimer - интерфейс для указания пригласившего после регистрации
+type ReferralClaimer interface {
+	ClaimReferral(telegramID, referrerID int64) (*db.User, error)
+}
+
+// New - регистрирует текущего пользователя; повторный вызов возвращает уже созданного
+// пользователя с 200 и не начисляет бонусы повторно, новый пользователь - 201
+func New(log *slog.Logger, registrar UserRegistrar) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.register.new"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		// тело необязательно: регистрация без приглашения
+		var req dto.RegisterRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "failed to decode request")
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			resp.Render(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		var referrerID int64
+		if req.ReferralCode != "" {
+			referrerID, err = referral.ParseCode(req.ReferralCode)
+			if err != nil {
+				resp.RenderError(w, r, http.StatusBadRequest, resp.CodeReferralInvalid, "invalid referral code")
+				return
+			}
+		}
+
+		user, created, err := registrar.RegisterUser(initData.User.ID, middlewares.Bot(r.Context()), referrerID)
+		if err != nil {
+			log.Error("failed to register user", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to register user")
+			return
+		}
+
+		if created {
+			log.Info("user registered", slog.Int64("referrer", user.ReferrerID))
+			render.Status(r, http.StatusCreated)
+		}
+		render.JSON(w, r, dto.NewUser(user))
+	}
+}
+
+// ClaimReferral - начисляет реферальные бонусы уже зарегистрированному пользователю,
+// если при регистрации код приглашения потерялся
+func ClaimReferral(log *slog.Logger, claimer ReferralClaimer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.register.claimReferral"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		var req dto.ReferralClaimRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "empty request")
+			return
+		}
+		if err != nil {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "failed to decode request")
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			resp.Render(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		referrerID, err := referral.ParseCode(req.ReferralCode)
+		if err != nil {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeReferralInvalid, "invalid referral code")
+			return
+		}
+
+		user, err := claimer.ClaimReferral(initData.User.ID, referrerID)
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "user not found")
+			return
+		case errors.Is(err, storage.ErrReferralInvalid):
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeReferralInvalid, "referral is invalid")
+			return
+		case errors.Is(err, storage.ErrReferralUnavailable):
+			resp.RenderError(w, r, http.StatusConflict, resp.CodeReferralUnavailable, "referral can no longer be claimed")
+			return
+		case err != nil:
+			log.Error("failed to claim referral", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to claim referral")
+			return
+		}
+
+		log.Info("referral claimed", slog.Int64("referrer", referrerID))
+		render.JSON(w, r, dto.NewUser(user))
+	}
+}
+
+func ctxInitData(ctx context.Context) (initdata.InitData, bool) {
+	initData, ok := ctx.Value(middlewares.InitDataKey).(initdata.InitData)
+	return initData, ok
+}
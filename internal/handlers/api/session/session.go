@@ -0,0 +1,295 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"taro-api/internal/lib/api/dto"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/bots"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/lib/loginwidget"
+	"taro-api/internal/lib/session"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// SessionCreator - интерфейс для создания сессии
+type SessionCreator interface {
+	CreateSession(session *db.Session) error
+}
+
+// LoginStore - интерфейс для входа через Telegram Login Widget
+type LoginStore interface {
+	SessionCreator
+	RegisterUser(telegramID int64, bot string, referrerID int64) (*db.User, bool, error)
+}
+
+// SessionRotator - интерфейс для обновления refresh токена
+type SessionRotator interface {
+	RotateSession(refreshHash, newRefreshHash string, expiresAt time.Time) (*db.Session, error)
+}
+
+// SessionRevoker - интерфейс для отзыва сессий
+type SessionRevoker interface {
+	RevokeSession(id uuid.UUID) error
+	RevokeUserSessions(telegramID int64) error
+}
+
+// New - обменивает проверенные init data на токены сессии
+func New(log *slog.Logger, creator SessionCreator, tokens *session.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.session.new"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok || middlewares.AuthType(r.Context()) != middlewares.AuthTypeTMA {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data is required")
+			return
+		}
+
+		s := SessionFromUser(initData.User)
+		s.Bot = middlewares.Bot(r.Context())
+
+		Issue(w, r, log, creator, tokens, s)
+	}
+}
+
+// Telegram - выдает токены сессии по данным Telegram Login Widget (вход на сайте вне Telegram),
+// данные принимаются от виджета любого из ботов
+func Telegram(log *slog.Logger, store LoginStore, tokens *session.Manager,
+	registry *bots.Registry, maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.session.telegram"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		var req loginwidget.Data
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "empty request")
+			return
+		}
+		if err != nil {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "failed to decode request")
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			resp.Render(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		botName, err := validateLogin(req, registry, maxAge)
+		if errors.Is(err, loginwidget.ErrExpired) {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeInitDataExpired, "login data is expired")
+			return
+		}
+		if err != nil {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeInitDataInvalid, "login data signature is invalid")
+			return
+		}
+
+		// тот же пользователь, что и в Mini App: баланс и история общие
+		if _, _, err := store.RegisterUser(req.ID, botName, 0); err != nil {
+			log.Error("failed to get user", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get user")
+			return
+		}
+
+		Issue(w, r, log, store, tokens, &db.Session{
+			TelegramID: req.ID,
+			FirstName:  req.FirstName,
+			LastName:   req.LastName,
+			Username:   req.Username,
+			Bot:        botName,
+		})
+	}
+}
+
+// validateLogin - проверяет подпись виджета токенами всех ботов, возвращает имя подошедшего
+func validateLogin(d loginwidget.Data, registry *bots.Registry, maxAge time.Duration) (string, error) {
+	var err error
+	for _, b := range registry.All() {
+		err = loginwidget.Validate(d, b.Token.Get(), maxAge)
+		if !errors.Is(err, loginwidget.ErrHashInvalid) {
+			return b.Name, err
+		}
+	}
+	return "", err
+}
+
+// SessionFromUser - заготовка сессии с профилем пользователя из init data
+func SessionFromUser(user initdata.User) *db.Session {
+	return &db.Session{
+		TelegramID:   user.ID,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Username:     user.Username,
+		LanguageCode: user.LanguageCode,
+		IsPremium:    user.IsPremium,
+	}
+}
+
+// Issue - создает сессию и отправляет клиенту токены
+func Issue(w http.ResponseWriter, r *http.Request, log *slog.Logger,
+	creator SessionCreator, tokens *session.Manager, s *db.Session) {
+	refreshToken, refreshHash, err := session.NewRefreshToken()
+	if err != nil {
+		log.Error("failed to generate refresh token", slog.String("error", err.Error()))
+		resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to create session")
+		return
+	}
+
+	s.RefreshHash = refreshHash
+	s.UserAgent = r.UserAgent()
+	s.ExpiresAt = tokens.RefreshExpiresAt()
+
+	err = creator.CreateSession(s)
+	if errors.Is(err, storage.ErrUserBlocked) {
+		resp.RenderError(w, r, http.StatusForbidden, resp.CodeForbidden, "user is blocked")
+		return
+	}
+	if err != nil {
+		log.Error("failed to create session", slog.String("error", err.Error()))
+		resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to create session")
+		return
+	}
+
+	responseSession(w, r, log, tokens, s, refreshToken)
+}
+
+// Refresh - выдает новую пару токенов по refresh токену
+func Refresh(log *slog.Logger, rotator SessionRotator, tokens *session.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.session.refresh"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		var req dto.RefreshRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "empty request")
+			return
+		}
+		if err != nil {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "failed to decode request")
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			resp.Render(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		refreshToken, refreshHash, err := session.NewRefreshToken()
+		if err != nil {
+			log.Error("failed to generate refresh token", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to refresh session")
+			return
+		}
+
+		s, err := rotator.RotateSession(session.HashRefreshToken(req.RefreshToken), refreshHash, tokens.RefreshExpiresAt())
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeSessionRevoked, "refresh token is invalid or revoked")
+			return
+		}
+		if errors.Is(err, storage.ErrUserBlocked) {
+			resp.RenderError(w, r, http.StatusForbidden, resp.CodeForbidden, "user is blocked")
+			return
+		}
+		if err != nil {
+			log.Error("failed to rotate session", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to refresh session")
+			return
+		}
+
+		responseSession(w, r, log, tokens, s, refreshToken)
+	}
+}
+
+// Logout - отзывает текущую сессию или все сессии пользователя
+func Logout(log *slog.Logger, revoker SessionRevoker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.session.logout"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		var req dto.LogoutRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "failed to decode request")
+			return
+		}
+
+		var err error
+		sessionID, hasSession := middlewares.SessionID(r.Context())
+		switch {
+		case req.All:
+			err = revoker.RevokeUserSessions(initData.User.ID)
+		case hasSession:
+			err = revoker.RevokeSession(sessionID)
+		}
+
+		if err != nil {
+			log.Error("failed to revoke session", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to logout")
+			return
+		}
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+func ctxInitData(ctx context.Context) (initdata.InitData, bool) {
+	initData, ok := ctx.Value(middlewares.InitDataKey).(initdata.InitData)
+	return initData, ok
+}
+
+func responseSession(w http.ResponseWriter, r *http.Request, log *slog.Logger,
+	tokens *session.Manager, s *db.Session, refreshToken string) {
+	accessToken, err := tokens.IssueAccess(session.Claims{
+		Subject:      s.TelegramID,
+		SessionID:    s.ID.String(),
+		FirstName:    s.FirstName,
+		LastName:     s.LastName,
+		Username:     s.Username,
+		LanguageCode: s.LanguageCode,
+		IsPremium:    s.IsPremium,
+		Bot:          s.Bot,
+	})
+	if err != nil {
+		log.Error("failed to issue access token", slog.String("error", err.Error()))
+		resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to issue token")
+		return
+	}
+
+	render.JSON(w, r, dto.Session{
+		AccessToken:      accessToken,
+		TokenType:        middlewares.AuthTypeBearer,
+		ExpiresIn:        int64(tokens.AccessTTL().Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: s.ExpiresAt,
+	})
+}
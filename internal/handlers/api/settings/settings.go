@@ -0,0 +1,184 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"taro-api/internal/lib/api/dto"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// Decks - колоды, которые можно выбрать в настройках
+var Decks = []string{"rider-waite", "thoth", "marseille"}
+
+// SettingsStore - интерфейс для чтения и изменения настроек пользователя
+type SettingsStore interface {
+	GetUserSettings(telegramID int64) (*db.User, error)
+	UpdateUserSettings(telegramID int64, upd db.SettingsUpdate) (*db.User, error)
+}
+
+// validate - валидатор с проверками языка, часового пояса, даты рождения и колоды
+var validate = newValidator()
+
+// Get - профиль и настройки текущего пользователя
+func Get(log *slog.Logger, store SettingsStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.settings.get"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		user, err := store.GetUserSettings(initData.User.ID)
+		if errors.Is(err, storage.ErrUserNotFound) {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "user not found")
+			return
+		}
+		if err != nil {
+			log.Error("failed to get settings", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to get settings")
+			return
+		}
+
+		render.JSON(w, r, toDTO(user, initData.User))
+	}
+}
+
+// Update - частично обновляет профиль и настройки текущего пользователя
+func Update(log *slog.Logger, store SettingsStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.settings.update"
+
+		log := logger.FromRequest(r, log).With(slog.String("op", op))
+
+		initData, ok := ctxInitData(r.Context())
+		if !ok {
+			resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+			return
+		}
+
+		var req dto.SettingsRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "empty request")
+			return
+		}
+		if err != nil {
+			resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "failed to decode request")
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			resp.Render(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		upd := db.SettingsUpdate{
+			Language:      req.Language,
+			Timezone:      req.Timezone,
+			BirthDate:     req.BirthDate,
+			PreferredDeck: req.PreferredDeck,
+		}
+		if req.DisplayName != nil {
+			name := strings.TrimSpace(*req.DisplayName)
+			upd.DisplayName = &name
+		}
+		if n := req.Notifications; n != nil {
+			upd.NotifyDaily = n.Daily
+			upd.NotifyDailyTime = n.DailyTime
+			upd.NotifyNews = n.News
+		}
+
+		user, err := store.UpdateUserSettings(initData.User.ID, upd)
+		if errors.Is(err, storage.ErrUserNotFound) {
+			resp.RenderError(w, r, http.StatusNotFound, resp.CodeNotFound, "user not found")
+			return
+		}
+		if err != nil {
+			log.Error("failed to update settings", slog.String("error", err.Error()))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to update settings")
+			return
+		}
+
+		render.JSON(w, r, toDTO(user, initData.User))
+	}
+}
+
+// toDTO - настройки пользователя; если имя не задано, берется имя из init data
+func toDTO(user *db.User, tgUser initdata.User) dto.Settings {
+	name := user.DisplayName
+	if name == "" {
+		name = strings.TrimSpace(tgUser.FirstName + " " + tgUser.LastName)
+	}
+
+	return dto.Settings{
+		DisplayName: name,
+		Language:    user.Language,
+		Timezone:    user.Timezone,
+		BirthDate:   user.BirthDate,
+		Notifications: dto.Notifications{
+			Daily:     user.NotifyDaily,
+			DailyTime: user.NotifyDailyTime,
+			News:      user.NotifyNews,
+		},
+		PreferredDeck: user.PreferredDeck,
+	}
+}
+
+// пустая строка в полях ниже - сброс значения
+func newValidator() *validator.Validate {
+	v := validator.New()
+	_ = v.RegisterValidation("language", func(fl validator.FieldLevel) bool {
+		return fl.Field().String() == "" || i18n.IsSupported(fl.Field().String())
+	})
+	// встроенный timezone не принимает пустую строку
+	_ = v.RegisterValidation("tz", func(fl validator.FieldLevel) bool {
+		tz := fl.Field().String()
+		if tz == "" {
+			return true
+		}
+		if strings.EqualFold(tz, "local") {
+			return false
+		}
+		_, err := time.LoadLocation(tz)
+		return err == nil
+	})
+	_ = v.RegisterValidation("birthdate", func(fl validator.FieldLevel) bool {
+		s := fl.Field().String()
+		if s == "" {
+			return true
+		}
+		d, err := time.Parse(time.DateOnly, s)
+		return err == nil && d.Year() >= 1900 && !d.After(time.Now())
+	})
+	_ = v.RegisterValidation("deck", func(fl validator.FieldLevel) bool {
+		return fl.Field().String() == "" || slices.Contains(Decks, fl.Field().String())
+	})
+	return v
+}
+
+func ctxInitData(ctx context.Context) (initdata.InitData, bool) {
+	initData, ok := ctx.Value(middlewares.InitDataKey).(initdata.InitData)
+	return initData, ok
+}
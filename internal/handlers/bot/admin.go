@@ -0,0 +1,74 @@
+package chat
+
+import (
+	"errors"
+	"strconv"
+	"taro-api/cmd/bot"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/storage"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// UserBlocker - интерфейс для блокировки пользователей
+type UserBlocker interface {
+	SetUserBlocked(telegramID int64, blocked bool) error
+}
+
+// AdminHandler - обработчик команд администратора
+type AdminHandler struct {
+	bot     *bot.TaroBot
+	blocker UserBlocker
+}
+
+// NewAdminHandler создает обработчик команд администратора
+func NewAdminHandler(bot *bot.TaroBot, blocker UserBlocker) *AdminHandler {
+	return &AdminHandler{bot: bot, blocker: blocker}
+}
+
+// OnlyAdmin - пропускает только сообщения от администратора
+func (h *AdminHandler) OnlyAdmin(next tele.HandlerFunc) tele.HandlerFunc {
+	return func(ctx tele.Context) error {
+		if ctx.Sender() == nil || ctx.Sender().ID != h.bot.AdminUserID {
+			return nil
+		}
+		return next(ctx)
+	}
+}
+
+// BlockHandler обрабатывает команду /block <telegram_id> и отзывает сессии пользователя
+func (h *AdminHandler) BlockHandler(ctx tele.Context) error {
+	return h.setBlocked(ctx, true)
+}
+
+// UnblockHandler обрабатывает команду /unblock <telegram_id>
+func (h *AdminHandler) UnblockHandler(ctx tele.Context) error {
+	return h.setBlocked(ctx, false)
+}
+
+func (h *AdminHandler) setBlocked(ctx tele.Context, blocked bool) error {
+	l := lang(ctx)
+
+	args := ctx.Args()
+	if len(args) != 1 {
+		return ctx.Send(i18n.T(l, i18n.BotAdminUsage))
+	}
+
+	telegramID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return ctx.Send(i18n.T(l, i18n.BotAdminBadID))
+	}
+
+	err = h.blocker.SetUserBlocked(telegramID, blocked)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return ctx.Send(i18n.T(l, i18n.BotAdminNotFound))
+	}
+	if err != nil {
+		return err
+	}
+
+	if blocked {
+		return ctx.Send(i18n.T(l, i18n.BotAdminBlocked))
+	}
+	return ctx.Send(i18n.T(l, i18n.BotAdminUnblocked))
+}
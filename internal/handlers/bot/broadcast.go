@@ -0,0 +1,256 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"taro-api/cmd/bot"
+	"taro-api/internal/lib/broadcast"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+// сколько последних рассылок показывает /bcstatus без аргументов
+const recentBroadcasts = 10
+
+// Кнопки управления рассылкой, в данных кнопки - ID рассылки
+var (
+	BtnBroadcastSend   = tele.Btn{Unique: "bc_send"}
+	BtnBroadcastPause  = tele.Btn{Unique: "bc_pause"}
+	BtnBroadcastResume = tele.Btn{Unique: "bc_resume"}
+	BtnBroadcastCancel = tele.Btn{Unique: "bc_cancel"}
+)
+
+// BroadcastStore - интерфейс для управления рассылками
+type BroadcastStore interface {
+	CreateBroadcast(b *db.Broadcast) error
+	GetBroadcast(id uuid.UUID) (*db.Broadcast, error)
+	RecentBroadcasts(limit int) ([]db.Broadcast, error)
+	StartBroadcast(id uuid.UUID) (*db.Broadcast, error)
+	SetBroadcastStatus(id uuid.UUID, to string, from ...string) (*db.Broadcast, error)
+}
+
+// Waker - отправщик рассылок, которого нужно разбудить после запуска
+type Waker interface {
+	Wake()
+}
+
+// BroadcastHandler - обработчик команд рассылок администратора
+type BroadcastHandler struct {
+	bot    *bot.TaroBot
+	store  BroadcastStore
+	worker Waker
+}
+
+// NewBroadcastHandler создает обработчик команд рассылок
+func NewBroadcastHandler(bot *bot.TaroBot, store BroadcastStore, worker Waker) *BroadcastHandler {
+	return &BroadcastHandler{bot: bot, store: store, worker: worker}
+}
+
+// CreateHandler обрабатывает /broadcast [сегмент] в ответ на сообщение: создает черновик
+// и отправляет администратору предпросмотр с кнопками запуска и отмены
+func (h *BroadcastHandler) CreateHandler(ctx tele.Context) error {
+	l := lang(ctx)
+
+	source := ctx.Message().ReplyTo
+	if source == nil {
+		return ctx.Send(i18n.T(l, i18n.BotBroadcastUsage))
+	}
+
+	seg, err := broadcast.ParseSegment(ctx.Args())
+	if err != nil {
+		return ctx.Send(i18n.T(l, i18n.BotBroadcastInvalid, err.Error()))
+	}
+
+	b := &db.Broadcast{
+		CreatedBy:       ctx.Sender().ID,
+		Bot:             h.bot.Name,
+		IncludeUntagged: h.bot.Primary,
+		SourceChatID:    source.Chat.ID,
+		SourceMessageID: source.ID,
+		Segment:         seg,
+	}
+	if err := h.store.CreateBroadcast(b); err != nil {
+		return err
+	}
+
+	admin := tele.ChatID(h.bot.AdminUserID)
+	if _, err := h.bot.Bot.Copy(admin, source); err != nil {
+		return err
+	}
+
+	preview := i18n.T(l, i18n.BotBroadcastPreview, b.ID.String(), broadcast.Describe(b.Segment), b.Total)
+	_, err = h.bot.Bot.Send(admin, preview, controls(l, b))
+	return err
+}
+
+// StatusHandler обрабатывает /bcstatus [id]: статус рассылки или список последних
+func (h *BroadcastHandler) StatusHandler(ctx tele.Context) error {
+	l := lang(ctx)
+
+	if len(ctx.Args()) == 0 {
+		list, err := h.store.RecentBroadcasts(recentBroadcasts)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return ctx.Send(i18n.T(l, i18n.BotBroadcastEmpty))
+		}
+
+		lines := make([]string, 0, len(list))
+		for _, b := range list {
+			lines = append(lines, fmt.Sprintf("%s %s %d/%d", b.ID, b.Status, b.Sent, b.Total))
+		}
+		return ctx.Send(strings.Join(lines, "\n"))
+	}
+
+	return h.command(ctx, func(id uuid.UUID) (*db.Broadcast, error) {
+		return h.store.GetBroadcast(id)
+	})
+}
+
+// PauseHandler обрабатывает /bcpause <id> и кнопку паузы
+func (h *BroadcastHandler) PauseHandler(ctx tele.Context) error {
+	return h.dispatch(ctx, h.pause)
+}
+
+// ResumeHandler обрабатывает /bcresume <id> и кнопку продолжения
+func (h *BroadcastHandler) ResumeHandler(ctx tele.Context) error {
+	return h.dispatch(ctx, h.resume)
+}
+
+// CancelHandler обрабатывает /bccancel <id> и кнопку отмены
+func (h *BroadcastHandler) CancelHandler(ctx tele.Context) error {
+	return h.dispatch(ctx, h.cancel)
+}
+
+// SendHandler обрабатывает кнопку запуска рассылки из предпросмотра
+func (h *BroadcastHandler) SendHandler(ctx tele.Context) error {
+	return h.dispatch(ctx, h.start)
+}
+
+func (h *BroadcastHandler) start(id uuid.UUID) (*db.Broadcast, error) {
+	b, err := h.store.StartBroadcast(id)
+	if err == nil {
+		h.worker.Wake()
+	}
+	return b, err
+}
+
+func (h *BroadcastHandler) pause(id uuid.UUID) (*db.Broadcast, error) {
+	return h.store.SetBroadcastStatus(id, db.BroadcastPaused, db.BroadcastRunning)
+}
+
+func (h *BroadcastHandler) resume(id uuid.UUID) (*db.Broadcast, error) {
+	b, err := h.store.SetBroadcastStatus(id, db.BroadcastRunning, db.BroadcastPaused)
+	if err == nil {
+		h.worker.Wake()
+	}
+	return b, err
+}
+
+func (h *BroadcastHandler) cancel(id uuid.UUID) (*db.Broadcast, error) {
+	return h.store.SetBroadcastStatus(id, db.BroadcastCancelled,
+		db.BroadcastDraft, db.BroadcastRunning, db.BroadcastPaused)
+}
+
+// dispatch - действие по нажатию кнопки (ID в данных кнопки) или по команде (ID в аргументе)
+func (h *BroadcastHandler) dispatch(ctx tele.Context, action func(uuid.UUID) (*db.Broadcast, error)) error {
+	if ctx.Callback() != nil {
+		return h.callback(ctx, action)
+	}
+	return h.command(ctx, action)
+}
+
+func (h *BroadcastHandler) command(ctx tele.Context, action func(uuid.UUID) (*db.Broadcast, error)) error {
+	l := lang(ctx)
+
+	args := ctx.Args()
+	if len(args) != 1 {
+		return ctx.Send(i18n.T(l, i18n.BotBroadcastControlUsage))
+	}
+
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return ctx.Send(i18n.T(l, i18n.BotBroadcastNotFound))
+	}
+
+	b, err := action(id)
+	if text, ok := actionError(l, err); ok {
+		return ctx.Send(text)
+	}
+	if err != nil {
+		return err
+	}
+
+	return ctx.Send(status(l, b), controls(l, b))
+}
+
+func (h *BroadcastHandler) callback(ctx tele.Context, action func(uuid.UUID) (*db.Broadcast, error)) error {
+	l := lang(ctx)
+
+	id, err := uuid.Parse(ctx.Callback().Data)
+	if err != nil {
+		return ctx.Respond(&tele.CallbackResponse{Text: i18n.T(l, i18n.BotBroadcastNotFound)})
+	}
+
+	b, err := action(id)
+	if text, ok := actionError(l, err); ok {
+		return ctx.Respond(&tele.CallbackResponse{Text: text})
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Respond(); err != nil {
+		return err
+	}
+	return ctx.Edit(status(l, b), controls(l, b))
+}
+
+// actionError - текст для администратора, если действие невозможно
+func actionError(l i18n.Lang, err error) (string, bool) {
+	switch {
+	case errors.Is(err, storage.ErrBroadcastNotFound):
+		return i18n.T(l, i18n.BotBroadcastNotFound), true
+	case errors.Is(err, storage.ErrBroadcastState):
+		return i18n.T(l, i18n.BotBroadcastBadState), true
+	}
+	return "", false
+}
+
+func status(l i18n.Lang, b *db.Broadcast) string {
+	return i18n.T(l, i18n.BotBroadcastStatus, b.ID.String(), b.Status, broadcast.Describe(b.Segment),
+		b.Sent, b.Total, b.Failed, b.Blocked)
+}
+
+// controls - кнопки, доступные в текущем статусе рассылки
+func controls(l i18n.Lang, b *db.Broadcast) *tele.ReplyMarkup {
+	markup := &tele.ReplyMarkup{}
+	id := b.ID.String()
+
+	button := func(btn tele.Btn, key i18n.Key) tele.Btn {
+		btn.Text = i18n.T(l, key)
+		btn.Data = id
+		return btn
+	}
+	cancel := button(BtnBroadcastCancel, i18n.BotBroadcastCancelButton)
+
+	switch b.Status {
+	case db.BroadcastDraft:
+		markup.Inline(markup.Row(button(BtnBroadcastSend, i18n.BotBroadcastSendButton), cancel))
+	case db.BroadcastRunning:
+		markup.Inline(markup.Row(button(BtnBroadcastPause, i18n.BotBroadcastPauseButton), cancel))
+	case db.BroadcastPaused:
+		markup.Inline(markup.Row(button(BtnBroadcastResume, i18n.BotBroadcastResumeButton), cancel))
+	default:
+		// пустая клавиатура убирает кнопки у завершенной рассылки
+		markup.Inline()
+	}
+
+	return markup
+}
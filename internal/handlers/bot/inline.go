@@ -0,0 +1,168 @@
+package chat
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"taro-api/cmd/bot"
+	"taro-api/internal/lib/deeplink"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/tarot"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+	"time"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	// inlinePageSize - результатов на страницу inline ответа (лимит Telegram - 50)
+	inlinePageSize = 20
+	// inlineCacheTime - сколько Telegram кэширует ответ на inline запрос для пользователя
+	inlineCacheTime = 5 * time.Minute
+)
+
+// InlineStore - интерфейс для поиска раскладов, которыми делятся через inline режим
+type InlineStore interface {
+	GetReading(id uuid.UUID) (*db.Reading, error)
+}
+
+// InlineHandler - обработчик inline режима: @bot <название карты> или @bot reading_<id>
+type InlineHandler struct {
+	bot   *bot.TaroBot
+	store InlineStore
+}
+
+// NewInlineHandler создает обработчик inline режима
+func NewInlineHandler(bot *bot.TaroBot, store InlineStore) *InlineHandler {
+	return &InlineHandler{bot: bot, store: store}
+}
+
+// inlinePage - найденное по запросу: карты страницы или расклад
+type inlinePage struct {
+	cards   []tarot.Card
+	reading *db.Reading
+	next    string
+}
+
+// QueryHandler обрабатывает inline запрос. Ответ зависит от языка пользователя, поэтому Telegram
+// кэширует его только для этого пользователя; у нас не кэшируется: поиск карт идет в памяти,
+// а расклад читается по первичному ключу
+func (h *InlineHandler) QueryHandler(ctx tele.Context) error {
+	l := lang(ctx)
+	query := ctx.Query()
+
+	page, err := h.find(query.Text, query.Offset)
+	if err != nil {
+		return err
+	}
+
+	results := make(tele.Results, 0, len(page.cards)+1)
+	if page.reading != nil {
+		results = append(results, h.readingResult(l, page.reading, ctx.Sender().ID))
+	}
+	for _, c := range page.cards {
+		results = append(results, h.cardResult(l, c))
+	}
+
+	return ctx.Answer(&tele.QueryResponse{
+		Results:    results,
+		CacheTime:  int(inlineCacheTime.Seconds()),
+		IsPersonal: true,
+		NextOffset: page.next,
+		Button: &tele.QueryResponseButton{
+			Text:   i18n.T(l, i18n.BotInlineOpenApp),
+			WebApp: &tele.WebApp{URL: h.bot.TmaURL},
+		},
+	})
+}
+
+// find - расклад, если запрос - его ID или ссылка на него, иначе страница найденных карт;
+// пустой запрос - вся колода
+func (h *InlineHandler) find(text, offset string) (inlinePage, error) {
+	if id, ok := deeplink.ParseReading(text); ok {
+		reading, err := h.store.GetReading(id)
+		if errors.Is(err, storage.ErrReadingNotFound) {
+			return inlinePage{}, nil
+		}
+		if err != nil {
+			return inlinePage{}, err
+		}
+		return inlinePage{reading: reading}, nil
+	}
+
+	cards := tarot.Deck()
+	if strings.TrimSpace(text) != "" {
+		cards = tarot.Search(text, 0)
+	}
+
+	start, _ := strconv.Atoi(offset)
+	start = min(max(start, 0), len(cards))
+	end := min(start+inlinePageSize, len(cards))
+
+	page := inlinePage{cards: cards[start:end]}
+	if end < len(cards) {
+		page.next = strconv.Itoa(end)
+	}
+	return page, nil
+}
+
+func (h *InlineHandler) cardResult(l i18n.Lang, c tarot.Card) tele.Result {
+	name := c.Name.In(l)
+	upright := strings.Join(c.Upright.In(l), ", ")
+	text := i18n.T(l, i18n.BotCardMeaning, name, upright, strings.Join(c.Reversed.In(l), ", "))
+
+	var result tele.Result
+	if image := cardImage(h.bot.CardImagesURL, c.ID); image != "" {
+		result = &tele.PhotoResult{URL: image, ThumbURL: image, Title: name, Description: upright, Caption: text}
+	} else {
+		result = &tele.ArticleResult{Title: name, Description: upright, Text: text}
+	}
+
+	result.SetResultID("card:" + c.ID)
+	result.SetReplyMarkup(h.linkMarkup(l, deeplink.Card(c.ID)))
+	return result
+}
+
+// readingResult - расклад для отправки в чат. ID расклада можно взять из чужой ссылки,
+// поэтому вопрос добавляется, только если расклад отправляет его автор
+func (h *InlineHandler) readingResult(l i18n.Lang, r *db.Reading, senderID int64) tele.Result {
+	spread, _ := tarot.SpreadByID(r.Spread)
+
+	names := make([]string, 0, len(r.Cards))
+	for _, c := range r.Cards {
+		card, _ := tarot.ByID(c.Card)
+		names = append(names, cardName(l, tarot.Drawn{Card: card, Reversed: c.Reversed}))
+	}
+
+	result := &tele.ArticleResult{
+		Title:       spread.Name.In(l),
+		Description: strings.Join(names, ", "),
+		Text:        readingText(l, r, r.TelegramID == senderID),
+	}
+	result.SetResultID("reading:" + r.ID.String())
+	result.SetReplyMarkup(h.linkMarkup(l, deeplink.Reading(r.ID)))
+	return result
+}
+
+// linkMarkup - кнопка "Открыть в Taroki" со ссылкой startapp. Кнопки WebApp в чатах
+// с другими пользователями недоступны, поэтому только ссылка; без username бота кнопки нет
+func (h *InlineHandler) linkMarkup(l i18n.Lang, param string) *tele.ReplyMarkup {
+	link := deeplink.StartApp(h.bot.Bot.Me.Username, param)
+	if link == "" {
+		return nil
+	}
+
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.URL(i18n.T(l, i18n.BotInlineOpenApp), link)))
+	return menu
+}
+
+// cardImage - адрес изображения карты, пусто - каталог изображений не настроен
+func cardImage(base, id string) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimRight(base, "/") + "/" + id + ".jpg"
+}
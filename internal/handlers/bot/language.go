@@ -0,0 +1,44 @@
+package chat
+
+import (
+	"taro-api/internal/lib/i18n"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const langKey = "lang"
+
+// LanguageStore - язык, который пользователь выбрал в настройках Mini App
+type LanguageStore interface {
+	GetUserLanguage(telegramID int64) (string, error)
+}
+
+// Language - определяет язык ответов: выбранный в настройках, иначе язык Telegram отправителя
+func Language(store LanguageStore) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(ctx tele.Context) error {
+			sender := ctx.Sender()
+			if sender == nil {
+				ctx.Set(langKey, i18n.Default)
+				return next(ctx)
+			}
+
+			// при ошибке хранилища отвечаем на языке Telegram
+			stored, _ := store.GetUserLanguage(sender.ID)
+			ctx.Set(langKey, i18n.Match(stored, sender.LanguageCode))
+
+			return next(ctx)
+		}
+	}
+}
+
+// lang - язык ответа для текущего обновления
+func lang(ctx tele.Context) i18n.Lang {
+	if l, ok := ctx.Get(langKey).(i18n.Lang); ok {
+		return l
+	}
+	if sender := ctx.Sender(); sender != nil {
+		return i18n.Match(sender.LanguageCode)
+	}
+	return i18n.Default
+}
@@ -0,0 +1,196 @@
+package chat
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"taro-api/cmd/bot"
+	"taro-api/internal/lib/deeplink"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/storage/db"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// BtnLanguage - кнопка выбора языка, в данных кнопки - код языка, пусто - язык Telegram
+var BtnLanguage = tele.Btn{Unique: "language"}
+
+// languageNames - названия языков на них самих, для кнопок выбора
+var languageNames = map[i18n.Lang]string{
+	i18n.RU: "Русский",
+	i18n.EN: "English",
+	i18n.UK: "Українська",
+	i18n.KK: "Қазақша",
+}
+
+// menuCommands - команды меню бота в порядке показа
+var menuCommands = []struct {
+	text string
+	key  i18n.Key
+}{
+	{"start", i18n.BotCmdStart},
+	{"card", i18n.BotCmdCard},
+	{"daily", i18n.BotCmdDaily},
+	{"spread", i18n.BotCmdSpread},
+	{"balance", i18n.BotCmdBalance},
+	{"invite", i18n.BotCmdInvite},
+	{"promo", i18n.BotCmdPromo},
+	{"language", i18n.BotCmdLanguage},
+	{"help", i18n.BotCmdHelp},
+}
+
+// MenuStore - интерфейс для команд аккаунта: пользователь создается при первой команде,
+// чтобы те, кто не открывает Mini App, тоже получили баланс и настройки
+type MenuStore interface {
+	RegisterUser(telegramID int64, bot string, referrerID int64) (*db.User, bool, error)
+	UpdateUserSettings(telegramID int64, upd db.SettingsUpdate) (*db.User, error)
+}
+
+// MenuHandler - обработчик команд меню: помощь, баланс, приглашения, язык
+type MenuHandler struct {
+	bot   *bot.TaroBot
+	store MenuStore
+}
+
+// NewMenuHandler создает обработчик команд меню
+func NewMenuHandler(bot *bot.TaroBot, store MenuStore) *MenuHandler {
+	return &MenuHandler{bot: bot, store: store}
+}
+
+// Commands - меню команд бота на языке l
+func Commands(l i18n.Lang) []tele.Command {
+	cmds := make([]tele.Command, 0, len(menuCommands))
+	for _, c := range menuCommands {
+		cmds = append(cmds, tele.Command{Text: c.text, Description: i18n.T(l, c.key)})
+	}
+	return cmds
+}
+
+// SetCommands - регистрирует меню команд (setMyCommands) для каждого языка с каталогом
+// и для остальных пользователей на языке по умолчанию
+func SetCommands(b *tele.Bot) error {
+	if err := b.SetCommands(Commands(i18n.Default)); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+
+	for _, l := range i18n.Supported() {
+		if err := b.SetCommands(Commands(l), string(l)); err != nil {
+			return fmt.Errorf("%s: %w", l, err)
+		}
+	}
+
+	return nil
+}
+
+// HelpHandler обрабатывает команду /help
+func (h *MenuHandler) HelpHandler(ctx tele.Context) error {
+	l := lang(ctx)
+
+	lines := make([]string, 0, len(menuCommands))
+	for _, c := range Commands(l) {
+		lines = append(lines, "/"+c.Text+" - "+c.Description)
+	}
+
+	menu := &tele.ReplyMarkup{}
+	launch := tele.Btn{Text: i18n.T(l, i18n.BotLaunchButton), WebApp: &tele.WebApp{URL: h.bot.TmaURL}}
+	menu.Inline(menu.Row(launch))
+
+	return ctx.Send(i18n.T(l, i18n.BotHelp, strings.Join(lines, "\n")), menu)
+}
+
+// BalanceHandler обрабатывает команду /balance: баланс и действующие доступы
+func (h *MenuHandler) BalanceHandler(ctx tele.Context) error {
+	l := lang(ctx)
+
+	user, _, err := h.store.RegisterUser(ctx.Sender().ID, h.bot.Name, 0)
+	if err != nil {
+		return err
+	}
+
+	lines := []string{i18n.T(l, i18n.BotBalance, user.Balance)}
+	for _, e := range user.Entitlements {
+		if e.ExpiresAt == nil {
+			lines = append(lines, i18n.T(l, i18n.BotBalanceForever, e.Name))
+			continue
+		}
+		lines = append(lines, i18n.T(l, i18n.BotBalanceEntitlement, e.Name, e.ExpiresAt.Format(time.DateOnly)))
+	}
+
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(menu.Row(appButton(h.bot, i18n.T(l, i18n.BotOpenInAppButton), deeplink.Profile)))
+
+	return ctx.Send(strings.Join(lines, "\n"), menu)
+}
+
+// InviteHandler обрабатывает команду /invite: реферальная ссылка и кнопка, чтобы ей поделиться
+func (h *MenuHandler) InviteHandler(ctx tele.Context) error {
+	l := lang(ctx)
+
+	param := deeplink.Referral(ctx.Sender().ID)
+	link := deeplink.StartApp(h.bot.Bot.Me.Username, param)
+	if link == "" {
+		// без username бота ссылку не собрать, код вводится в приложении вручную
+		return ctx.Send(i18n.T(l, i18n.BotInvite, param))
+	}
+
+	share := url.URL{Scheme: "https", Host: "t.me", Path: "/share/url"}
+	share.RawQuery = url.Values{"url": {link}, "text": {i18n.T(l, i18n.BotInviteShareText)}}.Encode()
+
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.URL(i18n.T(l, i18n.BotInviteShareButton), share.String())))
+
+	return ctx.Send(i18n.T(l, i18n.BotInvite, link), menu)
+}
+
+// LanguageHandler обрабатывает команду /language: кнопки выбора языка
+func (h *MenuHandler) LanguageHandler(ctx tele.Context) error {
+	l := lang(ctx)
+
+	menu := &tele.ReplyMarkup{}
+	rows := make([]tele.Row, 0, len(i18n.Supported())+1)
+	for _, supported := range i18n.Supported() {
+		btn := BtnLanguage
+		btn.Text = languageNames[supported]
+		btn.Data = string(supported)
+		rows = append(rows, menu.Row(btn))
+	}
+
+	auto := BtnLanguage
+	auto.Text = i18n.T(l, i18n.BotLanguageAuto)
+	rows = append(rows, menu.Row(auto))
+	menu.Inline(rows...)
+
+	return ctx.Send(i18n.T(l, i18n.BotLanguageChoose), menu)
+}
+
+// LanguageCallback обрабатывает выбор языка: сохраняет его в настройках, как в Mini App
+func (h *MenuHandler) LanguageCallback(ctx tele.Context) error {
+	code := ctx.Callback().Data
+	if code != "" && !i18n.IsSupported(code) {
+		return ctx.Respond()
+	}
+
+	sender := ctx.Sender()
+	if _, _, err := h.store.RegisterUser(sender.ID, h.bot.Name, 0); err != nil {
+		return err
+	}
+	if _, err := h.store.UpdateUserSettings(sender.ID, db.SettingsUpdate{Language: &code}); err != nil {
+		return err
+	}
+
+	l := i18n.Match(code, sender.LanguageCode)
+	if err := ctx.Respond(); err != nil {
+		return err
+	}
+	return ctx.Edit(i18n.T(l, i18n.BotLanguageSet))
+}
+
+// appButton - кнопка, открывающая Mini App на экране param. Если username бота неизвестен,
+// открывает Mini App кнопкой WebApp на главном экране
+func appButton(b *bot.TaroBot, text, param string) tele.Btn {
+	if link := deeplink.StartApp(b.Bot.Me.Username, param); link != "" {
+		return tele.Btn{Text: text, URL: link}
+	}
+	return tele.Btn{Text: text, WebApp: &tele.WebApp{URL: b.TmaURL}}
+}
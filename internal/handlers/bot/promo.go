@@ -0,0 +1,113 @@
+package chat
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"taro-api/cmd/bot"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/promo"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// сколько кодов выпуска отправлять текстом, остальные - файлом
+const maxCodesInMessage = 50
+
+// PromoStore - интерфейс для выпуска и активации промокодов
+type PromoStore interface {
+	CreatePromoCodes(codes []string, opts promo.Options, createdBy int64) (string, error)
+	RedeemPromoCode(telegramID int64, code string) (*db.PromoRedemption, *db.User, error)
+}
+
+// PromoHandler - обработчик команд промокодов
+type PromoHandler struct {
+	bot   *bot.TaroBot
+	store PromoStore
+}
+
+// NewPromoHandler создает обработчик команд промокодов
+func NewPromoHandler(bot *bot.TaroBot, store PromoStore) *PromoHandler {
+	return &PromoHandler{bot: bot, store: store}
+}
+
+// RedeemHandler обрабатывает команду /promo <код>
+func (h *PromoHandler) RedeemHandler(ctx tele.Context) error {
+	l := lang(ctx)
+
+	args := ctx.Args()
+	if len(args) != 1 || ctx.Sender() == nil {
+		return ctx.Send(i18n.T(l, i18n.BotPromoUsage))
+	}
+
+	redemption, user, err := h.store.RedeemPromoCode(ctx.Sender().ID, args[0])
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound):
+		return ctx.Send(i18n.T(l, i18n.BotPromoNoUser))
+	case errors.Is(err, storage.ErrUserBlocked):
+		return ctx.Send(i18n.T(l, i18n.ErrorKey("forbidden")))
+	case errors.Is(err, storage.ErrPromoNotFound):
+		return ctx.Send(i18n.T(l, i18n.ErrorKey("promo_not_found")))
+	case errors.Is(err, storage.ErrPromoInactive):
+		return ctx.Send(i18n.T(l, i18n.ErrorKey("promo_inactive")))
+	case errors.Is(err, storage.ErrPromoExhausted):
+		return ctx.Send(i18n.T(l, i18n.ErrorKey("promo_exhausted")))
+	case errors.Is(err, storage.ErrPromoAlreadyRedeemed):
+		return ctx.Send(i18n.T(l, i18n.ErrorKey("promo_already_redeemed")))
+	case err != nil:
+		return err
+	}
+
+	switch {
+	case redemption.RewardType == promo.RewardBalance:
+		return ctx.Send(i18n.T(l, i18n.BotPromoBalance, redemption.Amount, user.Balance))
+	case redemption.EntitlementUntil == nil:
+		return ctx.Send(i18n.T(l, i18n.BotPromoEntitlementForever, redemption.Entitlement))
+	default:
+		return ctx.Send(i18n.T(l, i18n.BotPromoEntitlement, redemption.Entitlement,
+			redemption.EntitlementUntil.Format(time.DateOnly)))
+	}
+}
+
+// CreateHandler обрабатывает команду администратора /newpromo key=value...
+func (h *PromoHandler) CreateHandler(ctx tele.Context) error {
+	l := lang(ctx)
+
+	args := ctx.Args()
+	if len(args) == 0 {
+		return ctx.Send(i18n.T(l, i18n.BotAdminPromoUsage))
+	}
+
+	opts, err := promo.ParseOptions(args)
+	if err != nil {
+		return ctx.Send(i18n.T(l, i18n.BotAdminPromoInvalid, err.Error()))
+	}
+
+	codes, err := opts.Codes()
+	if err != nil {
+		return err
+	}
+
+	batch, err := h.store.CreatePromoCodes(codes, opts, ctx.Sender().ID)
+	if errors.Is(err, storage.ErrPromoExists) {
+		return ctx.Send(i18n.T(l, i18n.BotAdminPromoExists))
+	}
+	if err != nil {
+		return err
+	}
+
+	created := i18n.T(l, i18n.BotAdminPromoCreated, len(codes), batch)
+	if len(codes) <= maxCodesInMessage {
+		return ctx.Send(created + "\n\n" + strings.Join(codes, "\n"))
+	}
+
+	return ctx.Send(&tele.Document{
+		File:     tele.FromReader(bytes.NewReader([]byte(strings.Join(codes, "\n") + "\n"))),
+		FileName: fmt.Sprintf("promo-%s.txt", batch),
+		Caption:  created,
+	})
+}
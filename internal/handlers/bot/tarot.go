@@ -0,0 +1,262 @@
+package chat
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"taro-api/cmd/bot"
+	"taro-api/internal/lib/deeplink"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/sharecard"
+	"taro-api/internal/lib/tarot"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+	"time"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+// Кнопки карт и раскладов: в данных BtnSpread - идентификатор расклада,
+// в данных BtnShareImage - ID сохраненного расклада
+var (
+	BtnCard       = tele.Btn{Unique: "card"}
+	BtnSpread     = tele.Btn{Unique: "spread"}
+	BtnSpreads    = tele.Btn{Unique: "spreads"}
+	BtnShareImage = tele.Btn{Unique: "share_image"}
+)
+
+// TarotStore - интерфейс для карты дня и сохранения раскладов из бота
+type TarotStore interface {
+	RegisterUser(telegramID int64, bot string, referrerID int64) (*db.User, bool, error)
+	CreateReading(r *db.Reading) error
+	GetReading(id uuid.UUID) (*db.Reading, error)
+}
+
+// ShareRenderer - интерфейс для картинки расклада
+type ShareRenderer interface {
+	Render(w io.Writer, l i18n.Lang, r *db.Reading, opts sharecard.Options) error
+}
+
+// TarotHandler - обработчик карт и раскладов прямо в чате
+type TarotHandler struct {
+	bot      *bot.TaroBot
+	store    TarotStore
+	renderer ShareRenderer
+}
+
+// NewTarotHandler создает обработчик карт и раскладов
+func NewTarotHandler(bot *bot.TaroBot, store TarotStore, renderer ShareRenderer) *TarotHandler {
+	return &TarotHandler{bot: bot, store: store, renderer: renderer}
+}
+
+// CardHandler обрабатывает команду /card [название]: без названия - случайная карта,
+// с названием - значение найденной карты в прямом и перевернутом положении
+func (h *TarotHandler) CardHandler(ctx tele.Context) error {
+	l := lang(ctx)
+
+	query := strings.TrimSpace(ctx.Message().Payload)
+	if query == "" {
+		return h.sendRandomCard(ctx)
+	}
+
+	found := tarot.Search(query, 1)
+	if len(found) == 0 {
+		return ctx.Send(i18n.T(l, i18n.BotCardNotFound, query))
+	}
+	card := found[0]
+
+	text := i18n.T(l, i18n.BotCardMeaning, card.Name.In(l),
+		strings.Join(card.Upright.In(l), ", "), strings.Join(card.Reversed.In(l), ", "))
+
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(menu.Row(appButton(h.bot, i18n.T(l, i18n.BotOpenInAppButton), deeplink.Card(card.ID))))
+
+	return ctx.Send(text, menu)
+}
+
+// CardCallback обрабатывает кнопку "Еще карта"
+func (h *TarotHandler) CardCallback(ctx tele.Context) error {
+	if err := ctx.Respond(); err != nil {
+		return err
+	}
+	return h.sendRandomCard(ctx)
+}
+
+func (h *TarotHandler) sendRandomCard(ctx tele.Context) error {
+	l := lang(ctx)
+
+	drawn := tarot.Draw(1)[0]
+
+	another := BtnCard
+	another.Text = i18n.T(l, i18n.BotAnotherCardButton)
+
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(menu.Row(another, appButton(h.bot, i18n.T(l, i18n.BotOpenInAppButton), deeplink.Card(drawn.Card.ID))))
+
+	return ctx.Send(cardText(l, drawn), menu)
+}
+
+// DailyHandler обрабатывает команду /daily: карта дня одна на весь день
+// в часовом поясе пользователя
+func (h *TarotHandler) DailyHandler(ctx tele.Context) error {
+	l := lang(ctx)
+
+	user, _, err := h.store.RegisterUser(ctx.Sender().ID, h.bot.Name, 0)
+	if err != nil {
+		return err
+	}
+
+	// пустой часовой пояс - UTC
+	day := time.Now().UTC()
+	if loc, err := time.LoadLocation(user.Timezone); err == nil {
+		day = day.In(loc)
+	}
+	drawn := tarot.Daily(user.TelegramID, day)
+
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(menu.Row(appButton(h.bot, i18n.T(l, i18n.BotOpenInAppButton), deeplink.Daily)))
+
+	return ctx.Send(i18n.T(l, i18n.BotDaily, day.Format(time.DateOnly), cardText(l, drawn)), menu)
+}
+
+// SpreadHandler обрабатывает команду /spread и кнопку "Другой расклад": выбор расклада
+func (h *TarotHandler) SpreadHandler(ctx tele.Context) error {
+	l := lang(ctx)
+
+	menu := &tele.ReplyMarkup{}
+	rows := make([]tele.Row, 0, len(tarot.Spreads())+1)
+	for _, s := range tarot.Spreads() {
+		btn := BtnSpread
+		btn.Text = s.Name.In(l)
+		btn.Data = s.ID
+		rows = append(rows, menu.Row(btn))
+	}
+	rows = append(rows, menu.Row(appButton(h.bot, i18n.T(l, i18n.BotOpenInAppButton), deeplink.Spreads)))
+	menu.Inline(rows...)
+
+	if ctx.Callback() != nil {
+		if err := ctx.Respond(); err != nil {
+			return err
+		}
+	}
+	return ctx.Send(i18n.T(l, i18n.BotSpreadChoose), menu)
+}
+
+// SpreadCallback обрабатывает выбор расклада: вытягивает карты, сохраняет расклад
+// и заменяет меню выбора результатом со ссылкой на расклад в Mini App
+func (h *TarotHandler) SpreadCallback(ctx tele.Context) error {
+	l := lang(ctx)
+
+	spread, ok := tarot.SpreadByID(ctx.Callback().Data)
+	if !ok {
+		return ctx.Respond()
+	}
+
+	drawn := tarot.Draw(spread.Size())
+
+	reading := &db.Reading{
+		TelegramID: ctx.Sender().ID,
+		Spread:     spread.ID,
+		Source:     db.ReadingSourceBot,
+		Cards:      make([]db.ReadingCard, 0, len(drawn)),
+	}
+	for _, d := range drawn {
+		reading.Cards = append(reading.Cards, db.ReadingCard{Card: d.Card.ID, Reversed: d.Reversed})
+	}
+
+	if err := h.store.CreateReading(reading); err != nil {
+		return err
+	}
+
+	another := BtnSpreads
+	another.Text = i18n.T(l, i18n.BotAnotherSpreadButton)
+
+	// поделиться - inline запрос с ID расклада в выбранном чате
+	share := tele.Btn{Text: i18n.T(l, i18n.BotShareButton), InlineQuery: deeplink.Reading(reading.ID)}
+
+	image := BtnShareImage
+	image.Text = i18n.T(l, i18n.BotShareImageButton)
+	image.Data = reading.ID.String()
+
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(
+		menu.Row(appButton(h.bot, i18n.T(l, i18n.BotOpenInAppButton), deeplink.Reading(reading.ID))),
+		menu.Row(share, image),
+		menu.Row(another),
+	)
+
+	if err := ctx.Respond(); err != nil {
+		return err
+	}
+	return ctx.Edit(readingText(l, reading, true), menu)
+}
+
+// ShareImageCallback обрабатывает кнопку "Картинка для сторис": присылает картинку расклада
+// с QR на реферальную ссылку того, кто раскладывал
+func (h *TarotHandler) ShareImageCallback(ctx tele.Context) error {
+	id, err := uuid.Parse(ctx.Callback().Data)
+	if err != nil {
+		return ctx.Respond()
+	}
+
+	reading, err := h.store.GetReading(id)
+	if errors.Is(err, storage.ErrReadingNotFound) {
+		return ctx.Respond()
+	}
+	if err != nil {
+		return err
+	}
+
+	link := deeplink.StartApp(h.bot.Bot.Me.Username, deeplink.Referral(reading.TelegramID))
+
+	var buf bytes.Buffer
+	// картинку пересылают дальше, поэтому вопрос на нее не попадает
+	if err := h.renderer.Render(&buf, lang(ctx), reading, sharecard.Options{Link: link}); err != nil {
+		return err
+	}
+
+	if err := ctx.Respond(); err != nil {
+		return err
+	}
+	return ctx.Send(&tele.Photo{File: tele.FromReader(&buf)})
+}
+
+// readingText - расклад по позициям с названиями и значениями карт, вопрос - только для автора
+func readingText(l i18n.Lang, r *db.Reading, withQuestion bool) string {
+	spread, _ := tarot.SpreadByID(r.Spread)
+
+	lines := make([]string, 0, len(r.Cards))
+	for i, c := range r.Cards {
+		card, _ := tarot.ByID(c.Card)
+		d := tarot.Drawn{Card: card, Reversed: c.Reversed}
+
+		position := ""
+		if i < spread.Size() {
+			position = spread.Positions[i].In(l)
+		}
+		lines = append(lines, i18n.T(l, i18n.BotSpreadPosition, i+1, position,
+			cardName(l, d), strings.Join(d.Keywords(l), ", ")))
+	}
+
+	text := i18n.T(l, i18n.BotSpreadResult, spread.Name.In(l), strings.Join(lines, "\n"))
+	if withQuestion && r.Question != "" {
+		text = r.Question + "\n\n" + text
+	}
+	return text
+}
+
+// cardName - название карты с пометкой перевернутого положения
+func cardName(l i18n.Lang, d tarot.Drawn) string {
+	name := d.Card.Name.In(l)
+	if d.Reversed {
+		return i18n.T(l, i18n.BotCardReversed, name)
+	}
+	return name
+}
+
+// cardText - название и значение вытянутой карты
+func cardText(l i18n.Lang, d tarot.Drawn) string {
+	return i18n.T(l, i18n.BotCard, cardName(l, d), strings.Join(d.Keywords(l), ", "))
+}
@@ -4,5 +4,11 @@ import "taro-api/cmd/bot"
 
 // Handler - структура обработчика
 type Handler struct {
-	bot *bot.TaroBot
-}
\ No newline at end of file
+	bot   *bot.TaroBot
+	users BotUserStore
+}
+
+// BotUserStore - отметка, что пользователь снова начал диалог с ботом после блокировки
+type BotUserStore interface {
+	SetBotBlocked(telegramID int64, blocked bool) error
+}
@@ -1,156 +1,345 @@
 package telegram
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
-	"taro-api/internal/utils"
+	"strings"
+	"sync"
+	"time"
 )
 
-// UserProfileResponse - структура ответа API telegram о текущем пользователе
-type UserProfileResponse struct {
-	Ok     bool `json:"ok"`
-	Result struct {
-		ID                                 int      `json:"id"`
-		FirstName                          string   `json:"first_name"`
-		LastName                           string   `json:"last_name"`
-		Username                           string   `json:"username"`
-		Type                               string   `json:"type"`
-		ActiveUsernames                    []string `json:"active_usernames"`
-		Bio                                string   `json:"bio"`
-		HasPrivateForwards                 bool     `json:"has_private_forwards"`
-		HasRestrictedVoiceAndVideoMessages bool     `json:"has_restricted_voice_and_video_messages"`
-		BusinessIntro                      struct {
-			Sticker struct {
-				Width      int    `json:"width"`
-				Height     int    `json:"height"`
-				Emoji      string `json:"emoji"`
-				SetName    string `json:"set_name"`
-				IsAnimated bool   `json:"is_animated"`
-				IsVideo    bool   `json:"is_video"`
-				Type       string `json:"type"`
-				Thumbnail  struct {
-					FileID       string `json:"file_id"`
-					FileUniqueID string `json:"file_unique_id"`
-					FileSize     int    `json:"file_size"`
-					Width        int    `json:"width"`
-					Height       int    `json:"height"`
-				} `json:"thumbnail"`
-				Thumb struct {
-					FileID       string `json:"file_id"`
-					FileUniqueID string `json:"file_unique_id"`
-					FileSize     int    `json:"file_size"`
-					Width        int    `json:"width"`
-					Height       int    `json:"height"`
-				} `json:"thumb"`
-				FileID       string `json:"file_id"`
-				FileUniqueID string `json:"file_unique_id"`
-				FileSize     int    `json:"file_size"`
-			} `json:"sticker"`
-		} `json:"business_intro"`
-		PersonalChat struct {
-			ID       int    `json:"id"`
-			Title    string `json:"title"`
-			Username string `json:"username"`
-			Type     string `json:"type"`
-		} `json:"personal_chat"`
-		Photo struct {
-			SmallFileID       string `json:"small_file_id"`
-			SmallFileUniqueID string `json:"small_file_unique_id"`
-			BigFileID         string `json:"big_file_id"`
-			BigFileUniqueID   string `json:"big_file_unique_id"`
-		} `json:"photo"`
-		EmojiStatusCustomEmojiID string `json:"emoji_status_custom_emoji_id"`
-		MaxReactionCount         int    `json:"max_reaction_count"`
-		AccentColorID            int    `json:"accent_color_id"`
-		BackgroundCustomEmojiID  string `json:"background_custom_emoji_id"`
-		ProfileAccentColorID     int    `json:"profile_accent_color_id"`
-	} `json:"result"`
-}
-
 const (
-	apiURL = "https://api.telegram.org"
+	// DefaultBaseURL - адрес Telegram Bot API
+	DefaultBaseURL = "https://api.telegram.org"
+
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 3
+	defaultBackoff    = 500 * time.Millisecond
+	maxRetryAfter     = 30 * time.Second
+	// maxFileSize - больше не скачивается: маленький аватар весит десятки килобайт,
+	// большой (640x640) - сотни
+	maxFileSize = 1 << 20
+
+	redactedToken = "<redacted>"
+)
+
+// Возможные ошибки
+var (
+	ErrNoPhoto      = errors.New("user has no profile photo")
+	ErrFileTooLarge = errors.New("file is too large")
 )
 
-// GetUserAvatarOriginalURL возвращает приватную ссылку на аватар пользователя Telegram
-func GetUserAvatarOriginalURL(telegramID int64, token string) (string, error) {
-	telegramIDStr := strconv.FormatInt(telegramID, 10)
-	url := utils.SumStrings(apiURL, "/bot", token, "/getChat?chat_id=", telegramIDStr)
-	response, err := http.Get(url)
+// APIError - ошибка, которую вернул Telegram Bot API (ok:false)
+type APIError struct {
+	Method      string
+	Code        int
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram %s: %d %s", e.Method, e.Code, e.Description)
+}
+
+// Client - клиент Telegram Bot API
+type Client struct {
+	baseURL    string
+	mu         sync.RWMutex
+	token      string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// Option - опция клиента
+type Option func(*Client)
+
+// WithBaseURL - задает адрес Bot API (например, адрес фейкового сервера в тестах)
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHTTPClient - задает HTTP клиент, nil - клиент по умолчанию
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout - задает таймаут одного запроса, не меняя остальные настройки HTTP клиента.
+// Клиент копируется: переданный в WithHTTPClient может использоваться где-то еще
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		var httpClient http.Client
+		if c.httpClient != nil {
+			httpClient = *c.httpClient
+		}
+		httpClient.Timeout = timeout
+		c.httpClient = &httpClient
+	}
+}
+
+// WithRetries - задает количество повторов и начальную задержку между ними
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// New - конструктор клиента Telegram Bot API
+func New(token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    DefaultBaseURL,
+		token:      token,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
 
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+
+	return c
+}
+
+// GetChat - возвращает информацию о чате или пользователе
+func (c *Client) GetChat(ctx context.Context, chatID int64) (*Chat, error) {
+	var chat Chat
+	params := url.Values{"chat_id": {strconv.FormatInt(chatID, 10)}}
+	if err := c.call(ctx, "getChat", params, &chat); err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+// GetFile - возвращает информацию о файле для скачивания
+func (c *Client) GetFile(ctx context.Context, fileID string) (*File, error) {
+	var file File
+	params := url.Values{"file_id": {fileID}}
+	if err := c.call(ctx, "getFile", params, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// GetMe - возвращает информацию о боте
+func (c *Client) GetMe(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.call(ctx, "getMe", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DownloadFile - скачивает файл по его file_path, файлы больше maxFileSize - ErrFileTooLarge
+func (c *Client) DownloadFile(ctx context.Context, filePath string) ([]byte, string, error) {
+	const op = "telegram.DownloadFile"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.fileURL(filePath), nil)
 	if err != nil {
-		slog.Error("Error getting avatar URL", "error", err)
-		return "", err
+		return nil, "", fmt.Errorf("%s: %w", op, c.redact(err))
 	}
-	defer response.Body.Close()
 
-	body, err := io.ReadAll(response.Body)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		slog.Error("Error reading response body", "error", err)
-		return "", err
+		return nil, "", fmt.Errorf("%s: %w", op, c.redact(err))
 	}
+	defer resp.Body.Close()
 
-	fileID, err := getAvatarURLFromResponse(body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%s: unexpected status %d", op, resp.StatusCode)
+	}
+
+	if resp.ContentLength > maxFileSize {
+		return nil, "", fmt.Errorf("%s: %w", op, ErrFileTooLarge)
+	}
 
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize+1))
 	if err != nil {
-		slog.Error("Error getting avatar 160x160 fileID from response", "error", err)
-		return "", err
+		return nil, "", fmt.Errorf("%s: %w", op, c.redact(err))
+	}
+	if len(data) > maxFileSize {
+		return nil, "", fmt.Errorf("%s: %w", op, ErrFileTooLarge)
 	}
 
-	photoURL, err := getTelegramAvatarURLByFileID(fileID, token)
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(data)
+	}
 
+	return data, contentType, nil
+}
+
+// GetUserAvatar - возвращает аватар пользователя (маленькая версия) и его тип
+func (c *Client) GetUserAvatar(ctx context.Context, telegramID int64) ([]byte, string, error) {
+	chat, err := c.GetChat(ctx, telegramID)
 	if err != nil {
-		slog.Error("Error getting avatar file URL", "error", err)
-		return "", err
+		return nil, "", err
 	}
 
-	resp, err := http.Get(photoURL)
-	if err != nil {
-		slog.Error("Error getting avatar image", "error", err)
-		return "", err
+	if chat.Photo == nil || chat.Photo.SmallFileID == "" {
+		return nil, "", ErrNoPhoto
 	}
-	defer resp.Body.Close()
 
-	return photoURL, nil
+	file, err := c.GetFile(ctx, chat.Photo.SmallFileID)
+	if err != nil {
+		return nil, "", err
+	}
 
+	return c.DownloadFile(ctx, file.FilePath)
 }
 
-func getAvatarURLFromResponse(body []byte) (string, error) {
-	var response UserProfileResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", err
+// call - выполняет метод Bot API с повтором при 429 и ошибках сети
+func (c *Client) call(ctx context.Context, method string, params url.Values, out any) error {
+	op := "telegram." + method
+
+	var lastErr error
+	delay := c.backoff
+
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("%s: %w", op, ctx.Err())
+			case <-timer.C:
+			}
+			delay *= 2
+		}
+
+		retry, err := c.do(ctx, method, params, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			delay = min(apiErr.RetryAfter, maxRetryAfter)
+		}
+
+		if !retry {
+			break
+		}
 	}
 
-	return response.Result.Photo.SmallFileID, nil
+	return fmt.Errorf("%s: %w", op, lastErr)
 }
 
-func getTelegramAvatarURLByFileID(fileID string, token string) (string, error) {
+// do - выполняет один запрос и сообщает, можно ли его повторить
+func (c *Client) do(ctx context.Context, method string, params url.Values, out any) (bool, error) {
+	endpoint := c.methodURL(method)
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
 
-	url := utils.SumStrings(apiURL, "/bot", token, "/getFile?file_id=", fileID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, c.redact(err)
+	}
 
-	response, err := http.Get(url)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return ctx.Err() == nil, c.redact(err)
 	}
-	defer response.Body.Close()
+	defer resp.Body.Close()
 
-	var responseData struct {
-		Ok     bool `json:"ok"`
-		Result struct {
-			FilePath string `json:"file_path"`
-		} `json:"result"`
+	var envelope struct {
+		Ok          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		ErrorCode   int             `json:"error_code"`
+		Description string          `json:"description"`
+		Parameters  *struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
 	}
 
-	err = json.NewDecoder(response.Body).Decode(&responseData)
-	if err != nil {
-		return "", err
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return resp.StatusCode >= http.StatusInternalServerError,
+			fmt.Errorf("decode response (status %d): %w", resp.StatusCode, err)
 	}
 
-	filePath := responseData.Result.FilePath
+	if !envelope.Ok {
+		apiErr := &APIError{
+			Method:      method,
+			Code:        envelope.ErrorCode,
+			Description: envelope.Description,
+		}
+		if apiErr.Code == 0 {
+			apiErr.Code = resp.StatusCode
+		}
+		if envelope.Parameters != nil && envelope.Parameters.RetryAfter > 0 {
+			apiErr.RetryAfter = time.Duration(envelope.Parameters.RetryAfter) * time.Second
+		}
+		retry := apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= http.StatusInternalServerError
+		return retry, apiErr
+	}
+
+	if out == nil {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return false, fmt.Errorf("decode result: %w", err)
+	}
+
+	return false, nil
+}
+
+// SetToken - заменяет токен бота (ротация без пересоздания клиента)
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+}
+
+func (c *Client) currentToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+func (c *Client) methodURL(method string) string {
+	return c.baseURL + "/bot" + c.currentToken() + "/" + method
+}
+
+func (c *Client) fileURL(filePath string) string {
+	return c.baseURL + "/file/bot" + c.currentToken() + "/" + filePath
+}
 
-	avatarURL := utils.SumStrings(apiURL, "/file/bot", token, "/", filePath)
+// redact - убирает токен бота из текста ошибки (net/url включает адрес запроса)
+func (c *Client) redact(err error) error {
+	token := c.currentToken()
+	if err == nil || token == "" {
+		return err
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, token) {
+		return err
+	}
+	return &redactedError{msg: strings.ReplaceAll(msg, token, redactedToken), err: err}
+}
 
-	return avatarURL, nil
+type redactedError struct {
+	msg string
+	err error
 }
+
+func (e *redactedError) Error() string { return e.msg }
+
+// Unwrap - сохраняет возможность errors.Is для context.Canceled и т.п.
+func (e *redactedError) Unwrap() error { return errors.Unwrap(e.err) }
@@ -0,0 +1,266 @@
+package telegram_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"taro-api/internal/helpers/telegram"
+	"taro-api/internal/helpers/telegram/telegramtest"
+	"testing"
+	"time"
+)
+
+const testToken = "123456:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+func TestGetMe(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	me, err := srv.Client().GetMe(context.Background())
+	if err != nil {
+		t.Fatalf("GetMe: %v", err)
+	}
+	if me.Username != "taroki_test_bot" || !me.IsBot {
+		t.Errorf("GetMe = %+v, want the fake bot", me)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	srv.FailNext("getMe", telegramtest.Failure{
+		Code:        http.StatusTooManyRequests,
+		Description: "Too Many Requests: retry after 1",
+		RetryAfter:  1,
+	})
+
+	// начальная задержка заведомо меньше retry_after: ждать нужно столько, сколько просит Telegram
+	client := srv.Client(telegram.WithRetries(1, time.Millisecond))
+
+	start := time.Now()
+	if _, err := client.GetMe(context.Background()); err != nil {
+		t.Fatalf("GetMe: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least retry_after (1s)", elapsed)
+	}
+	if calls := srv.Calls("getMe"); calls != 2 {
+		t.Errorf("getMe called %d times, want 2", calls)
+	}
+}
+
+func TestRetryAfterExhausted(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	srv.FailNext("getMe", telegramtest.Failure{Code: http.StatusTooManyRequests, Description: "Too Many Requests", RetryAfter: 5})
+
+	_, err := srv.Client(telegram.WithRetries(0, time.Millisecond)).GetMe(context.Background())
+
+	var apiErr *telegram.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("GetMe error = %v, want *APIError", err)
+	}
+	if apiErr.Code != http.StatusTooManyRequests || apiErr.RetryAfter != 5*time.Second {
+		t.Errorf("APIError = %+v, want code 429 and retry after 5s", apiErr)
+	}
+}
+
+func TestRetryAfterCanceled(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	srv.FailNext("getMe", telegramtest.Failure{Code: http.StatusTooManyRequests, Description: "Too Many Requests", RetryAfter: 30})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := srv.Client().GetMe(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetMe error = %v, want context.DeadlineExceeded", err)
+	}
+	if calls := srv.Calls("getMe"); calls != 1 {
+		t.Errorf("getMe called %d times, want 1", calls)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		failure telegramtest.Failure
+		calls   int
+	}{
+		{
+			name:    "client error is not retried",
+			failure: telegramtest.Failure{Code: http.StatusBadRequest, Description: "Bad Request: chat not found"},
+			calls:   1,
+		},
+		{
+			name:    "blocked by user",
+			failure: telegramtest.Failure{Code: http.StatusForbidden, Description: "Forbidden: bot was blocked by the user"},
+			calls:   1,
+		},
+		{
+			name:    "server error is retried",
+			failure: telegramtest.Failure{Code: http.StatusBadGateway, Description: "Bad Gateway"},
+			calls:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := telegramtest.NewServer(testToken)
+			defer srv.Close()
+
+			// повтор тоже завершается ошибкой, чтобы проверить, сколько раз был вызван метод
+			srv.FailNext("getMe", tt.failure)
+			srv.FailNext("getMe", tt.failure)
+
+			_, err := srv.Client(telegram.WithRetries(1, time.Millisecond)).GetMe(context.Background())
+
+			var apiErr *telegram.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("GetMe error = %v, want *APIError", err)
+			}
+			if apiErr.Method != "getMe" || apiErr.Code != tt.failure.Code || apiErr.Description != tt.failure.Description {
+				t.Errorf("APIError = %+v, want %+v", apiErr, tt.failure)
+			}
+			if calls := srv.Calls("getMe"); calls != tt.calls {
+				t.Errorf("getMe called %d times, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestTokenRedaction(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	client := srv.Client(telegram.WithRetries(0, time.Millisecond))
+	// ошибка соединения с закрытым сервером содержит адрес запроса вместе с токеном
+	srv.Close()
+
+	_, err := client.GetMe(context.Background())
+	if err == nil {
+		t.Fatal("GetMe with closed server succeeded")
+	}
+	if strings.Contains(err.Error(), testToken) {
+		t.Errorf("error contains bot token: %v", err)
+	}
+	if !strings.Contains(err.Error(), "<redacted>") {
+		t.Errorf("error = %v, want redacted URL", err)
+	}
+
+	_, _, err = client.DownloadFile(context.Background(), "photos/1.jpg")
+	if err == nil || strings.Contains(err.Error(), testToken) {
+		t.Errorf("DownloadFile error = %v, want error without bot token", err)
+	}
+}
+
+func TestSetToken(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	client := telegram.New("654321:BBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB", telegram.WithBaseURL(srv.URL))
+
+	var apiErr *telegram.APIError
+	if _, err := client.GetMe(context.Background()); !errors.As(err, &apiErr) || apiErr.Code != http.StatusUnauthorized {
+		t.Fatalf("GetMe with old token error = %v, want 401", err)
+	}
+
+	client.SetToken(testToken)
+	if _, err := client.GetMe(context.Background()); err != nil {
+		t.Fatalf("GetMe with new token: %v", err)
+	}
+}
+
+func TestGetUserAvatar(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	avatar := []byte("\xff\xd8\xff\xe0fake jpeg")
+	srv.SetUserAvatar(42, avatar)
+	srv.SetChat(telegram.Chat{ID: 43, Type: "private"})
+
+	client := srv.Client()
+
+	data, contentType, err := client.GetUserAvatar(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("GetUserAvatar: %v", err)
+	}
+	if string(data) != string(avatar) || contentType != "image/jpeg" {
+		t.Errorf("GetUserAvatar = %q, %q, want avatar as image/jpeg", data, contentType)
+	}
+
+	if _, _, err := client.GetUserAvatar(context.Background(), 43); !errors.Is(err, telegram.ErrNoPhoto) {
+		t.Errorf("GetUserAvatar without photo error = %v, want ErrNoPhoto", err)
+	}
+}
+
+func TestWithTimeoutKeepsHTTPClient(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	transport := &countingTransport{next: http.DefaultTransport}
+	httpClient := &http.Client{Transport: transport}
+
+	client := srv.Client(telegram.WithHTTPClient(httpClient), telegram.WithTimeout(time.Second))
+	if _, err := client.GetMe(context.Background()); err != nil {
+		t.Fatalf("GetMe: %v", err)
+	}
+
+	if transport.requests != 1 {
+		t.Errorf("transport from WithHTTPClient handled %d requests, want 1", transport.requests)
+	}
+	if httpClient.Timeout != 0 {
+		t.Errorf("WithTimeout changed the shared HTTP client: timeout %v", httpClient.Timeout)
+	}
+}
+
+type countingTransport struct {
+	next     http.RoundTripper
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.requests++
+	return t.next.RoundTrip(r)
+}
+
+func TestWithTimeoutWithoutHTTPClient(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	for name, opts := range map[string][]telegram.Option{
+		"timeout":          {telegram.WithHTTPClient(nil), telegram.WithTimeout(time.Second)},
+		"nil http client":  {telegram.WithHTTPClient(nil)},
+		"timeout then nil": {telegram.WithTimeout(time.Second), telegram.WithHTTPClient(nil)},
+	} {
+		if _, err := srv.Client(opts...).GetMe(context.Background()); err != nil {
+			t.Errorf("%s: GetMe: %v", name, err)
+		}
+	}
+}
+
+func TestDownloadFileTooLarge(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	srv.SetFile("big", "photos/big.jpg", make([]byte, 1<<20+1))
+	srv.SetFile("max", "photos/max.jpg", make([]byte, 1<<20))
+
+	client := srv.Client()
+
+	if _, _, err := client.DownloadFile(context.Background(), "photos/big.jpg"); !errors.Is(err, telegram.ErrFileTooLarge) {
+		t.Errorf("DownloadFile of a large file error = %v, want ErrFileTooLarge", err)
+	}
+
+	data, _, err := client.DownloadFile(context.Background(), "photos/max.jpg")
+	if err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	if len(data) != 1<<20 {
+		t.Errorf("downloaded %d bytes, want %d", len(data), 1<<20)
+	}
+}
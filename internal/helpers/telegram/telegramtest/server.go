@@ -0,0 +1,222 @@
+// Package telegramtest - фейковый Telegram Bot API на httptest для офлайн тестов
+package telegramtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"taro-api/internal/helpers/telegram"
+)
+
+// Failure - заранее заданная ошибка для следующего вызова метода
+type Failure struct {
+	Code        int
+	Description string
+	RetryAfter  int
+}
+
+// Server - фейковый сервер Bot API
+type Server struct {
+	*httptest.Server
+
+	Token string
+
+	mu       sync.Mutex
+	me       telegram.User
+	chats    map[int64]telegram.Chat
+	files    map[string]telegram.File
+	contents map[string][]byte
+	failures map[string][]Failure
+	calls    map[string]int
+	// chatIDs - chat_id успешных отправок по методам
+	chatIDs   map[string][]int64
+	messageID int
+}
+
+// NewServer - запускает фейковый сервер, принимающий только указанный токен
+func NewServer(token string) *Server {
+	s := &Server{
+		Token:    token,
+		me:       telegram.User{ID: 1, IsBot: true, FirstName: "Taroki", Username: "taroki_test_bot"},
+		chats:    make(map[int64]telegram.Chat),
+		files:    make(map[string]telegram.File),
+		contents: make(map[string][]byte),
+		failures: make(map[string][]Failure),
+		calls:    make(map[string]int),
+		chatIDs:  make(map[string][]int64),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Client - клиент, настроенный на этот сервер
+func (s *Server) Client(opts ...telegram.Option) *telegram.Client {
+	opts = append([]telegram.Option{telegram.WithBaseURL(s.URL)}, opts...)
+	return telegram.New(s.Token, opts...)
+}
+
+// SetMe - задает ответ getMe
+func (s *Server) SetMe(me telegram.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.me = me
+}
+
+// SetChat - задает ответ getChat
+func (s *Server) SetChat(chat telegram.Chat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chats[chat.ID] = chat
+}
+
+// SetFile - регистрирует файл для getFile и скачивания
+func (s *Server) SetFile(fileID, filePath string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[fileID] = telegram.File{
+		FileID:       fileID,
+		FileUniqueID: fileID,
+		FileSize:     int64(len(data)),
+		FilePath:     filePath,
+	}
+	s.contents[filePath] = data
+}
+
+// SetUserAvatar - задает пользователю аватар целиком (getChat, getFile и файл)
+func (s *Server) SetUserAvatar(telegramID int64, data []byte) {
+	fileID := "avatar-" + strconv.FormatInt(telegramID, 10)
+	s.SetChat(telegram.Chat{
+		ID:    telegramID,
+		Type:  "private",
+		Photo: &telegram.ChatPhoto{SmallFileID: fileID, SmallFileUniqueID: fileID},
+	})
+	s.SetFile(fileID, "photos/"+fileID+".jpg", data)
+}
+
+// FailNext - следующий вызов метода вернет указанную ошибку
+func (s *Server) FailNext(method string, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], f)
+}
+
+// Calls - количество вызовов метода
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// ChatIDs - получатели успешных вызовов метода отправки (copyMessage, sendMessage) по порядку
+func (s *Server) ChatIDs(method string) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.chatIDs[method])
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if filePath, ok := strings.CutPrefix(r.URL.Path, "/file/bot"+s.Token+"/"); ok {
+		s.serveFile(w, filePath)
+		return
+	}
+
+	method, ok := strings.CutPrefix(r.URL.Path, "/bot"+s.Token+"/")
+	if !ok {
+		writeError(w, Failure{Code: http.StatusUnauthorized, Description: "Unauthorized"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls[method]++
+
+	if queue := s.failures[method]; len(queue) > 0 {
+		s.failures[method] = queue[1:]
+		writeError(w, queue[0])
+		return
+	}
+
+	switch method {
+	case "getMe":
+		writeResult(w, s.me)
+	case "getChat":
+		id, _ := strconv.ParseInt(r.URL.Query().Get("chat_id"), 10, 64)
+		chat, ok := s.chats[id]
+		if !ok {
+			writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: chat not found"})
+			return
+		}
+		writeResult(w, chat)
+	case "getFile":
+		file, ok := s.files[r.URL.Query().Get("file_id")]
+		if !ok {
+			writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: invalid file_id"})
+			return
+		}
+		writeResult(w, file)
+	case "copyMessage", "sendMessage":
+		chatID, err := requestChatID(r)
+		if err != nil {
+			writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: chat not found"})
+			return
+		}
+		s.chatIDs[method] = append(s.chatIDs[method], chatID)
+		s.messageID++
+		writeResult(w, map[string]any{
+			"message_id": s.messageID,
+			"date":       0,
+			"chat":       map[string]any{"id": chatID, "type": "private"},
+		})
+	default:
+		writeError(w, Failure{Code: http.StatusNotFound, Description: "Not Found: method not found"})
+	}
+}
+
+// requestChatID - chat_id из параметров запроса или JSON тела, как его отправляет telebot
+func requestChatID(r *http.Request) (int64, error) {
+	if v := r.URL.Query().Get("chat_id"); v != "" {
+		return strconv.ParseInt(v, 10, 64)
+	}
+
+	var body struct {
+		ChatID json.RawMessage `json:"chat_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.Trim(string(body.ChatID), `"`), 10, 64)
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, filePath string) {
+	s.mu.Lock()
+	data, ok := s.contents[filePath]
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Write(data)
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+func writeError(w http.ResponseWriter, f Failure) {
+	body := map[string]any{"ok": false, "error_code": f.Code, "description": f.Description}
+	if f.RetryAfter > 0 {
+		body["parameters"] = map[string]int{"retry_after": f.RetryAfter}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.Code)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,7 @@
+package dto
+
+// Health - результат проверки готовности
+type Health struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
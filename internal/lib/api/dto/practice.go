@@ -0,0 +1,178 @@
+package dto
+
+import (
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/practice"
+	"taro-api/internal/lib/tarot"
+	"taro-api/internal/storage/db"
+	"time"
+)
+
+// Practice - тренировочный расклад: вопрос и карты по позициям без значений,
+// их пользователь толкует сам
+type Practice struct {
+	ID         string             `json:"id"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Spread     string             `json:"spread"`
+	SpreadName string             `json:"spread_name"`
+	Question   string             `json:"question"`
+	Positions  []PracticePosition `json:"positions"`
+}
+
+// PracticePosition - карта на позиции тренировочного расклада
+type PracticePosition struct {
+	Position string `json:"position"`
+	Card     string `json:"card"`
+	Name     string `json:"name"`
+	Reversed bool   `json:"reversed"`
+}
+
+// PracticeAnswerRequest - толкования позиций тренировочного расклада по порядку
+type PracticeAnswerRequest struct {
+	Interpretations []string `json:"interpretations" validate:"required,min=1,max=10,dive,max=2000"`
+}
+
+// PracticeResult - оценка ответа и серия дней с тренировкой после него
+type PracticeResult struct {
+	ID string `json:"id"`
+	// Score - средняя оценка позиций от 0 до 100
+	Score      int                     `json:"score"`
+	Streak     int                     `json:"streak"`
+	BestStreak int                     `json:"best_streak"`
+	Positions  []PracticePositionGrade `json:"positions"`
+}
+
+// PracticePositionGrade - оценка толкования позиции с ключевыми словами значения карты
+type PracticePositionGrade struct {
+	Position       string   `json:"position"`
+	Card           string   `json:"card"`
+	Name           string   `json:"name"`
+	Reversed       bool     `json:"reversed"`
+	Interpretation string   `json:"interpretation"`
+	Score          int      `json:"score"`
+	Correct        bool     `json:"correct"`
+	Matched        []string `json:"matched"`
+	Keywords       []string `json:"keywords"`
+	// OppositeOrientation - толкование ближе к значению карты в другом положении
+	OppositeOrientation bool   `json:"opposite_orientation"`
+	Feedback            string `json:"feedback"`
+}
+
+// PracticeStats - серия, средняя оценка и успехи по картам
+type PracticeStats struct {
+	Streak       int                 `json:"streak"`
+	BestStreak   int                 `json:"best_streak"`
+	Answered     int64               `json:"answered"`
+	AverageScore float64             `json:"average_score"`
+	Cards        []PracticeCardStats `json:"cards"`
+}
+
+// PracticeCardStats - успехи в толковании карты, карты идут по сроку повторения
+type PracticeCardStats struct {
+	Card     string  `json:"card"`
+	Name     string  `json:"name"`
+	Attempts int     `json:"attempts"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+	Box      int     `json:"box"`
+	// DueAt - когда карту пора повторить
+	DueAt time.Time `json:"due_at"`
+}
+
+// NewPractice - собирает DTO из модели хранилища, тексты - на языке l
+func NewPractice(p *db.Practice, l i18n.Lang) Practice {
+	spread, _ := tarot.SpreadByID(p.Spread)
+	question, _ := practice.QuestionByID(p.Question)
+
+	return Practice{
+		ID:         p.ID.String(),
+		CreatedAt:  p.CreatedAt,
+		Spread:     p.Spread,
+		SpreadName: spread.Name.In(l),
+		Question:   question.Text.In(l),
+		Positions:  practicePositions(p, spread, l),
+	}
+}
+
+// NewPracticeResult - собирает DTO из оценок grades толкований answers
+func NewPracticeResult(p *db.Practice, user *db.User, answers []string, grades []practice.Grade, score int, l i18n.Lang) PracticeResult {
+	spread, _ := tarot.SpreadByID(p.Spread)
+
+	result := PracticeResult{
+		ID:         p.ID.String(),
+		Score:      score,
+		Streak:     user.PracticeStreak,
+		BestStreak: user.PracticeBestStreak,
+		Positions:  make([]PracticePositionGrade, 0, len(grades)),
+	}
+
+	for i, position := range practicePositions(p, spread, l) {
+		g := grades[i]
+		keywords := g.Keywords
+		if keywords == nil {
+			keywords = []string{}
+		}
+
+		result.Positions = append(result.Positions, PracticePositionGrade{
+			Position:            position.Position,
+			Card:                position.Card,
+			Name:                position.Name,
+			Reversed:            position.Reversed,
+			Interpretation:      answers[i],
+			Score:               g.Score,
+			Correct:             g.Correct,
+			Matched:             g.Matched,
+			Keywords:            keywords,
+			OppositeOrientation: g.Opposite,
+			Feedback:            g.Feedback(l),
+		})
+	}
+
+	return result
+}
+
+// NewPracticeStats - собирает DTO статистики, названия карт - на языке l
+func NewPracticeStats(s *db.PracticeStats, l i18n.Lang) PracticeStats {
+	stats := PracticeStats{
+		Streak:       s.Streak,
+		BestStreak:   s.BestStreak,
+		Answered:     s.Answered,
+		AverageScore: s.AverageScore,
+		Cards:        make([]PracticeCardStats, 0, len(s.Cards)),
+	}
+
+	for _, c := range s.Cards {
+		card, _ := tarot.ByID(c.Card)
+		stats.Cards = append(stats.Cards, PracticeCardStats{
+			Card:     c.Card,
+			Name:     card.Name.In(l),
+			Attempts: c.Attempts,
+			Correct:  c.Correct,
+			Accuracy: c.Accuracy(),
+			Box:      c.Box,
+			DueAt:    c.DueAt,
+		})
+	}
+
+	return stats
+}
+
+func practicePositions(p *db.Practice, spread tarot.Spread, l i18n.Lang) []PracticePosition {
+	positions := make([]PracticePosition, 0, len(p.Cards))
+	for i, c := range p.Cards {
+		card, _ := tarot.ByID(c.Card)
+
+		position := ""
+		if i < spread.Size() {
+			position = spread.Positions[i].In(l)
+		}
+
+		positions = append(positions, PracticePosition{
+			Position: position,
+			Card:     c.Card,
+			Name:     card.Name.In(l),
+			Reversed: c.Reversed,
+		})
+	}
+	return positions
+}
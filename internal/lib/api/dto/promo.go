@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// PromoRedeemRequest - активация промокода
+type PromoRedeemRequest struct {
+	Code string `json:"code" validate:"required,max=64"`
+}
+
+// PromoRedemption - результат активации: награда и баланс после нее
+type PromoRedemption struct {
+	Code string `json:"code"`
+	// RewardType - balance или entitlement
+	RewardType  string `json:"reward_type"`
+	Amount      int64  `json:"amount,omitempty"`
+	Entitlement string `json:"entitlement,omitempty"`
+	// EntitlementUntil - до какого момента действует доступ, пусто - бессрочно
+	EntitlementUntil *time.Time `json:"entitlement_until,omitempty"`
+	Balance          int64      `json:"balance"`
+}
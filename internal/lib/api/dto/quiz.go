@@ -0,0 +1,162 @@
+package dto
+
+import (
+	"strings"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/quiz"
+	"taro-api/internal/lib/tarot"
+	"taro-api/internal/storage/db"
+	"time"
+)
+
+// QuizQuestion - вопрос карточки. Поля, по которым можно догадаться об ответе, не отдаются:
+// в вопросе по значению нет карты, в вопросе о положении - положения
+type QuizQuestion struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Kind - card_meaning, meaning_card или orientation
+	Kind string `json:"kind"`
+	// Format - choice (ответ - номер варианта) или text (ответ - текст)
+	Format   string   `json:"format"`
+	Prompt   string   `json:"prompt"`
+	Card     string   `json:"card,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Reversed *bool    `json:"reversed,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+	Options  []string `json:"options,omitempty"`
+}
+
+// QuizAnswerRequest - ответ на вопрос карточки: номер варианта или текст, по формату вопроса
+type QuizAnswerRequest struct {
+	QuestionID string `json:"question_id" validate:"required,uuid"`
+	Option     *int   `json:"option" validate:"omitempty,min=0,max=9"`
+	Answer     string `json:"answer" validate:"max=500"`
+}
+
+// QuizResult - проверка ответа, верный ответ и следующее повторение карточки
+type QuizResult struct {
+	QuestionID string `json:"question_id"`
+	Correct    bool   `json:"correct"`
+	// CorrectOption - номер верного варианта, только для вопросов с выбором
+	CorrectOption *int     `json:"correct_option,omitempty"`
+	CorrectAnswer string   `json:"correct_answer"`
+	Card          string   `json:"card"`
+	Name          string   `json:"name"`
+	Reversed      bool     `json:"reversed"`
+	Keywords      []string `json:"keywords"`
+	// IntervalDays и NextReviewAt - когда карточку повторить
+	IntervalDays int       `json:"interval_days"`
+	NextReviewAt time.Time `json:"next_review_at"`
+	// Mastered - карточка выучена: на нее ответили верно несколько раз подряд
+	Mastered bool `json:"mastered"`
+	// Achievements - достижения, полученные этим ответом
+	Achievements []Achievement `json:"achievements"`
+}
+
+// Achievement - достижение пользователя
+type Achievement struct {
+	Code        string    `json:"code"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	UnlockedAt  time.Time `json:"unlocked_at"`
+}
+
+// NewQuizQuestion - собирает DTO из модели хранилища, тексты - на языке l
+func NewQuizQuestion(q *db.QuizQuestion, l i18n.Lang) QuizQuestion {
+	drawn := quizCard(q.Card, q.Reversed)
+	name := drawn.Card.Name.In(l)
+	keywords := strings.Join(drawn.Keywords(l), ", ")
+
+	question := QuizQuestion{
+		ID:        q.ID.String(),
+		CreatedAt: q.CreatedAt,
+		Kind:      q.Kind,
+		Format:    q.Format,
+	}
+
+	switch quiz.Kind(q.Kind) {
+	case quiz.KindCardMeaning:
+		question.Card, question.Name, question.Reversed = q.Card, name, &q.Reversed
+		if q.Reversed {
+			name = i18n.T(l, i18n.BotCardReversed, name)
+		}
+		question.Prompt = i18n.T(l, i18n.QuizPromptCard, name)
+	case quiz.KindMeaningCard:
+		question.Keywords = drawn.Keywords(l)
+		question.Prompt = i18n.T(l, i18n.QuizPromptMeaning, keywords)
+	case quiz.KindOrientation:
+		question.Card, question.Name = q.Card, name
+		question.Keywords = drawn.Keywords(l)
+		question.Prompt = i18n.T(l, i18n.QuizPromptOrientation, name, keywords)
+	}
+
+	for _, o := range q.Options {
+		question.Options = append(question.Options, quizOption(quiz.Kind(q.Kind), quizCard(o.Card, o.Reversed), l))
+	}
+
+	return question
+}
+
+// NewQuizResult - собирает DTO проверки ответа на вопрос q
+func NewQuizResult(q *db.QuizQuestion, correct bool, outcome *db.QuizOutcome, l i18n.Lang) QuizResult {
+	drawn := quizCard(q.Card, q.Reversed)
+
+	keywords := drawn.Keywords(l)
+	if keywords == nil {
+		keywords = []string{}
+	}
+
+	result := QuizResult{
+		QuestionID:    q.ID.String(),
+		Correct:       correct,
+		CorrectAnswer: quizOption(quiz.Kind(q.Kind), drawn, l),
+		Card:          q.Card,
+		Name:          drawn.Card.Name.In(l),
+		Reversed:      q.Reversed,
+		Keywords:      keywords,
+		IntervalDays:  outcome.Item.Interval,
+		NextReviewAt:  outcome.Item.DueAt,
+		Mastered:      outcome.Item.Mastered(),
+		Achievements:  make([]Achievement, 0, len(outcome.Achievements)),
+	}
+	if q.Format == string(quiz.FormatChoice) {
+		result.CorrectOption = &q.Answer
+	}
+
+	for _, a := range outcome.Achievements {
+		result.Achievements = append(result.Achievements, NewAchievement(a, l))
+	}
+
+	return result
+}
+
+// NewAchievement - достижение с названием и описанием на языке l
+func NewAchievement(a db.Achievement, l i18n.Lang) Achievement {
+	return Achievement{
+		Code:        a.Code,
+		Title:       i18n.T(l, i18n.AchievementKey(a.Code)),
+		Description: i18n.T(l, i18n.AchievementDescriptionKey(a.Code)),
+		UnlockedAt:  a.CreatedAt,
+	}
+}
+
+// quizOption - вариант ответа так, как его видит пользователь: в вопросе по карте - значение,
+// по значению - название карты, о положении - положение
+func quizOption(kind quiz.Kind, d tarot.Drawn, l i18n.Lang) string {
+	switch kind {
+	case quiz.KindMeaningCard:
+		return d.Card.Name.In(l)
+	case quiz.KindOrientation:
+		if d.Reversed {
+			return i18n.T(l, i18n.QuizReversed)
+		}
+		return i18n.T(l, i18n.QuizUpright)
+	default:
+		return strings.Join(d.Keywords(l), ", ")
+	}
+}
+
+func quizCard(id string, reversed bool) tarot.Drawn {
+	card, _ := tarot.ByID(id)
+	return tarot.Drawn{Card: card, Reversed: reversed}
+}
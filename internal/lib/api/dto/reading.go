@@ -0,0 +1,92 @@
+package dto
+
+import (
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/tarot"
+	"taro-api/internal/storage/db"
+	"time"
+)
+
+// Reading - расклад с названиями карт и позиций на языке пользователя. Автор и вопрос есть
+// только в раскладе, который смотрит его автор (см. NewPublicReading)
+type Reading struct {
+	ID         string        `json:"id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	TelegramID int64         `json:"telegram_id,omitempty"`
+	Spread     string        `json:"spread"`
+	SpreadName string        `json:"spread_name"`
+	Question   string        `json:"question,omitempty"`
+	Source     string        `json:"source"`
+	Cards      []ReadingCard `json:"cards"`
+}
+
+// ReadingCard - карта на позиции расклада
+type ReadingCard struct {
+	Position string   `json:"position"`
+	Card     string   `json:"card"`
+	Name     string   `json:"name"`
+	Reversed bool     `json:"reversed"`
+	Keywords []string `json:"keywords"`
+}
+
+// NewReading - собирает DTO из модели хранилища, тексты - на языке l
+func NewReading(r *db.Reading, l i18n.Lang) Reading {
+	spread, _ := tarot.SpreadByID(r.Spread)
+
+	reading := Reading{
+		ID:         r.ID.String(),
+		CreatedAt:  r.CreatedAt,
+		TelegramID: r.TelegramID,
+		Spread:     r.Spread,
+		SpreadName: spread.Name.In(l),
+		Question:   r.Question,
+		Source:     r.Source,
+		Cards:      make([]ReadingCard, 0, len(r.Cards)),
+	}
+
+	for i, c := range r.Cards {
+		card, _ := tarot.ByID(c.Card)
+		drawn := tarot.Drawn{Card: card, Reversed: c.Reversed}
+
+		position := ""
+		if i < spread.Size() {
+			position = spread.Positions[i].In(l)
+		}
+
+		keywords := drawn.Keywords(l)
+		if keywords == nil {
+			keywords = []string{}
+		}
+
+		reading.Cards = append(reading.Cards, ReadingCard{
+			Position: position,
+			Card:     c.Card,
+			Name:     card.Name.In(l),
+			Reversed: c.Reversed,
+			Keywords: keywords,
+		})
+	}
+
+	return reading
+}
+
+// NewPublicReading - расклад для других пользователей, которым им поделились: без автора и вопроса
+func NewPublicReading(r *db.Reading, l i18n.Lang) Reading {
+	reading := NewReading(r, l)
+	reading.TelegramID = 0
+	reading.Question = ""
+	return reading
+}
+
+// ShareImageRequest - ссылка на картинку расклада; вопрос на картинке показывается, только
+// если автор это разрешил
+type ShareImageRequest struct {
+	IncludeQuestion bool `json:"include_question"`
+}
+
+// ShareImage - ссылка на картинку расклада для сторис и чатов
+type ShareImage struct {
+	// URL - путь картинки относительно адреса API, с токеном доступа
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+// RegisterRequest - регистрация пользователя Mini App
+type RegisterRequest struct {
+	// ReferralCode - код пригласившего (ref_<telegram_id>) из параметра startapp
+	ReferralCode string `json:"referral_code,omitempty" validate:"omitempty,max=32"`
+}
+
+// ReferralClaimRequest - указание пригласившего после регистрации
+type ReferralClaimRequest struct {
+	ReferralCode string `json:"referral_code" validate:"required,max=32"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+import "time"
+
+// Session - токены, выданные клиенту
+type Session struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int64     `json:"expires_in"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// RefreshRequest - запрос на обновление токенов
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutRequest - запрос на выход
+type LogoutRequest struct {
+	// All - отозвать все сессии пользователя, а не только текущую
+	All bool `json:"all,omitempty"`
+}
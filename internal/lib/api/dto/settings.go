@@ -0,0 +1,48 @@
+package dto
+
+// Settings - профиль и настройки пользователя
+type Settings struct {
+	// DisplayName - имя для обращения, по умолчанию имя из Telegram
+	DisplayName string `json:"display_name"`
+	// Language - выбранный язык, пусто - используется язык Telegram
+	Language string `json:"language"`
+	// Timezone - часовой пояс IANA (Europe/Moscow), пусто - не указан
+	Timezone string `json:"timezone"`
+	// BirthDate - дата рождения YYYY-MM-DD для знака зодиака и карты рождения, пусто - не указана
+	BirthDate     string        `json:"birth_date"`
+	Notifications Notifications `json:"notifications"`
+	// PreferredDeck - колода для раскладов, пусто - колода по умолчанию
+	PreferredDeck string `json:"preferred_deck"`
+}
+
+// Notifications - какие уведомления бот присылает пользователю
+type Notifications struct {
+	// Daily - карта дня
+	Daily bool `json:"daily"`
+	// DailyTime - время карты дня HH:MM в часовом поясе пользователя
+	DailyTime string `json:"daily_time"`
+	// News - новости и мастер-классы
+	News bool `json:"news"`
+}
+
+// SettingsRequest - частичное обновление настроек, незаданные поля не меняются
+type SettingsRequest struct {
+	// DisplayName - пустая строка - вернуться к имени из Telegram
+	DisplayName *string `json:"display_name,omitempty" validate:"omitempty,max=64"`
+	// Language - код языка (ru, en), пустая строка - вернуться к языку Telegram
+	Language *string `json:"language,omitempty" validate:"omitempty,language"`
+	// Timezone - часовой пояс IANA, пустая строка - сбросить
+	Timezone *string `json:"timezone,omitempty" validate:"omitempty,tz"`
+	// BirthDate - YYYY-MM-DD, пустая строка - сбросить
+	BirthDate     *string               `json:"birth_date,omitempty" validate:"omitempty,birthdate"`
+	Notifications *NotificationsRequest `json:"notifications,omitempty"`
+	// PreferredDeck - колода из списка поддерживаемых, пустая строка - колода по умолчанию
+	PreferredDeck *string `json:"preferred_deck,omitempty" validate:"omitempty,deck"`
+}
+
+// NotificationsRequest - частичное обновление настроек уведомлений
+type NotificationsRequest struct {
+	Daily     *bool   `json:"daily,omitempty"`
+	DailyTime *string `json:"daily_time,omitempty" validate:"omitempty,datetime=15:04"`
+	News      *bool   `json:"news,omitempty"`
+}
@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"taro-api/internal/storage/db"
+	"time"
+)
+
+// User - пользователь в ответах API (не зависит от полей db.User)
+type User struct {
+	ID                   string     `json:"id"`
+	CreatedAt            time.Time  `json:"created_at"`
+	TelegramID           int64      `json:"telegram_id"`
+	Balance              int64      `json:"balance"`
+	Role                 string     `json:"role"`
+	PhotoURL             string     `json:"photo_url"`
+	ReferrerID           int64      `json:"referrer,omitempty"`
+	Referrals            []Referral `json:"referrals"`
+	ReferralBonusApplied bool       `json:"referral_bonus_applied"`
+	Bot                  string     `json:"bot,omitempty"`
+	// Профиль Telegram на момент последней синхронизации
+	FirstName        string `json:"first_name"`
+	LastName         string `json:"last_name"`
+	Username         string `json:"username,omitempty"`
+	LanguageCode     string `json:"language_code,omitempty"`
+	IsPremium        bool   `json:"is_premium"`
+	TelegramPhotoURL string `json:"telegram_photo_url,omitempty"`
+	// Entitlements - действующие платные доступы (premium и т.п.)
+	Entitlements []Entitlement `json:"entitlements"`
+}
+
+// Entitlement - доступ к платной возможности, ExpiresAt пусто - бессрочно
+type Entitlement struct {
+	Name      string     `json:"name"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Referral - приглашенный пользователь
+type Referral struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	TelegramID int64     `json:"telegram_id"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	Username   string    `json:"username,omitempty"`
+}
+
+// NewUser - собирает DTO из модели хранилища
+func NewUser(u *db.User) User {
+	user := User{
+		ID:                   u.ID.String(),
+		CreatedAt:            u.CreatedAt,
+		TelegramID:           u.TelegramID,
+		Balance:              u.Balance,
+		Role:                 u.Role,
+		PhotoURL:             u.PhotoURL,
+		ReferrerID:           u.ReferrerID,
+		Referrals:            []Referral{},
+		Entitlements:         []Entitlement{},
+		ReferralBonusApplied: u.ReferralBonusApplied,
+		Bot:                  u.Bot,
+		FirstName:            u.FirstName,
+		LastName:             u.LastName,
+		Username:             u.Username,
+		LanguageCode:         u.LanguageCode,
+		IsPremium:            u.IsPremium,
+		TelegramPhotoURL:     u.TelegramPhotoURL,
+	}
+
+	for _, e := range u.Entitlements {
+		user.Entitlements = append(user.Entitlements, Entitlement{Name: e.Name, ExpiresAt: e.ExpiresAt})
+	}
+
+	if u.Referrals != nil {
+		for _, ref := range *u.Referrals {
+			user.Referrals = append(user.Referrals, Referral{
+				ID:         ref.ID.String(),
+				CreatedAt:  ref.CreatedAt,
+				TelegramID: ref.TelegramID,
+				FirstName:  ref.FirstName,
+				LastName:   ref.LastName,
+				Username:   ref.Username,
+			})
+		}
+	}
+
+	return user
+}
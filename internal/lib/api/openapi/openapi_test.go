@@ -0,0 +1,315 @@
+package openapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"taro-api/internal/config"
+	"taro-api/internal/helpers/telegram"
+	"taro-api/internal/helpers/telegram/telegramtest"
+	"taro-api/internal/lib/api/openapi"
+	"taro-api/internal/lib/bots"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/lifecycle"
+	"taro-api/internal/lib/secret"
+	tokens "taro-api/internal/lib/session"
+	"taro-api/internal/lib/sharecard"
+	"taro-api/internal/lib/sharetoken"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/router"
+	"taro-api/internal/storage/db"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+const (
+	testToken  = "123456:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	testUserID = 42
+)
+
+// TestRoutesMatchSpec - каждый маршрут роутера описан в спецификации и наоборот
+func TestRoutesMatchSpec(t *testing.T) {
+	api, _ := newAPI(t)
+
+	if err := openapi.CheckRoutes(api, openapi.Spec(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestResponsesMatchSpec - ответы реального роутера на примеры запросов соответствуют схемам.
+// Для каждой операции спецификации нужен хотя бы один пример
+func TestResponsesMatchSpec(t *testing.T) {
+	api, storage := newAPI(t)
+	c := newClient(t, api)
+
+	c.do(http.MethodGet, "/openapi.json", nil, http.StatusOK)
+	c.do(http.MethodGet, "/healthz", nil, http.StatusOK)
+	c.do(http.MethodGet, "/readyz", nil, http.StatusOK)
+	c.do(http.MethodGet, "/version", nil, http.StatusOK)
+
+	c.auth = ""
+	c.do(http.MethodGet, "/me", nil, http.StatusUnauthorized)
+	c.auth = "tma " + signInitData(t, testUserID)
+
+	c.do(http.MethodGet, "/me", nil, http.StatusNotFound)
+	c.header = http.Header{middlewares.IdempotencyKeyHeader: {"register-1"}}
+	c.do(http.MethodPost, "/users/register", map[string]any{}, http.StatusCreated)
+	if w := c.do(http.MethodPost, "/users/register", map[string]any{}, http.StatusCreated); w.Header().Get(middlewares.IdempotentReplayedHeader) != "true" {
+		t.Error("repeated registration with the same Idempotency-Key was not replayed")
+	}
+	c.do(http.MethodPost, "/users/register", map[string]any{"referral_code": "x"}, http.StatusUnprocessableEntity)
+	c.header = nil
+	c.do(http.MethodGet, "/me", nil, http.StatusOK)
+	c.do(http.MethodPost, "/me/referral/claim", map[string]any{"referral_code": "nope"}, http.StatusBadRequest)
+	c.do(http.MethodPost, "/me/promo/redeem", map[string]any{"code": "NOPE"}, http.StatusNotFound)
+
+	c.do(http.MethodGet, "/me/settings", nil, http.StatusOK)
+	c.do(http.MethodPatch, "/me/settings", map[string]any{"language": "en", "timezone": "Europe/Moscow"}, http.StatusOK)
+	c.do(http.MethodPatch, "/me/settings", map[string]any{"timezone": "Mars/Olympus"}, http.StatusBadRequest)
+
+	c.do(http.MethodGet, "/me/photo", nil, http.StatusOK)
+
+	reading := &db.Reading{
+		TelegramID: testUserID,
+		Spread:     "three",
+		Question:   "What should I focus on?",
+		Cards:      []db.ReadingCard{{Card: "fool"}, {Card: "magician", Reversed: true}, {Card: "star"}},
+		Source:     db.ReadingSourceApp,
+	}
+	if err := storage.CreateReading(reading); err != nil {
+		t.Fatalf("create reading: %v", err)
+	}
+	c.do(http.MethodGet, "/readings/"+reading.ID.String(), nil, http.StatusOK)
+	c.do(http.MethodGet, "/readings/00000000-0000-0000-0000-000000000000", nil, http.StatusNotFound)
+	c.do(http.MethodGet, "/readings/"+reading.ID.String()+"/share.png", nil, http.StatusNotFound)
+	var share struct {
+		URL string `json:"url"`
+	}
+	c.decode(c.do(http.MethodPost, "/readings/"+reading.ID.String()+"/share", map[string]any{"include_question": true}, http.StatusOK), &share)
+	c.auth = ""
+	if w := c.do(http.MethodGet, share.URL, nil, http.StatusOK); strings.Contains(w.Header().Get("Cache-Control"), "public") {
+		t.Errorf("share image Cache-Control = %q", w.Header().Get("Cache-Control"))
+	}
+	c.auth = "tma " + signInitData(t, testUserID+1)
+	c.do(http.MethodGet, "/readings/"+reading.ID.String(), nil, http.StatusOK)
+	c.do(http.MethodPost, "/readings/"+reading.ID.String()+"/share", nil, http.StatusNotFound)
+	c.auth = "tma " + signInitData(t, testUserID)
+
+	var p struct {
+		ID        string `json:"id"`
+		Positions []any  `json:"positions"`
+	}
+	c.decode(c.do(http.MethodGet, "/me/practice/next", nil, http.StatusOK), &p)
+	answers := make([]string, len(p.Positions))
+	for i := range answers {
+		answers[i] = "new beginnings, freedom"
+	}
+	c.do(http.MethodPost, "/me/practice/"+p.ID+"/answer", map[string]any{"interpretations": answers}, http.StatusOK)
+	c.do(http.MethodPost, "/me/practice/"+p.ID+"/answer", map[string]any{"interpretations": answers}, http.StatusConflict)
+	c.do(http.MethodGet, "/me/practice/stats", nil, http.StatusOK)
+
+	var q struct {
+		ID string `json:"id"`
+	}
+	c.decode(c.do(http.MethodGet, "/me/quiz/next", nil, http.StatusOK), &q)
+	c.do(http.MethodGet, "/me/quiz/next?format=text", nil, http.StatusOK)
+	c.do(http.MethodPost, "/me/quiz/answer", map[string]any{"question_id": q.ID, "option": 0}, http.StatusOK)
+	c.do(http.MethodPost, "/me/quiz/answer", map[string]any{"question_id": "not-a-uuid"}, http.StatusBadRequest)
+
+	var s struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	c.decode(c.do(http.MethodPost, "/auth/session", nil, http.StatusOK), &s)
+	c.auth = ""
+	c.decode(c.do(http.MethodPost, "/auth/refresh", map[string]any{"refresh_token": s.RefreshToken}, http.StatusOK), &s)
+	c.do(http.MethodPost, "/auth/telegram", map[string]any{
+		"id":         testUserID,
+		"first_name": "Test",
+		"auth_date":  time.Now().Unix(),
+		"hash":       strings.Repeat("0", 64),
+	}, http.StatusUnauthorized)
+	c.auth = "Bearer " + s.AccessToken
+	c.do(http.MethodGet, "/me", nil, http.StatusOK)
+	c.do(http.MethodPost, "/auth/logout", map[string]any{"all": true}, http.StatusOK)
+	c.do(http.MethodGet, "/me", nil, http.StatusUnauthorized)
+
+	c.checkCoverage()
+}
+
+func newAPI(t *testing.T) (*chi.Mux, *db.Storage) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	storage, err := db.New(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	t.Cleanup(func() { storage.CloseDatabaseConnection() })
+
+	tg := telegramtest.NewServer(testToken)
+	t.Cleanup(tg.Close)
+	tg.SetUserAvatar(testUserID, []byte("\x89PNG\r\n\x1a\nfake png"))
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{
+			CORSOrigins:     []string{"https://*"},
+			RateLimit:       1000,
+			RateWindow:      time.Second,
+			ThrottleLimit:   100,
+			ThrottleBacklog: 100,
+			ThrottleTimeout: time.Second,
+			IdempotencyTTL:  time.Hour,
+		},
+		InitData: config.InitDataConfig{
+			MaxAge:              time.Hour,
+			ProfileSyncInterval: time.Minute,
+		},
+		Session: config.SessionConfig{
+			LoginWidgetMaxAge: time.Hour,
+		},
+	}
+
+	registry := bots.NewRegistry(&bots.Bot{Name: "main", Token: secret.NewToken(testToken)})
+	manager := tokens.NewManager([]byte(strings.Repeat("s", 32)), 15*time.Minute, 24*time.Hour)
+
+	api := router.New(router.Deps{
+		Log:      log,
+		Config:   cfg,
+		Storage:  storage,
+		Bots:     registry,
+		Telegram: func(string) *telegram.Client { return tg.Client() },
+		Auth: middlewares.AuthOptions{
+			MaxAge: cfg.InitData.MaxAge,
+			Tokens: manager,
+			Store:  storage,
+		},
+		Tokens:            manager,
+		ShareCards:        sharecard.New(nil),
+		ShareTokens:       sharetoken.NewSigner([]byte(strings.Repeat("s", 32)), time.Hour),
+		ReferralLink:      func(int64) string { return "https://t.me/taroki_test_bot/app?startapp=ref" },
+		NotifyAchievement: func(string, int64, i18n.Lang, db.Achievement) error { return nil },
+		Jobs:              &lifecycle.InFlight{},
+	})
+
+	return api, storage
+}
+
+// signInitData - init data пользователя, подписанные тестовым ботом
+func signInitData(t *testing.T, telegramID int64) string {
+	t.Helper()
+
+	user, err := json.Marshal(map[string]any{"id": telegramID, "first_name": "Test", "language_code": "ru"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authDate := time.Now()
+	payload := map[string]string{"user": string(user), "query_id": "AAH-test"}
+
+	values := url.Values{}
+	for k, v := range payload {
+		values.Set(k, v)
+	}
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+	values.Set("hash", initdata.Sign(payload, testToken, authDate))
+
+	return values.Encode()
+}
+
+// client - выполняет запросы к роутеру и проверяет ответы по спецификации
+type client struct {
+	t       *testing.T
+	api     *chi.Mux
+	spec    *openapi.Document
+	auth    string
+	header  http.Header
+	covered map[string]bool
+}
+
+func newClient(t *testing.T, api *chi.Mux) *client {
+	return &client{t: t, api: api, spec: openapi.Spec(time.Hour), covered: make(map[string]bool)}
+}
+
+func (c *client) do(method, target string, body any, want int) *httptest.ResponseRecorder {
+	c.t.Helper()
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	r := httptest.NewRequest(method, target, reqBody)
+	r.RemoteAddr = "192.0.2.1:1234"
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != "" {
+		r.Header.Set("Authorization", c.auth)
+	}
+	for k, v := range c.header {
+		r.Header[k] = v
+	}
+
+	w := httptest.NewRecorder()
+	c.api.ServeHTTP(w, r)
+
+	rctx := chi.NewRouteContext()
+	if !c.api.Match(rctx, method, r.URL.Path) {
+		c.t.Fatalf("%s %s: no route", method, target)
+	}
+	pattern := rctx.RoutePattern()
+	c.covered[method+" "+pattern] = true
+
+	if w.Code != want {
+		c.t.Fatalf("%s %s: status %d, want %d: %s", method, target, w.Code, want, w.Body.String())
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if mediaType != openapi.JSON {
+		return w
+	}
+	if err := c.spec.ValidateResponse(method, pattern, w.Code, w.Body.Bytes()); err != nil {
+		c.t.Errorf("%s %s: %v\n%s", method, target, err, w.Body.String())
+	}
+
+	return w
+}
+
+func (c *client) decode(w *httptest.ResponseRecorder, v any) {
+	c.t.Helper()
+
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		c.t.Fatalf("decode response: %v", err)
+	}
+}
+
+// checkCoverage - для каждой операции спецификации был хотя бы один запрос
+func (c *client) checkCoverage() {
+	c.t.Helper()
+
+	for path, item := range c.spec.Paths {
+		for method := range item {
+			if key := strings.ToUpper(method) + " " + path; !c.covered[key] {
+				c.t.Errorf("no example request for %s", key)
+			}
+		}
+	}
+}
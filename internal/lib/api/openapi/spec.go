@@ -0,0 +1,422 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"taro-api/internal/lib/api/dto"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/buildinfo"
+	"taro-api/internal/lib/loginwidget"
+	"time"
+)
+
+const (
+	securityTMA    = "tma"
+	securityBearer = "bearer"
+)
+
+// Spec - спецификация всех маршрутов API; idempotencyTTL - сколько хранятся ответы на запросы
+// с Idempotency-Key (HTTP_IDEMPOTENCY_TTL)
+//
+// При добавлении маршрута в роутер его нужно описать здесь и добавить пример запроса
+// в тест спецификации, иначе он не пройдет.
+func Spec(idempotencyTTL time.Duration) *Document {
+	d := New("Taroki API", "1.0.0")
+
+	d.Components.SecuritySchemes[securityTMA] = SecurityScheme{
+		Type:        "apiKey",
+		In:          "header",
+		Name:        "Authorization",
+		Description: "Init data Telegram Mini App: `tma <initData>`",
+	}
+	d.Components.SecuritySchemes[securityBearer] = SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "Токен доступа, выданный POST /auth/session",
+	}
+	secured := []SecurityRequirement{{securityTMA: {}}, {securityBearer: {}}}
+	tmaOnly := []SecurityRequirement{{securityTMA: {}}}
+
+	statusSchema := d.RefAs("StatusResponse", resp.Response{})
+	errorResponse := JSONResponse("Ошибка", statusSchema)
+	authErrors := func(responses map[string]*Response) map[string]*Response {
+		responses["401"] = errorResponse
+		responses["default"] = errorResponse
+		return responses
+	}
+
+	// idempotent - запрос, меняющий баланс: принимает Idempotency-Key и может вернуть
+	// 409 (запрос с ключом еще выполняется) и 422 (ключ использован с другим телом)
+	keyDescription := "Уникальный ключ запроса, одинаковый для всех его повторов; ответ хранится " +
+		formatDuration(idempotencyTTL)
+	idempotent := func(op *Operation) *Operation {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        "Idempotency-Key",
+			In:          "header",
+			Description: keyDescription,
+			Schema:      &Schema{Type: "string"},
+		})
+		op.Responses["409"] = errorResponse
+		op.Responses["422"] = errorResponse
+		return op
+	}
+
+	d.Add(http.MethodGet, "/openapi.json", &Operation{
+		OperationID: "getOpenAPI",
+		Summary:     "Спецификация API",
+		Tags:        []string{"meta"},
+		Responses: map[string]*Response{
+			"200": JSONResponse("Спецификация OpenAPI", &Schema{Type: "object"}),
+		},
+	})
+
+	d.Add(http.MethodGet, "/healthz", &Operation{
+		OperationID: "getHealth",
+		Summary:     "Проверка, что процесс жив",
+		Tags:        []string{"meta"},
+		Responses: map[string]*Response{
+			"200": JSONResponse("Процесс жив", d.Ref(dto.Health{})),
+		},
+	})
+
+	d.Add(http.MethodGet, "/readyz", &Operation{
+		OperationID: "getReadiness",
+		Summary:     "Готовность: база, миграции, доступность Bot API",
+		Tags:        []string{"meta"},
+		Responses: map[string]*Response{
+			"200": JSONResponse("Все проверки прошли", d.Ref(dto.Health{})),
+			"503": JSONResponse("Есть непройденные проверки", d.Ref(dto.Health{})),
+		},
+	})
+
+	d.Add(http.MethodGet, "/version", &Operation{
+		OperationID: "getVersion",
+		Summary:     "Сведения о сборке",
+		Tags:        []string{"meta"},
+		Responses: map[string]*Response{
+			"200": JSONResponse("Сборка", d.RefAs("BuildInfo", buildinfo.Info{})),
+		},
+	})
+
+	d.Add(http.MethodGet, "/me", &Operation{
+		OperationID: "getMe",
+		Summary:     "Текущий пользователь (в режиме совместимости HTTP_LEGACY_GET_ME создается при первом запросе)",
+		Tags:        []string{"users"},
+		Security:    secured,
+		Parameters: []Parameter{{
+			Name:        "referralID",
+			In:          "query",
+			Description: "Telegram ID пригласившего пользователя, только в режиме совместимости",
+			Deprecated:  true,
+			Schema:      &Schema{Type: "integer", Format: "int64"},
+		}},
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Пользователь", d.Ref(dto.User{})),
+			"400": errorResponse,
+			"404": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodPost, "/users/register", idempotent(&Operation{
+		OperationID: "registerUser",
+		Summary:     "Регистрация текущего пользователя, повторный вызов возвращает существующего",
+		Tags:        []string{"users"},
+		Security:    secured,
+		RequestBody: &RequestBody{Content: map[string]MediaType{JSON: {Schema: d.Ref(dto.RegisterRequest{})}}},
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Пользователь уже зарегистрирован", d.Ref(dto.User{})),
+			"201": JSONResponse("Пользователь создан", d.Ref(dto.User{})),
+			"400": errorResponse,
+		}),
+	}))
+
+	d.Add(http.MethodPost, "/me/referral/claim", idempotent(&Operation{
+		OperationID: "claimReferral",
+		Summary:     "Указание пригласившего после регистрации с начислением бонусов",
+		Tags:        []string{"users"},
+		Security:    secured,
+		RequestBody: jsonBody(d.Ref(dto.ReferralClaimRequest{})),
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Пользователь", d.Ref(dto.User{})),
+			"400": errorResponse,
+			"404": errorResponse,
+			"409": errorResponse,
+		}),
+	}))
+
+	d.Add(http.MethodPost, "/me/promo/redeem", idempotent(&Operation{
+		OperationID: "redeemPromoCode",
+		Summary:     "Активация промокода или подарочного кода",
+		Tags:        []string{"users"},
+		Security:    secured,
+		RequestBody: jsonBody(d.Ref(dto.PromoRedeemRequest{})),
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Награда и баланс после активации", d.Ref(dto.PromoRedemption{})),
+			"400": errorResponse,
+			"403": errorResponse,
+			"404": errorResponse,
+		}),
+	}))
+
+	d.Add(http.MethodGet, "/me/settings", &Operation{
+		OperationID: "getMySettings",
+		Summary:     "Профиль и настройки текущего пользователя",
+		Tags:        []string{"users"},
+		Security:    secured,
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Настройки", d.Ref(dto.Settings{})),
+			"404": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodPatch, "/me/settings", &Operation{
+		OperationID: "updateMySettings",
+		Summary:     "Частичное изменение профиля и настроек текущего пользователя",
+		Tags:        []string{"users"},
+		Security:    secured,
+		RequestBody: jsonBody(d.Ref(dto.SettingsRequest{})),
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Настройки", d.Ref(dto.Settings{})),
+			"400": errorResponse,
+			"404": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodGet, "/readings/{id}", &Operation{
+		OperationID: "getReading",
+		Summary:     "Расклад по ID, в том числе чужой, которым поделились: без автора и вопроса",
+		Tags:        []string{"readings"},
+		Security:    secured,
+		Parameters: []Parameter{{
+			Name:     "id",
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string", Format: "uuid"},
+		}},
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Расклад", d.Ref(dto.Reading{})),
+			"404": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodPost, "/readings/{id}/share", &Operation{
+		OperationID: "createReadingShareImage",
+		Summary:     "Ссылка на картинку своего расклада; вопрос на картинке только с include_question",
+		Tags:        []string{"readings"},
+		Security:    secured,
+		Parameters: []Parameter{{
+			Name:     "id",
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string", Format: "uuid"},
+		}},
+		RequestBody: &RequestBody{
+			Content: map[string]MediaType{JSON: {Schema: d.Ref(dto.ShareImageRequest{})}},
+		},
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Ссылка на картинку и срок ее действия", d.Ref(dto.ShareImage{})),
+			"400": errorResponse,
+			"404": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodGet, "/readings/{id}/share.png", &Operation{
+		OperationID: "getReadingShareImage",
+		Summary:     "Картинка расклада для сторис и чатов, без авторизации, по токену из ссылки",
+		Tags:        []string{"readings"},
+		Parameters: []Parameter{
+			{
+				Name:     "id",
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string", Format: "uuid"},
+			},
+			{
+				Name:        "token",
+				In:          "query",
+				Required:    true,
+				Description: "Токен из POST /readings/{id}/share; неверный или истекший - 404",
+				Schema:      &Schema{Type: "string"},
+			},
+			{
+				Name:        "lang",
+				In:          "query",
+				Description: "Язык подписей, по умолчанию из Accept-Language",
+				Schema:      &Schema{Type: "string"},
+			},
+		},
+		Responses: map[string]*Response{
+			"200": {
+				Description: "PNG 1080x1920",
+				Content:     map[string]MediaType{"image/png": {Schema: &Schema{Type: "string", Format: "binary"}}},
+			},
+			"404": errorResponse,
+			"500": errorResponse,
+		},
+	})
+
+	d.Add(http.MethodGet, "/me/practice/next", &Operation{
+		OperationID: "getNextPractice",
+		Summary:     "Тренировочный расклад: неотвеченный или новый с картами, которые пора повторить",
+		Tags:        []string{"practice"},
+		Security:    secured,
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Тренировочный расклад", d.Ref(dto.Practice{})),
+			"404": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodPost, "/me/practice/{id}/answer", &Operation{
+		OperationID: "answerPractice",
+		Summary:     "Толкования позиций тренировочного расклада с оценкой и отзывом",
+		Tags:        []string{"practice"},
+		Security:    secured,
+		Parameters: []Parameter{{
+			Name:     "id",
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string", Format: "uuid"},
+		}},
+		RequestBody: jsonBody(d.Ref(dto.PracticeAnswerRequest{})),
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Оценка толкований", d.Ref(dto.PracticeResult{})),
+			"400": errorResponse,
+			"404": errorResponse,
+			"409": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodGet, "/me/practice/stats", &Operation{
+		OperationID: "getPracticeStats",
+		Summary:     "Серия дней с тренировкой, средняя оценка и успехи по картам",
+		Tags:        []string{"practice"},
+		Security:    secured,
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Успехи в тренировках", d.Ref(dto.PracticeStats{})),
+			"404": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodGet, "/me/quiz/next", &Operation{
+		OperationID: "getNextQuizQuestion",
+		Summary:     "Вопрос карточки: неотвеченный или по карточке, которую пора повторить",
+		Tags:        []string{"quiz"},
+		Security:    secured,
+		Parameters: []Parameter{
+			{
+				Name:        "kind",
+				In:          "query",
+				Description: "Вид вопроса: card_meaning, meaning_card или orientation, по умолчанию любой",
+				Schema:      &Schema{Type: "string"},
+			},
+			{
+				Name:        "format",
+				In:          "query",
+				Description: "choice (по умолчанию) или text; вопрос о положении всегда с выбором",
+				Schema:      &Schema{Type: "string"},
+			},
+		},
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Вопрос", d.Ref(dto.QuizQuestion{})),
+			"400": errorResponse,
+			"404": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodPost, "/me/quiz/answer", &Operation{
+		OperationID: "answerQuizQuestion",
+		Summary:     "Ответ на вопрос карточки с проверкой и следующим повторением",
+		Tags:        []string{"quiz"},
+		Security:    secured,
+		RequestBody: jsonBody(d.Ref(dto.QuizAnswerRequest{})),
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Проверка ответа и полученные достижения", d.Ref(dto.QuizResult{})),
+			"400": errorResponse,
+			"404": errorResponse,
+			"409": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodGet, "/me/photo", &Operation{
+		OperationID: "getMyPhoto",
+		Summary:     "Аватар текущего пользователя",
+		Tags:        []string{"users"},
+		Security:    secured,
+		Responses: authErrors(map[string]*Response{
+			"200": {
+				Description: "Изображение",
+				Content:     map[string]MediaType{"image/*": {Schema: &Schema{Type: "string", Format: "binary"}}},
+			},
+			"404": errorResponse,
+			"502": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodPost, "/auth/session", &Operation{
+		OperationID: "createSession",
+		Summary:     "Обмен init data на токены сессии",
+		Tags:        []string{"auth"},
+		Security:    tmaOnly,
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Токены сессии", d.Ref(dto.Session{})),
+			"403": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodPost, "/auth/refresh", &Operation{
+		OperationID: "refreshSession",
+		Summary:     "Обновление токенов по refresh токену",
+		Tags:        []string{"auth"},
+		RequestBody: jsonBody(d.Ref(dto.RefreshRequest{})),
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Токены сессии", d.Ref(dto.Session{})),
+			"400": errorResponse,
+			"403": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodPost, "/auth/telegram", &Operation{
+		OperationID: "loginWithTelegram",
+		Summary:     "Вход на сайте через Telegram Login Widget",
+		Tags:        []string{"auth"},
+		RequestBody: jsonBody(d.RefAs("TelegramLoginRequest", loginwidget.Data{})),
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Токены сессии", d.Ref(dto.Session{})),
+			"400": errorResponse,
+			"403": errorResponse,
+		}),
+	})
+
+	d.Add(http.MethodPost, "/auth/logout", &Operation{
+		OperationID: "logout",
+		Summary:     "Отзыв текущей или всех сессий",
+		Tags:        []string{"auth"},
+		Security:    secured,
+		RequestBody: &RequestBody{Content: map[string]MediaType{JSON: {Schema: d.Ref(dto.LogoutRequest{})}}},
+		Responses: authErrors(map[string]*Response{
+			"200": JSONResponse("Сессии отозваны", statusSchema),
+		}),
+	})
+
+	return d
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{JSON: {Schema: schema}},
+	}
+}
+
+// formatDuration - срок для описаний: целые часы и минуты без лишних нулей
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + " ч"
+	case d >= time.Minute && d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + " мин"
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,181 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+// CheckRoutes - проверяет, что каждый маршрут chi описан в спецификации и наоборот
+func CheckRoutes(routes chi.Routes, d *Document) error {
+	registered := make(map[string]bool)
+	var missing []string
+
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		key := method + " " + route
+		registered[key] = true
+		if _, ok := d.Operation(method, route); !ok {
+			missing = append(missing, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walk routes: %w", err)
+	}
+
+	var stale []string
+	for path, item := range d.Paths {
+		for method := range item {
+			key := strings.ToUpper(method) + " " + path
+			if !registered[key] {
+				stale = append(stale, key)
+			}
+		}
+	}
+
+	if len(missing) == 0 && len(stale) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	sort.Strings(stale)
+
+	var msgs []string
+	if len(missing) > 0 {
+		msgs = append(msgs, "routes missing from spec: "+strings.Join(missing, ", "))
+	}
+	if len(stale) > 0 {
+		msgs = append(msgs, "spec operations without route: "+strings.Join(stale, ", "))
+	}
+	return fmt.Errorf("openapi: %s", strings.Join(msgs, "; "))
+}
+
+// ValidateResponse - проверяет JSON ответ операции на соответствие схеме
+func (d *Document) ValidateResponse(method, path string, status int, body []byte) error {
+	op, ok := d.Operation(method, path)
+	if !ok {
+		return fmt.Errorf("openapi: operation %s %s is not described", method, path)
+	}
+
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		response, ok = op.Responses["default"]
+	}
+	if !ok {
+		return fmt.Errorf("openapi: %s %s: status %d is not described", method, path, status)
+	}
+
+	media, ok := response.Content[JSON]
+	if !ok {
+		return nil
+	}
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("openapi: %s %s: invalid JSON: %w", method, path, err)
+	}
+
+	return d.validate(media.Schema, value, "$")
+}
+
+func (d *Document) validate(s *Schema, value any, at string) error {
+	if s == nil {
+		return nil
+	}
+
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, refPrefix)
+		resolved, ok := d.Components.Schemas[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown schema %s", at, s.Ref)
+		}
+		return d.validate(resolved, value, at)
+	}
+
+	if value == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not allowed", at)
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		return fmt.Errorf("%s: value %v is not one of %v", at, value, s.Enum)
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected string, got %T", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", at, value)
+		}
+	case "number", "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: expected %s, got %T", at, s.Type, value)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: %w", at, err)
+		}
+		if s.Type == "integer" && f != math.Trunc(f) {
+			return fmt.Errorf("%s: expected integer, got %s", at, n)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", at, value)
+		}
+		for i, item := range items {
+			if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", at, value)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", at, name)
+			}
+		}
+		for name, v := range obj {
+			prop, ok := s.Properties[name]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			if err := d.validate(prop, v, at+"."+name); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%s: unsupported schema type %q", at, s.Type)
+	}
+
+	return nil
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,15 +2,30 @@ package response
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
+	"taro-api/internal/lib/i18n"
 
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 )
 
-// Response - структура ответа
+// Response - структура ответа, Message - текст ошибки для показа пользователю на его языке
 type Response struct {
-	Status string `json:"status"`
-	Error  string `json:"error,omitempty"`
+	Status    string       `json:"status"`
+	Code      string       `json:"code,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Message   string       `json:"message,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError - ошибка валидации конкретного поля
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
 }
 
 // Возможные статусы ответов
@@ -19,6 +34,41 @@ const (
 	StatusError = "Error"
 )
 
+// Машиночитаемые коды ошибок
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidation       = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+	CodeUpstream         = "upstream_error"
+
+	CodeInitDataExpired = "init_data_expired"
+	CodeInitDataInvalid = "init_data_invalid"
+	CodeNonceRequired   = "nonce_required"
+	CodeReplayDetected  = "replay_detected"
+	CodeTokenExpired    = "token_expired"
+	CodeTokenInvalid    = "token_invalid"
+	CodeSessionRevoked  = "session_revoked"
+
+	CodeReferralInvalid     = "referral_invalid"
+	CodeReferralUnavailable = "referral_unavailable"
+
+	CodePromoNotFound  = "promo_not_found"
+	CodePromoInactive  = "promo_inactive"
+	CodePromoExhausted = "promo_exhausted"
+	CodePromoRedeemed  = "promo_already_redeemed"
+
+	CodePracticeAnswered = "practice_answered"
+	CodeQuizAnswered     = "quiz_answered"
+
+	CodeIdempotencyMismatch   = "idempotency_key_mismatch"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+)
+
 // OK - Возвращает успешный ответ
 func OK() Response {
 	return Response{
@@ -26,10 +76,11 @@ func OK() Response {
 	}
 }
 
-// Error - Возвращает статус и текст ошибки
-func Error(msg string) Response {
+// Error - Возвращает код и текст ошибки
+func Error(code, msg string) Response {
 	return Response{
 		Status: StatusError,
+		Code:   code,
 		Error:  msg,
 	}
 }
@@ -37,20 +88,60 @@ func Error(msg string) Response {
 // ValidationError - проверяет на ошибки
 func ValidationError(errs validator.ValidationErrors) Response {
 	var errMsgs []string
+	fields := make([]FieldError, 0, len(errs))
 
 	for _, err := range errs {
+		var msg string
 		switch err.ActualTag() {
 		case "required":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is a required field", err.Field()))
+			msg = fmt.Sprintf("field %s is a required field", err.Field())
 		case "url":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid URL", err.Field()))
+			msg = fmt.Sprintf("field %s is not a valid URL", err.Field())
 		default:
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", err.Field()))
+			msg = fmt.Sprintf("field %s is not valid", err.Field())
 		}
+		errMsgs = append(errMsgs, msg)
+		fields = append(fields, FieldError{
+			Field:   err.Field(),
+			Tag:     err.ActualTag(),
+			Message: msg,
+		})
 	}
 
 	return Response{
 		Status: StatusError,
+		Code:   CodeValidation,
 		Error:  strings.Join(errMsgs, ", "),
+		Fields: fields,
 	}
 }
+
+// Render - отправляет ошибку с HTTP статусом, ID запроса и текстом на языке пользователя
+func Render(w http.ResponseWriter, r *http.Request, status int, resp Response) {
+	resp.RequestID = middleware.GetReqID(r.Context())
+	if resp.Code != "" && resp.Message == "" {
+		resp.Message, _ = i18n.Lookup(i18n.FromContext(r.Context()), i18n.ErrorKey(resp.Code))
+	}
+	render.Status(r, status)
+	render.JSON(w, r, resp)
+}
+
+// RenderError - отправляет ошибку с кодом и текстом
+func RenderError(w http.ResponseWriter, r *http.Request, status int, code, msg string) {
+	Render(w, r, status, Error(code, msg))
+}
+
+// NotFound - обработчик для несуществующих маршрутов
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	RenderError(w, r, http.StatusNotFound, CodeNotFound, "route not found")
+}
+
+// MethodNotAllowed - обработчик для неподдерживаемых методов
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	RenderError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method not allowed")
+}
+
+// RateLimited - обработчик превышения лимита запросов
+func RateLimited(w http.ResponseWriter, r *http.Request) {
+	RenderError(w, r, http.StatusTooManyRequests, CodeRateLimited, "too many requests")
+}
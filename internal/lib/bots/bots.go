@@ -0,0 +1,40 @@
+// Package bots - боты Telegram, которые обслуживает один экземпляр API
+package bots
+
+import "taro-api/internal/lib/secret"
+
+// Bot - бот и его текущий токен
+type Bot struct {
+	Name  string
+	Token *secret.Token
+}
+
+// Registry - список ботов, первый из них основной
+type Registry struct {
+	bots []*Bot
+}
+
+// NewRegistry - создает реестр; порядок задает порядок проверки подписи init data
+func NewRegistry(bots ...*Bot) *Registry {
+	return &Registry{bots: bots}
+}
+
+// Primary - основной бот (им подписываются токены по умолчанию и запросы к Bot API)
+func (r *Registry) Primary() *Bot {
+	return r.bots[0]
+}
+
+// All - все боты
+func (r *Registry) All() []*Bot {
+	return r.bots
+}
+
+// Get - бот по имени
+func (r *Registry) Get(name string) (*Bot, bool) {
+	for _, b := range r.bots {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return nil, false
+}
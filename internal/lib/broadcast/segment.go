@@ -0,0 +1,83 @@
+// Package broadcast - рассылки администратора: сегменты получателей и фоновая отправка
+package broadcast
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"taro-api/internal/storage/db"
+)
+
+var langRe = regexp.MustCompile(`^[a-z]{2}$`)
+
+// ParseSegment - сегмент из аргументов команды вида key=value:
+//
+//	[lang=ru] [balance=yes] [referrals=yes] [active=30]
+func ParseSegment(args []string) (db.BroadcastSegment, error) {
+	var seg db.BroadcastSegment
+
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || value == "" {
+			return seg, fmt.Errorf("argument %q must be key=value", arg)
+		}
+
+		var err error
+		switch strings.ToLower(key) {
+		case "lang":
+			// любой код ISO 639-1, не только языки с переводами: язык Telegram может быть любым
+			seg.Language = strings.ToLower(value)
+			if !langRe.MatchString(seg.Language) {
+				err = fmt.Errorf("must be a language code")
+			}
+		case "balance":
+			seg.HasBalance, err = parseYes(value)
+		case "referrals":
+			seg.HasReferrals, err = parseYes(value)
+		case "active":
+			seg.ActiveDays, err = strconv.Atoi(value)
+			if err == nil && seg.ActiveDays < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		default:
+			return seg, fmt.Errorf("unknown argument %q", key)
+		}
+		if err != nil {
+			return seg, fmt.Errorf("invalid %s: %q", key, value)
+		}
+	}
+
+	return seg, nil
+}
+
+// Describe - сегмент в виде аргументов команды, "all" - все пользователи бота
+func Describe(seg db.BroadcastSegment) string {
+	var parts []string
+	if seg.Language != "" {
+		parts = append(parts, "lang="+seg.Language)
+	}
+	if seg.HasBalance {
+		parts = append(parts, "balance=yes")
+	}
+	if seg.HasReferrals {
+		parts = append(parts, "referrals=yes")
+	}
+	if seg.ActiveDays > 0 {
+		parts = append(parts, "active="+strconv.Itoa(seg.ActiveDays))
+	}
+	if len(parts) == 0 {
+		return "all"
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseYes(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "true", "1":
+		return true, nil
+	case "no", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("must be yes or no")
+}
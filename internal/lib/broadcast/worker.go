@@ -0,0 +1,221 @@
+package broadcast
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/storage/db"
+	"time"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	// получатели берутся порциями; пауза и отмена вступают в силу между порциями
+	batchSize = 50
+	// как часто проверять запущенные рассылки, если Wake не вызывался (например, после рестарта)
+	idlePoll = 30 * time.Second
+	// сколько раз повторять отправку получателю после ответа 429
+	maxFloodRetries = 3
+)
+
+// Store - хранилище рассылок
+type Store interface {
+	RunningBroadcasts() ([]db.Broadcast, error)
+	PendingRecipients(broadcastID uuid.UUID, limit int) ([]db.BroadcastRecipient, error)
+	ClaimDelivery(r *db.BroadcastRecipient) (bool, error)
+	ReleaseDelivery(r *db.BroadcastRecipient) error
+	RecordDelivery(r *db.BroadcastRecipient, status, errText string) error
+	FinishBroadcast(id uuid.UUID) (*db.Broadcast, bool, error)
+	GetUserLanguage(telegramID int64) (string, error)
+}
+
+// Bots - запущенный бот по имени; после ротации токена возвращает новый экземпляр
+type Bots func(name string) (*tele.Bot, bool)
+
+// Worker - отправляет запущенные рассылки с общим для всех рассылок ограничением скорости
+type Worker struct {
+	log      *slog.Logger
+	store    Store
+	bots     Bots
+	interval time.Duration
+	wake     chan struct{}
+}
+
+// NewWorker - создает отправщика; rate - сообщений в секунду на все рассылки сразу
+// (лимит Telegram - около 30 в секунду на бота)
+func NewWorker(log *slog.Logger, store Store, bots Bots, rate int) *Worker {
+	return &Worker{
+		log:      log.With(slog.String("component", "broadcasts")),
+		store:    store,
+		bots:     bots,
+		interval: time.Second / time.Duration(rate),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Wake - сообщает, что рассылка запущена или продолжена
+func (w *Worker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run - отправляет рассылки до отмены ctx; состояние хранится в базе, поэтому после
+// рестарта отправка продолжается с того же места
+func (w *Worker) Run(ctx context.Context) error {
+	limiter := time.NewTicker(w.interval)
+	defer limiter.Stop()
+
+	for {
+		list, err := w.store.RunningBroadcasts()
+		if err != nil {
+			w.log.Error("failed to get running broadcasts", slog.String("error", err.Error()))
+		}
+
+		progressed := false
+		for i := range list {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if w.process(ctx, &list[i], limiter) {
+				progressed = true
+			}
+		}
+
+		if progressed {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.wake:
+		case <-time.After(idlePoll):
+		}
+	}
+}
+
+// process - отправляет очередную порцию рассылки, возвращает false, если отправлять нечего
+func (w *Worker) process(ctx context.Context, b *db.Broadcast, limiter *time.Ticker) bool {
+	log := w.log.With(slog.String("broadcast", b.ID.String()))
+
+	bot, ok := w.bots(b.Bot)
+	if !ok {
+		log.Warn("bot for broadcast is not running", slog.String("bot", b.Bot))
+		return false
+	}
+
+	recipients, err := w.store.PendingRecipients(b.ID, batchSize)
+	if err != nil {
+		log.Error("failed to get recipients", slog.String("error", err.Error()))
+		return false
+	}
+
+	if len(recipients) == 0 {
+		w.finish(bot, b.ID, log)
+		return false
+	}
+
+	msg := tele.StoredMessage{MessageID: strconv.Itoa(b.SourceMessageID), ChatID: b.SourceChatID}
+
+	for i := range recipients {
+		r := &recipients[i]
+
+		// получатель отмечается до отправки: если результат не сохранится, повторно
+		// сообщение ему не уйдет. Ошибка хранилища останавливает порцию до следующей проверки
+		claimed, err := w.store.ClaimDelivery(r)
+		if err != nil {
+			log.Error("failed to claim delivery", slog.String("error", err.Error()))
+			return false
+		}
+		if !claimed {
+			continue
+		}
+
+		status, errText := w.deliver(ctx, bot, r.TelegramID, msg, limiter)
+		if status == "" {
+			// остановка приложения до отправки: получатель возвращается в очередь
+			if err := w.store.ReleaseDelivery(r); err != nil {
+				log.Error("failed to release delivery", slog.String("error", err.Error()))
+			}
+			return true
+		}
+
+		if err := w.store.RecordDelivery(r, status, errText); err != nil {
+			log.Error("failed to record delivery", slog.Int64("telegram_id", r.TelegramID),
+				slog.String("error", err.Error()))
+			return false
+		}
+	}
+
+	return true
+}
+
+// deliver - копирует сообщение получателю, соблюдая ограничение скорости
+func (w *Worker) deliver(ctx context.Context, bot *tele.Bot, to int64, msg tele.StoredMessage,
+	limiter *time.Ticker) (string, string) {
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return "", ""
+		case <-limiter.C:
+		}
+
+		_, err := bot.Copy(tele.ChatID(to), msg)
+
+		var flood tele.FloodError
+		switch {
+		case err == nil:
+			return db.DeliverySent, ""
+		case errors.Is(err, tele.ErrBlockedByUser), errors.Is(err, tele.ErrUserIsDeactivated):
+			return db.DeliveryBlocked, err.Error()
+		case errors.As(err, &flood) && attempt < maxFloodRetries:
+			// Telegram просит подождать: пауза действует на всю отправку, а не только на получателя
+			w.log.Warn("broadcast is throttled by telegram", slog.Int("retry_after", flood.RetryAfter))
+			select {
+			case <-ctx.Done():
+				return "", ""
+			case <-time.After(time.Duration(flood.RetryAfter) * time.Second):
+			}
+		default:
+			return db.DeliveryFailed, err.Error()
+		}
+	}
+}
+
+// finish - завершает рассылку и отправляет отчет ее автору
+func (w *Worker) finish(bot *tele.Bot, id uuid.UUID, log *slog.Logger) {
+	b, finished, err := w.store.FinishBroadcast(id)
+	if err != nil {
+		log.Error("failed to finish broadcast", slog.String("error", err.Error()))
+		return
+	}
+	if !finished {
+		return
+	}
+
+	log.Info("broadcast finished",
+		slog.Int("total", b.Total),
+		slog.Int("sent", b.Sent),
+		slog.Int("failed", b.Failed),
+		slog.Int("blocked", b.Blocked),
+	)
+
+	stored, _ := w.store.GetUserLanguage(b.CreatedBy)
+	l := i18n.Match(stored)
+
+	var took time.Duration
+	if b.StartedAt != nil && b.FinishedAt != nil {
+		took = b.FinishedAt.Sub(*b.StartedAt).Round(time.Second)
+	}
+
+	report := i18n.T(l, i18n.BotBroadcastReport, b.ID.String(), b.Sent, b.Total, b.Failed, b.Blocked, took.String())
+	if _, err := bot.Send(tele.ChatID(b.CreatedBy), report); err != nil {
+		log.Error("failed to send broadcast report", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,189 @@
+package broadcast_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"taro-api/internal/helpers/telegram/telegramtest"
+	"taro-api/internal/lib/broadcast"
+	"taro-api/internal/storage/db"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+const testToken = "123456:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// failingStore - хранилище, у которого не сохраняется результат первой доставки
+type failingStore struct {
+	*db.Storage
+	failed bool
+}
+
+func (s *failingStore) RecordDelivery(r *db.BroadcastRecipient, status, errText string) error {
+	if !s.failed {
+		s.failed = true
+		return errors.New("database is locked")
+	}
+	return s.Storage.RecordDelivery(r, status, errText)
+}
+
+// waitDone - ждет завершения рассылки
+func waitDone(t *testing.T, store *db.Storage, id uuid.UUID) *db.Broadcast {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b, err := store.GetBroadcast(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Status == db.BroadcastDone {
+			return b
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("broadcast is not finished: %+v", b)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newBroadcast - запущенная рассылка бота main пользователям 1..n
+func newBroadcast(t *testing.T, n int64) (*db.Storage, *db.Broadcast) {
+	t.Helper()
+
+	store, err := db.New(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.CloseDatabaseConnection() })
+
+	for id := int64(1); id <= n; id++ {
+		if _, _, err := store.RegisterUser(id, "main", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := &db.Broadcast{Bot: "main", CreatedBy: 100, SourceChatID: 100, SourceMessageID: 7}
+	if err := store.CreateBroadcast(b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.StartBroadcast(b.ID); err != nil {
+		t.Fatal(err)
+	}
+	return store, b
+}
+
+func newBot(t *testing.T, srv *telegramtest.Server) broadcast.Bots {
+	t.Helper()
+
+	bot, err := tele.NewBot(tele.Settings{Token: testToken, URL: srv.URL, Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(name string) (*tele.Bot, bool) { return bot, name == "main" }
+}
+
+// TestWorkerResumes - после рестарта отправляются только оставшиеся получатели,
+// заблокировавший бота учитывается, автору приходит отчет
+func TestWorkerResumes(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	store, b := newBroadcast(t, 5)
+
+	// до рестарта первые два получателя уже получили сообщение
+	recipients, err := store.PendingRecipients(b.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range recipients {
+		if err := store.RecordDelivery(&recipients[i], db.DeliverySent, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv.FailNext("copyMessage", telegramtest.Failure{
+		Code:        http.StatusForbidden,
+		Description: "Forbidden: bot was blocked by the user",
+	})
+
+	w := broadcast.NewWorker(slog.New(slog.NewTextHandler(io.Discard, nil)), store, newBot(t, srv), 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	b = waitDone(t, store, b.ID)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if b.Total != 5 || b.Sent != 4 || b.Blocked != 1 || b.Failed != 0 {
+		t.Errorf("broadcast = %d total, %d sent, %d blocked, %d failed; want 5, 4, 1, 0",
+			b.Total, b.Sent, b.Blocked, b.Failed)
+	}
+
+	// получатель 3 заблокировал бота: попытка была, но сообщение не дошло
+	if got := srv.ChatIDs("copyMessage"); !slices.Equal(got, []int64{4, 5}) {
+		t.Errorf("copied to %v, want [4 5]", got)
+	}
+	if calls := srv.Calls("copyMessage"); calls != 3 {
+		t.Errorf("copyMessage called %d times, want 3", calls)
+	}
+	if got := srv.ChatIDs("sendMessage"); !slices.Equal(got, []int64{100}) {
+		t.Errorf("report sent to %v, want [100]", got)
+	}
+}
+
+// TestWorkerRecordFailure - если результат доставки не сохранился, получатель не получает
+// сообщение повторно
+func TestWorkerRecordFailure(t *testing.T) {
+	srv := telegramtest.NewServer(testToken)
+	defer srv.Close()
+
+	store, b := newBroadcast(t, 3)
+	w := broadcast.NewWorker(slog.New(slog.NewTextHandler(io.Discard, nil)), &failingStore{Storage: store},
+		newBot(t, srv), 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	// после ошибки хранилища порция остановлена до следующей проверки
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.Calls("copyMessage") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("nothing was sent")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if calls := srv.Calls("copyMessage"); calls != 1 {
+		t.Errorf("copyMessage called %d times after a storage error, want 1", calls)
+	}
+
+	w.Wake()
+	b = waitDone(t, store, b.ID)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if got := srv.ChatIDs("copyMessage"); !slices.Equal(got, []int64{1, 2, 3}) {
+		t.Errorf("copied to %v, want each recipient once", got)
+	}
+	// результат первой доставки потерян, но она не повторялась
+	if b.Sent != 2 {
+		t.Errorf("sent = %d, want 2", b.Sent)
+	}
+}
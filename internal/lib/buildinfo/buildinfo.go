@@ -0,0 +1,51 @@
+// Package buildinfo - сведения о сборке, задаются через -ldflags в Makefile
+package buildinfo
+
+import "runtime/debug"
+
+// Значения подставляются при сборке:
+//
+//	-X taro-api/internal/lib/buildinfo.Commit=... -X taro-api/internal/lib/buildinfo.BuildTime=...
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info - сведения о текущей сборке
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get - возвращает сведения о сборке, при отсутствии ldflags берет их из VCS информации Go
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = bi.GoVersion
+
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+
+	return info
+}
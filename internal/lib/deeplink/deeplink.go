@@ -0,0 +1,65 @@
+// Package deeplink - ссылки t.me, открывающие Mini App на нужном экране через параметр startapp.
+// Mini App получает параметр в start_param init data и по префиксу выбирает экран
+package deeplink
+
+import (
+	"net/url"
+	"strings"
+	"taro-api/internal/lib/referral"
+
+	"github.com/google/uuid"
+)
+
+// Параметры startapp для экранов Mini App
+const (
+	// Daily - карта дня
+	Daily = "daily"
+	// Spreads - список раскладов
+	Spreads = "spreads"
+	// Profile - профиль и баланс
+	Profile = "profile"
+
+	cardPrefix    = "card_"
+	readingPrefix = "reading_"
+)
+
+// Card - экран карты колоды
+func Card(id string) string {
+	return cardPrefix + id
+}
+
+// Reading - экран сохраненного расклада
+func Reading(id uuid.UUID) string {
+	return readingPrefix + id.String()
+}
+
+// ParseReading - ID расклада из параметра reading_<id>, ссылки с таким параметром
+// или просто из ID, как его вставляют в inline запрос
+func ParseReading(s string) (uuid.UUID, bool) {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndex(s, readingPrefix); i >= 0 {
+		s = s[i+len(readingPrefix):]
+	}
+
+	id, err := uuid.Parse(s)
+	return id, err == nil
+}
+
+// Referral - главный экран с реферальным кодом пригласившего
+func Referral(telegramID int64) string {
+	return referral.Code(telegramID)
+}
+
+// StartApp - ссылка, открывающая основное Mini App бота с параметром param;
+// пусто, если username бота неизвестен (бот запущен без getMe)
+func StartApp(botUsername, param string) string {
+	if botUsername == "" {
+		return ""
+	}
+
+	link := url.URL{Scheme: "https", Host: "t.me", Path: "/" + botUsername}
+	if param != "" {
+		link.RawQuery = url.Values{"startapp": {param}}.Encode()
+	}
+	return link.String()
+}
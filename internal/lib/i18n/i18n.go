@@ -0,0 +1,138 @@
+// Package i18n - каталог сообщений бота и API на языках пользователей
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Lang - код языка (ISO 639-1, как language_code в Telegram)
+type Lang string
+
+// Языки, для которых есть или планируются переводы
+const (
+	RU Lang = "ru"
+	EN Lang = "en"
+	UK Lang = "uk"
+	KK Lang = "kk"
+)
+
+// Default - язык, если ни один из предпочтительных не поддерживается
+const Default = EN
+
+// Key - ключ сообщения в каталоге
+type Key string
+
+// fallbacks - на какой язык переходить, если перевода нет: украинским и казахским
+// пользователям русский обычно понятнее английского
+var fallbacks = map[Lang]Lang{
+	UK: RU,
+	KK: RU,
+	RU: EN,
+}
+
+type ctxKey struct{}
+
+// Supported - языки, для которых есть каталог сообщений
+func Supported() []Lang {
+	langs := make([]Lang, 0, len(catalog))
+	for _, lang := range []Lang{RU, EN, UK, KK} {
+		if _, ok := catalog[lang]; ok {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// IsSupported - есть ли каталог для языка
+func IsSupported(code string) bool {
+	_, ok := catalog[Lang(code)]
+	return ok
+}
+
+// Match - первый из предпочтительных языков (в порядке приоритета), для которого есть
+// каталог или перевод через fallback; коды вида "ru-RU" приводятся к "ru"
+func Match(codes ...string) Lang {
+	for _, code := range codes {
+		lang := normalize(code)
+		for lang != "" {
+			if _, ok := catalog[lang]; ok {
+				return lang
+			}
+			lang = fallbacks[lang]
+		}
+	}
+	return Default
+}
+
+// FromAcceptLanguage - язык из заголовка Accept-Language (веса q не учитываются,
+// браузеры и так перечисляют языки по убыванию приоритета)
+func FromAcceptLanguage(header string) Lang {
+	var codes []string
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		codes = append(codes, tag)
+	}
+	return Match(codes...)
+}
+
+// T - сообщение на языке lang; если перевода нет - на языке из fallback, затем на Default.
+// Для неизвестного ключа возвращается сам ключ
+func T(lang Lang, key Key, args ...any) string {
+	msg, ok := Lookup(lang, key)
+	if !ok {
+		return string(key)
+	}
+	return format(msg, args)
+}
+
+// Lookup - как T, но без подстановки аргументов и с признаком, что ключ найден
+func Lookup(lang Lang, key Key) (string, bool) {
+	for _, l := range Chain(lang) {
+		if msg, ok := catalog[l][key]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// Chain - язык и языки, на которые переходить при отсутствии перевода, по порядку;
+// Default всегда последний. Нужна для переводов вне каталога (например, названий карт)
+func Chain(lang Lang) []Lang {
+	chain := make([]Lang, 0, 3)
+	for l := lang; l != ""; l = fallbacks[l] {
+		chain = append(chain, l)
+	}
+	if len(chain) == 0 || chain[len(chain)-1] != Default {
+		chain = append(chain, Default)
+	}
+	return chain
+}
+
+// WithLang - кладет язык в контекст
+func WithLang(ctx context.Context, lang Lang) context.Context {
+	return context.WithValue(ctx, ctxKey{}, lang)
+}
+
+// FromContext - язык из контекста или Default
+func FromContext(ctx context.Context) Lang {
+	if lang, ok := ctx.Value(ctxKey{}).(Lang); ok {
+		return lang
+	}
+	return Default
+}
+
+func normalize(code string) Lang {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code, _, _ = strings.Cut(code, "-")
+	code, _, _ = strings.Cut(code, "_")
+	return Lang(code)
+}
+
+func format(msg string, args []any) string {
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
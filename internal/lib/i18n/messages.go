@@ -0,0 +1,363 @@
+package i18n
+
+// Ключи сообщений бота
+const (
+	BotWelcome        Key = "bot.welcome"
+	BotLaunchButton   Key = "bot.launch_button"
+	BotAdminUsage     Key = "bot.admin.usage"
+	BotAdminBadID     Key = "bot.admin.bad_id"
+	BotAdminNotFound  Key = "bot.admin.not_found"
+	BotAdminBlocked   Key = "bot.admin.blocked"
+	BotAdminUnblocked Key = "bot.admin.unblocked"
+
+	BotPromoUsage              Key = "bot.promo.usage"
+	BotPromoBalance            Key = "bot.promo.balance"
+	BotPromoEntitlement        Key = "bot.promo.entitlement"
+	BotPromoEntitlementForever Key = "bot.promo.entitlement_forever"
+	BotPromoNoUser             Key = "bot.promo.no_user"
+	BotAdminPromoUsage         Key = "bot.admin.promo.usage"
+	BotAdminPromoInvalid       Key = "bot.admin.promo.invalid"
+	BotAdminPromoExists        Key = "bot.admin.promo.exists"
+	BotAdminPromoCreated       Key = "bot.admin.promo.created"
+
+	BotBroadcastUsage        Key = "bot.broadcast.usage"
+	BotBroadcastInvalid      Key = "bot.broadcast.invalid"
+	BotBroadcastPreview      Key = "bot.broadcast.preview"
+	BotBroadcastStatus       Key = "bot.broadcast.status"
+	BotBroadcastReport       Key = "bot.broadcast.report"
+	BotBroadcastNotFound     Key = "bot.broadcast.not_found"
+	BotBroadcastBadState     Key = "bot.broadcast.bad_state"
+	BotBroadcastControlUsage Key = "bot.broadcast.control_usage"
+	BotBroadcastEmpty        Key = "bot.broadcast.empty"
+	BotBroadcastSendButton   Key = "bot.broadcast.send_button"
+	BotBroadcastPauseButton  Key = "bot.broadcast.pause_button"
+	BotBroadcastResumeButton Key = "bot.broadcast.resume_button"
+	BotBroadcastCancelButton Key = "bot.broadcast.cancel_button"
+
+	BotCmdStart    Key = "bot.cmd.start"
+	BotCmdCard     Key = "bot.cmd.card"
+	BotCmdDaily    Key = "bot.cmd.daily"
+	BotCmdSpread   Key = "bot.cmd.spread"
+	BotCmdBalance  Key = "bot.cmd.balance"
+	BotCmdInvite   Key = "bot.cmd.invite"
+	BotCmdPromo    Key = "bot.cmd.promo"
+	BotCmdLanguage Key = "bot.cmd.language"
+	BotCmdHelp     Key = "bot.cmd.help"
+
+	BotHelp                Key = "bot.help"
+	BotCard                Key = "bot.card"
+	BotCardMeaning         Key = "bot.card.meaning"
+	BotCardReversed        Key = "bot.card.reversed"
+	BotCardNotFound        Key = "bot.card.not_found"
+	BotDaily               Key = "bot.daily"
+	BotSpreadChoose        Key = "bot.spread.choose"
+	BotSpreadResult        Key = "bot.spread.result"
+	BotSpreadPosition      Key = "bot.spread.position"
+	BotAnotherCardButton   Key = "bot.another_card_button"
+	BotAnotherSpreadButton Key = "bot.another_spread_button"
+	BotOpenInAppButton     Key = "bot.open_in_app_button"
+	BotShareButton         Key = "bot.share_button"
+	BotShareImageButton    Key = "bot.share_image_button"
+	BotInlineOpenApp       Key = "bot.inline.open_app"
+	BotBalance             Key = "bot.balance"
+	BotBalanceEntitlement  Key = "bot.balance.entitlement"
+	BotBalanceForever      Key = "bot.balance.forever"
+	BotInvite              Key = "bot.invite"
+	BotInviteShareButton   Key = "bot.invite.share_button"
+	BotInviteShareText     Key = "bot.invite.share_text"
+	BotLanguageChoose      Key = "bot.language.choose"
+	BotLanguageAuto        Key = "bot.language.auto"
+	BotLanguageSet         Key = "bot.language.set"
+
+	ShareCardPosition Key = "share_card.position"
+	ShareCardInvite   Key = "share_card.invite"
+
+	PracticeFeedbackGood     Key = "practice.feedback.good"
+	PracticeFeedbackPartial  Key = "practice.feedback.partial"
+	PracticeFeedbackMissed   Key = "practice.feedback.missed"
+	PracticeFeedbackOpposite Key = "practice.feedback.opposite"
+
+	QuizPromptCard        Key = "quiz.prompt.card"
+	QuizPromptMeaning     Key = "quiz.prompt.meaning"
+	QuizPromptOrientation Key = "quiz.prompt.orientation"
+	QuizUpright           Key = "quiz.upright"
+	QuizReversed          Key = "quiz.reversed"
+
+	BotAchievement Key = "bot.achievement"
+)
+
+// ErrorKey - ключ пользовательского текста для кода ошибки API (response.Code*)
+func ErrorKey(code string) Key {
+	return Key("error." + code)
+}
+
+// AchievementKey - ключ названия достижения (quiz.Achievement*)
+func AchievementKey(code string) Key {
+	return Key("achievement." + code)
+}
+
+// AchievementDescriptionKey - ключ описания достижения
+func AchievementDescriptionKey(code string) Key {
+	return Key("achievement." + code + ".description")
+}
+
+// catalog - переводы; для UK и KK каталоги добавляются по мере готовности переводов,
+// до этого их пользователи получают русский текст (см. fallbacks)
+var catalog = map[Lang]map[Key]string{
+	RU: {
+		BotWelcome: `%s, приветствую! ♥️
+
+Меня зовут Taroki, я твой проводник в мир таро и эзотерики 💫
+
+Здесь ты можешь:
+
+– Узнать значения всех карт таро
+– Получить схемы таро раскладов
+– Смотреть бесплатные мастер-классы по таро, магии и другим направлениям
+– Получать подсказку на день от карт таро
+– Практиковаться в чтении раскладов
+– Записаться на расклад к эксперту-тарологу
+
+Чтобы начать работу – нажми на кнопку ниже 👇🏻`,
+		BotLaunchButton:   "Запустить 🃏",
+		BotAdminUsage:     "Использование: /block <telegram_id> или /unblock <telegram_id>",
+		BotAdminBadID:     "Некорректный telegram_id",
+		BotAdminNotFound:  "Пользователь не найден",
+		BotAdminBlocked:   "Пользователь заблокирован, его сессии отозваны",
+		BotAdminUnblocked: "Пользователь разблокирован",
+
+		BotPromoUsage:              "Отправьте код так: /promo КОД",
+		BotPromoBalance:            "Код активирован! На баланс начислено %d, теперь на балансе %d ✨",
+		BotPromoEntitlement:        "Код активирован! Доступ %s открыт до %s ✨",
+		BotPromoEntitlementForever: "Код активирован! Доступ %s открыт навсегда ✨",
+		BotPromoNoUser:             "Сначала откройте приложение кнопкой в /start, затем отправьте код снова",
+		BotAdminPromoUsage:         "Использование: /newpromo code=КОД | count=N [prefix=ПРЕФИКС] balance=N | entitlement=premium [days=N] [max=N] [per_user=N] [from=ГГГГ-ММ-ДД] [until=ГГГГ-ММ-ДД]",
+		BotAdminPromoInvalid:       "Некорректные параметры: %s",
+		BotAdminPromoExists:        "Такой код уже существует",
+		BotAdminPromoCreated:       "Создано кодов: %d (выпуск %s)",
+
+		BotBroadcastUsage: `Ответьте командой /broadcast на сообщение, которое нужно разослать.
+Сегмент (необязательно): lang=ru balance=yes referrals=yes active=30`,
+		BotBroadcastInvalid:      "Некорректный сегмент: %s",
+		BotBroadcastPreview:      "Рассылка %s\nСегмент: %s\nПолучателей сейчас: %d\n\nВыше - сообщение так, как его увидят пользователи.",
+		BotBroadcastStatus:       "Рассылка %s: %s\nСегмент: %s\nОтправлено %d из %d, не доставлено %d, заблокировали бота %d",
+		BotBroadcastReport:       "Рассылка %s завершена\nОтправлено: %d из %d\nНе доставлено: %d\nЗаблокировали бота: %d\nВремя: %s",
+		BotBroadcastNotFound:     "Рассылка не найдена",
+		BotBroadcastBadState:     "Действие недоступно в текущем статусе рассылки",
+		BotBroadcastControlUsage: "Использование: /bcstatus [id], /bcpause <id>, /bcresume <id>, /bccancel <id>",
+		BotBroadcastEmpty:        "Рассылок пока нет",
+		BotBroadcastSendButton:   "Отправить",
+		BotBroadcastPauseButton:  "Пауза",
+		BotBroadcastResumeButton: "Продолжить",
+		BotBroadcastCancelButton: "Отменить",
+
+		BotCmdStart:    "Открыть приложение",
+		BotCmdCard:     "Случайная карта или значение карты по названию",
+		BotCmdDaily:    "Карта дня",
+		BotCmdSpread:   "Сделать расклад",
+		BotCmdBalance:  "Баланс и доступы",
+		BotCmdInvite:   "Пригласить друга",
+		BotCmdPromo:    "Активировать промокод",
+		BotCmdLanguage: "Язык бота",
+		BotCmdHelp:     "Что умеет бот",
+
+		BotHelp:                "Вот что я умею:\n\n%s\n\nЗначения всех карт, схемы раскладов и обучение - в приложении 👇🏻",
+		BotCard:                "🃏 %s\n\n%s",
+		BotCardMeaning:         "🃏 %s\n\nПрямое положение: %s\nПеревернутое положение: %s",
+		BotCardReversed:        "%s (перевернутая)",
+		BotCardNotFound:        "Не нашла карту «%s». Попробуйте так: /card шут",
+		BotDaily:               "Ваша карта дня на %s\n\n%s",
+		BotSpreadChoose:        "Выберите расклад 🔮",
+		BotSpreadResult:        "🔮 %s\n\n%s",
+		BotSpreadPosition:      "%d. %s - %s: %s",
+		BotAnotherCardButton:   "Еще карта",
+		BotAnotherSpreadButton: "Другой расклад",
+		BotOpenInAppButton:     "Открыть в приложении",
+		BotShareButton:         "Поделиться",
+		BotShareImageButton:    "Картинка для сторис",
+		BotInlineOpenApp:       "Открыть в Taroki",
+		BotBalance:             "Баланс: %d",
+		BotBalanceEntitlement:  "Доступ %s до %s",
+		BotBalanceForever:      "Доступ %s навсегда",
+		BotInvite:              "Приглашайте друзей: когда друг откроет приложение по вашей ссылке, вы оба получите бонус на баланс 🎁\n\n%s",
+		BotInviteShareButton:   "Поделиться ссылкой",
+		BotInviteShareText:     "Мой проводник в мир таро - присоединяйся ✨",
+		BotLanguageChoose:      "Выберите язык",
+		BotLanguageAuto:        "Как в Telegram",
+		BotLanguageSet:         "Готово, теперь я отвечаю на русском",
+
+		ShareCardPosition: "%d. %s: %s",
+		ShareCardInvite:   "Сделай свой расклад",
+
+		PracticeFeedbackGood:     "Верно! Вы отметили главное: %s",
+		PracticeFeedbackPartial:  "Близко. Вы отметили: %s. Еще в значении карты: %s",
+		PracticeFeedbackMissed:   "Значение карты в этом положении: %s",
+		PracticeFeedbackOpposite: "Похоже, это толкование карты в другом положении. В этом положении: %s",
+
+		QuizPromptCard:        "Что означает карта «%s»?",
+		QuizPromptMeaning:     "Какая карта означает: %s?",
+		QuizPromptOrientation: "В каком положении карта «%s» означает: %s?",
+		QuizUpright:           "Прямое",
+		QuizReversed:          "Перевернутое",
+
+		BotAchievement: "🏆 Новое достижение: %s\n\n%s",
+
+		AchievementKey("major_arcana_master"):            "Знаток старших арканов",
+		AchievementDescriptionKey("major_arcana_master"): "Вы выучили значения всех 22 старших арканов в прямом и перевернутом положении",
+
+		ErrorKey("bad_request"):              "Некорректный запрос",
+		ErrorKey("validation_failed"):        "Проверьте введенные данные",
+		ErrorKey("unauthorized"):             "Требуется авторизация",
+		ErrorKey("forbidden"):                "Доступ запрещен",
+		ErrorKey("not_found"):                "Не найдено",
+		ErrorKey("method_not_allowed"):       "Метод не поддерживается",
+		ErrorKey("rate_limited"):             "Слишком много запросов, попробуйте позже",
+		ErrorKey("internal_error"):           "Что-то пошло не так, попробуйте позже",
+		ErrorKey("upstream_error"):           "Telegram временно недоступен, попробуйте позже",
+		ErrorKey("init_data_expired"):        "Сессия устарела, перезапустите приложение",
+		ErrorKey("init_data_invalid"):        "Не удалось подтвердить вход через Telegram",
+		ErrorKey("nonce_required"):           "Некорректный запрос",
+		ErrorKey("replay_detected"):          "Запрос уже был отправлен",
+		ErrorKey("token_expired"):            "Сессия устарела, войдите снова",
+		ErrorKey("token_invalid"):            "Требуется повторный вход",
+		ErrorKey("session_revoked"):          "Сессия завершена, войдите снова",
+		ErrorKey("referral_invalid"):         "Приглашение недействительно",
+		ErrorKey("referral_unavailable"):     "Бонус за приглашение уже получен или больше недоступен",
+		ErrorKey("promo_not_found"):          "Такого кода нет, проверьте написание",
+		ErrorKey("promo_inactive"):           "Срок действия кода истек или еще не начался",
+		ErrorKey("promo_exhausted"):          "Код уже активировали максимальное число раз",
+		ErrorKey("promo_already_redeemed"):   "Вы уже активировали этот код",
+		ErrorKey("practice_answered"):        "Вы уже ответили на этот расклад",
+		ErrorKey("quiz_answered"):            "Вы уже ответили на этот вопрос",
+		ErrorKey("idempotency_key_mismatch"): "Запрос не совпадает с отправленным ранее",
+		ErrorKey("idempotency_in_progress"):  "Запрос еще выполняется, подождите",
+	},
+	EN: {
+		BotWelcome: `%s, hello! ♥️
+
+My name is Taroki, I am your guide to the world of tarot and esotericism 💫
+
+Here you can:
+
+– Find out the meanings of all tarot cards
+– Get tarot layouts
+– Watch free master classes on tarot, magic and other areas
+– Receive daily hints from tarot cards
+– Practice reading layouts
+– Sign up for a reading with an expert tarot reader
+
+To get started, click on the button below 👇🏻`,
+		BotLaunchButton:   "Launch 🃏",
+		BotAdminUsage:     "Usage: /block <telegram_id> or /unblock <telegram_id>",
+		BotAdminBadID:     "Invalid telegram_id",
+		BotAdminNotFound:  "User not found",
+		BotAdminBlocked:   "User is blocked, their sessions are revoked",
+		BotAdminUnblocked: "User is unblocked",
+
+		BotPromoUsage:              "Send the code like this: /promo CODE",
+		BotPromoBalance:            "Code redeemed! %d added to your balance, now you have %d ✨",
+		BotPromoEntitlement:        "Code redeemed! %s access is open until %s ✨",
+		BotPromoEntitlementForever: "Code redeemed! %s access is open forever ✨",
+		BotPromoNoUser:             "Open the app with the button in /start first, then send the code again",
+		BotAdminPromoUsage:         "Usage: /newpromo code=CODE | count=N [prefix=PREFIX] balance=N | entitlement=premium [days=N] [max=N] [per_user=N] [from=YYYY-MM-DD] [until=YYYY-MM-DD]",
+		BotAdminPromoInvalid:       "Invalid parameters: %s",
+		BotAdminPromoExists:        "This code already exists",
+		BotAdminPromoCreated:       "Codes created: %d (batch %s)",
+
+		BotBroadcastUsage: `Reply with /broadcast to the message you want to send.
+Segment (optional): lang=ru balance=yes referrals=yes active=30`,
+		BotBroadcastInvalid:      "Invalid segment: %s",
+		BotBroadcastPreview:      "Broadcast %s\nSegment: %s\nRecipients now: %d\n\nAbove is the message as users will see it.",
+		BotBroadcastStatus:       "Broadcast %s: %s\nSegment: %s\nSent %d of %d, failed %d, blocked the bot %d",
+		BotBroadcastReport:       "Broadcast %s is finished\nSent: %d of %d\nFailed: %d\nBlocked the bot: %d\nTook: %s",
+		BotBroadcastNotFound:     "Broadcast not found",
+		BotBroadcastBadState:     "This action is not available in the current broadcast status",
+		BotBroadcastControlUsage: "Usage: /bcstatus [id], /bcpause <id>, /bcresume <id>, /bccancel <id>",
+		BotBroadcastEmpty:        "There are no broadcasts yet",
+		BotBroadcastSendButton:   "Send",
+		BotBroadcastPauseButton:  "Pause",
+		BotBroadcastResumeButton: "Resume",
+		BotBroadcastCancelButton: "Cancel",
+
+		BotCmdStart:    "Open the app",
+		BotCmdCard:     "A random card or a card meaning by name",
+		BotCmdDaily:    "Card of the day",
+		BotCmdSpread:   "Make a spread",
+		BotCmdBalance:  "Balance and access",
+		BotCmdInvite:   "Invite a friend",
+		BotCmdPromo:    "Redeem a promo code",
+		BotCmdLanguage: "Bot language",
+		BotCmdHelp:     "What the bot can do",
+
+		BotHelp:                "Here is what I can do:\n\n%s\n\nMeanings of all cards, spread layouts and lessons are in the app 👇🏻",
+		BotCard:                "🃏 %s\n\n%s",
+		BotCardMeaning:         "🃏 %s\n\nUpright: %s\nReversed: %s",
+		BotCardReversed:        "%s (reversed)",
+		BotCardNotFound:        "Could not find the card \"%s\". Try like this: /card fool",
+		BotDaily:               "Your card of the day for %s\n\n%s",
+		BotSpreadChoose:        "Choose a spread 🔮",
+		BotSpreadResult:        "🔮 %s\n\n%s",
+		BotSpreadPosition:      "%d. %s - %s: %s",
+		BotAnotherCardButton:   "Another card",
+		BotAnotherSpreadButton: "Another spread",
+		BotOpenInAppButton:     "Open in the app",
+		BotShareButton:         "Share",
+		BotShareImageButton:    "Image for stories",
+		BotInlineOpenApp:       "Open in Taroki",
+		BotBalance:             "Balance: %d",
+		BotBalanceEntitlement:  "%s access until %s",
+		BotBalanceForever:      "%s access forever",
+		BotInvite:              "Invite your friends: when a friend opens the app with your link, you both get a bonus to your balance 🎁\n\n%s",
+		BotInviteShareButton:   "Share the link",
+		BotInviteShareText:     "My guide to the world of tarot - join me ✨",
+		BotLanguageChoose:      "Choose a language",
+		BotLanguageAuto:        "Same as Telegram",
+		BotLanguageSet:         "Done, I will answer in English now",
+
+		ShareCardPosition: "%d. %s: %s",
+		ShareCardInvite:   "Get your own reading",
+
+		PracticeFeedbackGood:     "Correct! You noted the key points: %s",
+		PracticeFeedbackPartial:  "Close. You noted: %s. The card also means: %s",
+		PracticeFeedbackMissed:   "In this position the card means: %s",
+		PracticeFeedbackOpposite: "This looks like the meaning of the card in the other position. In this position: %s",
+
+		QuizPromptCard:        "What does the %s card mean?",
+		QuizPromptMeaning:     "Which card means: %s?",
+		QuizPromptOrientation: "In which position does the %s card mean: %s?",
+		QuizUpright:           "Upright",
+		QuizReversed:          "Reversed",
+
+		BotAchievement: "🏆 New achievement: %s\n\n%s",
+
+		AchievementKey("major_arcana_master"):            "Major Arcana Master",
+		AchievementDescriptionKey("major_arcana_master"): "You have learned the meanings of all 22 Major Arcana, upright and reversed",
+
+		ErrorKey("bad_request"):              "Invalid request",
+		ErrorKey("validation_failed"):        "Please check the entered data",
+		ErrorKey("unauthorized"):             "Authorization required",
+		ErrorKey("forbidden"):                "Access denied",
+		ErrorKey("not_found"):                "Not found",
+		ErrorKey("method_not_allowed"):       "Method not allowed",
+		ErrorKey("rate_limited"):             "Too many requests, please try again later",
+		ErrorKey("internal_error"):           "Something went wrong, please try again later",
+		ErrorKey("upstream_error"):           "Telegram is temporarily unavailable, please try again later",
+		ErrorKey("init_data_expired"):        "Your session is outdated, please restart the app",
+		ErrorKey("init_data_invalid"):        "Could not verify your Telegram sign-in",
+		ErrorKey("nonce_required"):           "Invalid request",
+		ErrorKey("replay_detected"):          "This request has already been sent",
+		ErrorKey("token_expired"):            "Your session has expired, please sign in again",
+		ErrorKey("token_invalid"):            "Please sign in again",
+		ErrorKey("session_revoked"):          "Your session has ended, please sign in again",
+		ErrorKey("referral_invalid"):         "This invitation is not valid",
+		ErrorKey("referral_unavailable"):     "The invitation bonus has already been received or is no longer available",
+		ErrorKey("promo_not_found"):          "There is no such code, please check the spelling",
+		ErrorKey("promo_inactive"):           "This code has expired or is not active yet",
+		ErrorKey("promo_exhausted"):          "This code has already been used the maximum number of times",
+		ErrorKey("promo_already_redeemed"):   "You have already redeemed this code",
+		ErrorKey("practice_answered"):        "You have already answered this spread",
+		ErrorKey("quiz_answered"):            "You have already answered this question",
+		ErrorKey("idempotency_key_mismatch"): "This request does not match the one sent earlier",
+		ErrorKey("idempotency_in_progress"):  "The request is still being processed, please wait",
+	},
+}
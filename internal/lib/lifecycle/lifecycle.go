@@ -0,0 +1,206 @@
+// Package lifecycle - запуск и корректная остановка частей приложения (HTTP, боты, фоновые задачи)
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Component - часть приложения
+type Component struct {
+	Name string
+	// Start - запускает компонент и блокируется, пока он работает; ошибка останавливает все приложение.
+	// ctx отменяется, когда начинается остановка
+	Start func(ctx context.Context) error
+	// Stop - перестает принимать новую работу и дожидается завершения текущей
+	Stop func(ctx context.Context) error
+	// Ready - закрывается, когда компонент готов к работе (например, HTTP сервер слушает порт);
+	// следующий компонент запускается только после этого. nil - готов сразу после запуска
+	Ready <-chan struct{}
+}
+
+// Runner - запускает компоненты по порядку и останавливает их в обратном
+type Runner struct {
+	log             *slog.Logger
+	shutdownTimeout time.Duration
+	components      []Component
+}
+
+// New - создает Runner; shutdownTimeout ограничивает остановку всех компонентов
+func New(log *slog.Logger, shutdownTimeout time.Duration) *Runner {
+	return &Runner{log: log, shutdownTimeout: shutdownTimeout}
+}
+
+// Add - добавляет компонент, порядок добавления - порядок запуска
+func (r *Runner) Add(c Component) {
+	r.components = append(r.components, c)
+}
+
+// Run - запускает компоненты по порядку, дожидаясь готовности каждого, и работает до отмены
+// ctx (сигнал остановки) или до ошибки любого компонента, затем останавливает запущенные
+// компоненты и возвращает первую ошибку. Если компонент не запустился, следующие не запускаются
+func (r *Runner) Run(ctx context.Context) error {
+	g, gctx := errgroup.WithContext(ctx)
+
+	started := 0
+	for _, c := range r.components {
+		if gctx.Err() != nil {
+			break
+		}
+
+		r.log.Info("starting component", slog.String("component", c.Name))
+		started++
+
+		g.Go(func() error {
+			if err := c.Start(gctx); err != nil {
+				return fmt.Errorf("%s: %w", c.Name, err)
+			}
+			return nil
+		})
+
+		if c.Ready != nil {
+			select {
+			case <-c.Ready:
+			case <-gctx.Done():
+			}
+		}
+	}
+
+	runDone := make(chan error, 1)
+	go func() { runDone <- g.Wait() }()
+
+	// gctx отменяется и когда все компоненты завершились сами
+	<-gctx.Done()
+	stopErr := r.stop(r.components[:started])
+	runErr := <-runDone
+
+	return errors.Join(runErr, stopErr)
+}
+
+// stop - останавливает компоненты в порядке, обратном запуску
+func (r *Runner) stop(components []Component) error {
+	r.log.Info("shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		if c.Stop == nil {
+			continue
+		}
+
+		log := r.log.With(slog.String("component", c.Name))
+		log.Info("stopping component")
+
+		if err := c.Stop(ctx); err != nil {
+			log.Error("failed to stop component", slog.String("error", err.Error()))
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.Name, err))
+			continue
+		}
+
+		log.Info("component stopped")
+	}
+
+	return errors.Join(errs...)
+}
+
+// InFlight - счетчик выполняющихся задач, чтобы дождаться их при остановке. После начала
+// Wait новые задачи не принимаются: иначе задача могла бы начаться, когда ее уже не ждут
+type InFlight struct {
+	mu       sync.Mutex
+	draining bool
+	wg       sync.WaitGroup
+}
+
+// Track - отмечает начало задачи и возвращает функцию для отметки ее завершения;
+// ok = false, если остановка уже началась и задачу выполнять не нужно
+func (f *InFlight) Track() (done func(), ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.draining {
+		return nil, false
+	}
+	f.wg.Add(1)
+	return f.wg.Done, true
+}
+
+// Go - выполняет fn в отдельной горутине как задачу; false, если остановка уже началась
+func (f *InFlight) Go(fn func()) bool {
+	done, ok := f.Track()
+	if !ok {
+		return false
+	}
+
+	go func() {
+		defer done()
+		fn()
+	}()
+	return true
+}
+
+// Wait - перестает принимать задачи и ждет завершения текущих или отмены ctx
+func (f *InFlight) Wait(ctx context.Context) error {
+	f.mu.Lock()
+	f.draining = true
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Component - задачи как часть приложения: при остановке новые не принимаются, а текущие
+// дожидаются. Добавляется в Runner раньше компонентов, которые запускают задачи
+func (f *InFlight) Component(name string) Component {
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		},
+		Stop: f.Wait,
+	}
+}
+
+// Periodic - фоновая задача, которая выполняется раз в interval до остановки приложения;
+// ошибка выполнения логируется и не останавливает приложение
+func Periodic(log *slog.Logger, name string, interval time.Duration, task func(ctx context.Context) error) Component {
+	log = log.With(slog.String("component", name))
+
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+					if err := task(ctx); err != nil && ctx.Err() == nil {
+						log.Error("periodic task failed", slog.String("error", err.Error()))
+					}
+				}
+			}
+		},
+	}
+}
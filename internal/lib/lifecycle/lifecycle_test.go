@@ -0,0 +1,274 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"taro-api/internal/lib/lifecycle"
+	"testing"
+	"time"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// events - журнал запуска и остановки компонентов
+type events struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (e *events) add(s string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.list = append(e.list, s)
+}
+
+func (e *events) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.list)
+}
+
+// component - компонент, который готов через delay после запуска и работает до остановки
+func component(ev *events, name string, delay time.Duration) lifecycle.Component {
+	ready := make(chan struct{})
+
+	return lifecycle.Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			time.Sleep(delay)
+			ev.add("ready " + name)
+			close(ready)
+			<-ctx.Done()
+			return nil
+		},
+		Stop: func(context.Context) error {
+			ev.add("stop " + name)
+			return nil
+		},
+		Ready: ready,
+	}
+}
+
+func TestRunStartsInOrder(t *testing.T) {
+	var ev events
+
+	r := lifecycle.New(discard, time.Second)
+	// первый компонент готовится дольше: при параллельном запуске второй был бы готов раньше
+	r.Add(component(&ev, "http", 30*time.Millisecond))
+	r.Add(component(&ev, "bots", 0))
+	r.Add(component(&ev, "broadcasts", 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+
+	waitFor(t, func() bool { return len(ev.get()) == 3 })
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := []string{"ready http", "ready bots", "ready broadcasts", "stop broadcasts", "stop bots", "stop http"}
+	if got := ev.get(); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestRunStopsOnStartFailure(t *testing.T) {
+	var ev events
+	fail := errors.New("address already in use")
+
+	r := lifecycle.New(discard, time.Second)
+	r.Add(component(&ev, "metrics", 0))
+	r.Add(lifecycle.Component{
+		Name:  "http",
+		Start: func(context.Context) error { return fail },
+		Stop: func(context.Context) error {
+			ev.add("stop http")
+			return nil
+		},
+		Ready: make(chan struct{}),
+	})
+	r.Add(component(&ev, "bots", 0))
+
+	err := r.Run(context.Background())
+	if !errors.Is(err, fail) {
+		t.Fatalf("Run error = %v, want start failure", err)
+	}
+
+	// боты не запускались, поэтому и не останавливаются
+	want := []string{"ready metrics", "stop http", "stop metrics"}
+	if got := ev.get(); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestRunReturnsWhenComponentsFinish(t *testing.T) {
+	r := lifecycle.New(discard, time.Second)
+	r.Add(lifecycle.Component{Name: "once", Start: func(context.Context) error { return nil }})
+
+	done := make(chan error)
+	go func() { done <- r.Run(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after all components finished")
+	}
+}
+
+func TestInFlightWait(t *testing.T) {
+	var f lifecycle.InFlight
+
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	if !f.Go(func() {
+		<-release
+		close(finished)
+	}) {
+		t.Fatal("Go rejected a job before draining")
+	}
+
+	waited := make(chan error)
+	go func() { waited <- f.Wait(context.Background()) }()
+
+	waitFor(t, func() bool {
+		done, ok := f.Track()
+		if ok {
+			done()
+		}
+		return !ok
+	})
+
+	select {
+	case <-waited:
+		t.Fatal("Wait returned before the job finished")
+	default:
+	}
+
+	close(release)
+	if err := <-waited; err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Fatal("Wait returned before the job finished")
+	}
+}
+
+func TestInFlightRejectsAfterDrain(t *testing.T) {
+	var f lifecycle.InFlight
+
+	if err := f.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+
+	if done, ok := f.Track(); ok || done != nil {
+		t.Error("Track accepted a task after draining started")
+	}
+	if f.Go(func() { t.Error("job started after draining") }) {
+		t.Error("Go accepted a job after draining started")
+	}
+}
+
+func TestInFlightWaitTimeout(t *testing.T) {
+	var f lifecycle.InFlight
+
+	done, ok := f.Track()
+	if !ok {
+		t.Fatal("Track rejected a task before draining")
+	}
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := f.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+// TestInFlightConcurrent - Track во время Wait не должен гоняться с WaitGroup (go test -race)
+func TestInFlightConcurrent(t *testing.T) {
+	var f lifecycle.InFlight
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.Go(func() { time.Sleep(time.Millisecond) })
+		}()
+	}
+
+	if err := f.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	wg.Wait()
+}
+
+// TestInFlightComponent - задачи, запущенные запросами, дожидаются после остановки HTTP
+func TestInFlightComponent(t *testing.T) {
+	var (
+		ev   events
+		jobs lifecycle.InFlight
+	)
+
+	release := make(chan struct{})
+	r := lifecycle.New(discard, time.Second)
+	r.Add(jobs.Component("jobs"))
+	r.Add(component(&ev, "http", 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+
+	waitFor(t, func() bool { return len(ev.get()) == 1 })
+	if !jobs.Go(func() {
+		<-release
+		ev.add("job finished")
+	}) {
+		t.Fatal("Go rejected a job before shutdown")
+	}
+	cancel()
+
+	waitFor(t, func() bool { return len(ev.get()) == 2 })
+	select {
+	case err := <-done:
+		t.Fatalf("Run returned before the job finished: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	// после остановки HTTP компонент задач перестает их принимать
+	waitFor(t, func() bool { return !jobs.Go(func() {}) })
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := []string{"ready http", "stop http", "job finished"}
+	if got := ev.get(); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
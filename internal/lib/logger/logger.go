@@ -0,0 +1,83 @@
+// Package logger - настройка slog и логгер, привязанный к запросу
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi"
+)
+
+// Форматы вывода
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type ctxKey struct{}
+
+// entry - логгер запроса, который можно дополнять по мере прохождения мидлвейров
+type entry struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+// New - создает логгер с заданным форматом (text/json) и уровнем (debug/info/warn/error)
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText, "":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+}
+
+// WithContext - кладет логгер запроса в контекст
+func WithContext(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &entry{logger: log})
+}
+
+// Enrich - добавляет атрибуты к логгеру запроса (видны и в access логе)
+func Enrich(ctx context.Context, attrs ...any) {
+	e, ok := ctx.Value(ctxKey{}).(*entry)
+	if !ok {
+		return
+	}
+	e.mu.Lock()
+	e.logger = e.logger.With(attrs...)
+	e.mu.Unlock()
+}
+
+// FromContext - логгер запроса или fallback, если его нет
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	e, ok := ctx.Value(ctxKey{}).(*entry)
+	if !ok {
+		return fallback
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.logger
+}
+
+// FromRequest - логгер запроса с шаблоном маршрута chi
+func FromRequest(r *http.Request, fallback *slog.Logger) *slog.Logger {
+	log := FromContext(r.Context(), fallback)
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		log = log.With(slog.String("route", rctx.RoutePattern()))
+	}
+	return log
+}
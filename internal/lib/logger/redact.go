@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// Redacted - значение, которое выводится вместо секрета
+const Redacted = "<redacted>"
+
+// Redactor - вычищает известные секреты (токены ботов) из сообщений и атрибутов логов
+type Redactor struct {
+	replacer atomic.Pointer[strings.Replacer]
+	secrets  []string
+}
+
+// NewRedactor - создает Redactor для заданных секретов
+func NewRedactor(secrets ...string) *Redactor {
+	r := &Redactor{}
+	r.Add(secrets...)
+	return r
+}
+
+// Add - добавляет секреты; старые значения остаются, чтобы после ротации
+// не утекали токены из запросов, которые еще в работе. Вызывать из одной горутины.
+func (r *Redactor) Add(secrets ...string) {
+	for _, s := range secrets {
+		if s == "" {
+			continue
+		}
+		// токен в адресе запроса может оказаться экранированным (":" -> "%3A")
+		r.secrets = append(r.secrets, s, url.QueryEscape(s))
+	}
+
+	pairs := make([]string, 0, len(r.secrets)*2)
+	for _, s := range r.secrets {
+		pairs = append(pairs, s, Redacted)
+	}
+	r.replacer.Store(strings.NewReplacer(pairs...))
+}
+
+// Redact - заменяет секреты в строке на Redacted
+func (r *Redactor) Redact(s string) string {
+	replacer := r.replacer.Load()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// Redact - оборачивает логгер так, что секреты не попадают в вывод
+func Redact(log *slog.Logger, r *Redactor) *slog.Logger {
+	return slog.New(&redactHandler{next: log.Handler(), r: r})
+}
+
+type redactHandler struct {
+	next slog.Handler
+	r    *Redactor
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.r.Redact(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.attr(a)
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted), r: h.r}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name), r: h.r}
+}
+
+func (h *redactHandler) attr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.r.Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]any, len(group))
+		for i, ga := range group {
+			redacted[i] = h.attr(ga)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		// ошибки, структуры, Stringer и прочие значения выводятся через строковое представление:
+		// секрет может оказаться в любом поле, а JSON обработчик вывел бы и поля, скрытые String()
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, h.r.Redact(err.Error()))
+		}
+		return slog.String(a.Key, h.r.Redact(fmt.Sprintf("%+v", v.Any())))
+	default:
+		return a
+	}
+}
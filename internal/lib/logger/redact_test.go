@@ -0,0 +1,71 @@
+package logger_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"taro-api/internal/lib/logger"
+	"testing"
+)
+
+const secret = "123456:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+type config struct {
+	Name  string
+	Token string
+}
+
+// masked - Stringer, который прячет токен, хотя он есть в полях
+type masked struct {
+	Token string
+}
+
+func (masked) String() string { return "masked" }
+
+type stringer struct{ s string }
+
+func (s stringer) String() string { return s.s }
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{name: "string", attr: slog.String("url", "https://api.telegram.org/bot"+secret+"/getMe")},
+		{name: "escaped", attr: slog.String("url", "/bot123456%3AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA/getMe")},
+		{name: "error", attr: slog.Any("error", errors.New("Get /bot"+secret+": timeout"))},
+		{name: "struct", attr: slog.Any("config", config{Name: "main", Token: secret}), want: "main"},
+		{name: "pointer", attr: slog.Any("config", &config{Name: "main", Token: secret}), want: "main"},
+		{name: "stringer", attr: slog.Any("value", stringer{s: "token " + secret})},
+		{name: "hidden by stringer", attr: slog.Any("value", masked{Token: secret}), want: "masked"},
+		{name: "slice", attr: slog.Any("tokens", []string{secret})},
+		{name: "map", attr: slog.Any("tokens", map[string]string{"main": secret}), want: "main"},
+		{name: "group", attr: slog.Group("bot", slog.Any("config", config{Token: secret}))},
+		{name: "int", attr: slog.Int64("telegram_id", 123456), want: "123456"},
+	}
+
+	for _, tt := range tests {
+		for handler, newHandler := range map[string]func(*bytes.Buffer) slog.Handler{
+			"text": func(b *bytes.Buffer) slog.Handler { return slog.NewTextHandler(b, nil) },
+			"json": func(b *bytes.Buffer) slog.Handler { return slog.NewJSONHandler(b, nil) },
+		} {
+			t.Run(tt.name+" "+handler, func(t *testing.T) {
+				var buf bytes.Buffer
+				log := logger.Redact(slog.New(newHandler(&buf)), logger.NewRedactor(secret))
+
+				log.Info("message "+secret, tt.attr)
+				log.With(tt.attr).Info("with")
+
+				out := buf.String()
+				if strings.Contains(out, "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA") {
+					t.Errorf("secret leaked: %s", out)
+				}
+				if tt.want != "" && !strings.Contains(out, tt.want) {
+					t.Errorf("output %s lost %q", out, tt.want)
+				}
+			})
+		}
+	}
+}
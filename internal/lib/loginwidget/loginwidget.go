@@ -0,0 +1,77 @@
+// Package loginwidget - проверка данных Telegram Login Widget
+// (https://core.telegram.org/widgets/login#checking-authorization)
+package loginwidget
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Возможные ошибки
+var (
+	ErrHashMissing = errors.New("hash is missing")
+	ErrHashInvalid = errors.New("hash is invalid")
+	ErrExpired     = errors.New("auth data is expired")
+)
+
+// Data - данные, которые виджет передает после входа
+type Data struct {
+	ID        int64  `json:"id" validate:"required"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name,omitempty"`
+	Username  string `json:"username,omitempty"`
+	PhotoURL  string `json:"photo_url,omitempty"`
+	AuthDate  int64  `json:"auth_date" validate:"required"`
+	Hash      string `json:"hash" validate:"required"`
+}
+
+// Validate - проверяет подпись данных токеном бота и их возраст (maxAge <= 0 - без ограничения)
+func Validate(d Data, token string, maxAge time.Duration) error {
+	if d.Hash == "" {
+		return ErrHashMissing
+	}
+
+	if maxAge > 0 && time.Unix(d.AuthDate, 0).Add(maxAge).Before(time.Now()) {
+		return ErrExpired
+	}
+
+	secret := sha256.Sum256([]byte(token))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(d.checkString()))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(d.Hash))) {
+		return ErrHashInvalid
+	}
+
+	return nil
+}
+
+// checkString - отсортированные пары key=value без hash, пустые поля виджет не передает
+func (d Data) checkString() string {
+	fields := map[string]string{
+		"id":         strconv.FormatInt(d.ID, 10),
+		"first_name": d.FirstName,
+		"last_name":  d.LastName,
+		"username":   d.Username,
+		"photo_url":  d.PhotoURL,
+		"auth_date":  strconv.FormatInt(d.AuthDate, 10),
+	}
+
+	pairs := make([]string, 0, len(fields))
+	for k, v := range fields {
+		if v == "" {
+			continue
+		}
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "\n")
+}
@@ -0,0 +1,120 @@
+package loginwidget_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"taro-api/internal/lib/loginwidget"
+	"testing"
+	"time"
+)
+
+const testToken = "123456:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// sign - подпись по описанию Telegram: ключ - SHA256 токена, данные - отсортированные пары
+func sign(token, checkString string) string {
+	secret := sha256.Sum256([]byte(token))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(checkString))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func signed(authDate int64) loginwidget.Data {
+	d := loginwidget.Data{
+		ID:        42,
+		FirstName: "Ann",
+		Username:  "ann",
+		PhotoURL:  "https://t.me/i/userpic/320/ann.jpg",
+		AuthDate:  authDate,
+	}
+	// last_name пустой и в строку не входит
+	d.Hash = sign(testToken, strings.Join([]string{
+		"auth_date=" + strconv.FormatInt(authDate, 10),
+		"first_name=Ann",
+		"id=42",
+		"photo_url=https://t.me/i/userpic/320/ann.jpg",
+		"username=ann",
+	}, "\n"))
+	return d
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name   string
+		data   func() loginwidget.Data
+		token  string
+		maxAge time.Duration
+		want   error
+	}{
+		{name: "valid", data: func() loginwidget.Data { return signed(now) }, maxAge: time.Hour},
+		{
+			name: "upper case hash",
+			data: func() loginwidget.Data {
+				d := signed(now)
+				d.Hash = strings.ToUpper(d.Hash)
+				return d
+			},
+			maxAge: time.Hour,
+		},
+		{
+			name: "tampered id",
+			data: func() loginwidget.Data {
+				d := signed(now)
+				d.ID = 43
+				return d
+			},
+			maxAge: time.Hour,
+			want:   loginwidget.ErrHashInvalid,
+		},
+		{
+			name: "added field",
+			data: func() loginwidget.Data {
+				d := signed(now)
+				d.LastName = "Smith"
+				return d
+			},
+			maxAge: time.Hour,
+			want:   loginwidget.ErrHashInvalid,
+		},
+		{
+			name:   "other bot",
+			data:   func() loginwidget.Data { return signed(now) },
+			token:  "654321:BBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB",
+			maxAge: time.Hour,
+			want:   loginwidget.ErrHashInvalid,
+		},
+		{
+			name: "no hash",
+			data: func() loginwidget.Data {
+				d := signed(now)
+				d.Hash = ""
+				return d
+			},
+			want: loginwidget.ErrHashMissing,
+		},
+		{
+			name:   "expired",
+			data:   func() loginwidget.Data { return signed(now - 2*3600) },
+			maxAge: time.Hour,
+			want:   loginwidget.ErrExpired,
+		},
+		{name: "no max age", data: func() loginwidget.Data { return signed(now - 30*24*3600) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := tt.token
+			if token == "" {
+				token = testToken
+			}
+			if err := loginwidget.Validate(tt.data(), token, tt.maxAge); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
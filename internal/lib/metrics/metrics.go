@@ -0,0 +1,215 @@
+// Package metrics - метрики Prometheus для HTTP, бота, хранилища и вызовов Telegram API
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	tele "gopkg.in/telebot.v3"
+)
+
+const namespace = "taroki"
+
+// Registry - реестр метрик приложения
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route pattern, method and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	httpRejected = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rejected_total",
+		Help:      "HTTP requests rejected before reaching a handler.",
+	}, []string{"reason"})
+
+	botUpdates = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "bot",
+		Name:      "updates_total",
+		Help:      "Bot updates processed by handler.",
+	}, []string{"handler"})
+
+	botErrors = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "bot",
+		Name:      "handler_errors_total",
+		Help:      "Bot handler errors, including failed sends.",
+	}, []string{"handler"})
+
+	telegramRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "requests_total",
+		Help:      "Outbound Telegram Bot API calls by method and result.",
+	}, []string{"method", "result"})
+
+	telegramDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "telegram",
+		Name:      "request_duration_seconds",
+		Help:      "Outbound Telegram Bot API call latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler - обработчик /metrics
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// HTTP - мидлвейр, считающий запросы и их длительность по шаблону маршрута chi
+func HTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// RateLimited - оборачивает обработчик превышения лимита httprate
+func RateLimited(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		httpRejected.WithLabelValues("rate_limit").Inc()
+		h(w, r)
+	}
+}
+
+// ObserveThrottle - оборачивает ThrottleBacklog и считает запросы, которые он отклонил.
+// Отказ отдает rejected: сам ThrottleBacklog пишет ответ простым текстом, и он отбрасывается
+func ObserveThrottle(throttle func(http.Handler) http.Handler, rejected http.HandlerFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		type stateKey struct{}
+		type state struct {
+			w       http.ResponseWriter
+			reached bool
+		}
+
+		inner := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			s := r.Context().Value(stateKey{}).(*state)
+			s.reached = true
+			next.ServeHTTP(s.w, r)
+		})
+		throttled := throttle(inner)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s := &state{w: w}
+			ctx := context.WithValue(r.Context(), stateKey{}, s)
+
+			throttled.ServeHTTP(discardWriter{header: make(http.Header)}, r.WithContext(ctx))
+
+			if !s.reached {
+				httpRejected.WithLabelValues("throttle").Inc()
+				rejected(w, r)
+			}
+		})
+	}
+}
+
+// discardWriter - принимает ответ ThrottleBacklog, не отправляя его клиенту
+type discardWriter struct {
+	header http.Header
+}
+
+func (w discardWriter) Header() http.Header         { return w.header }
+func (w discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w discardWriter) WriteHeader(int)             {}
+
+// BotHandler - мидлвейр telebot, считающий обновления и ошибки обработчика
+func BotHandler(name string) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			botUpdates.WithLabelValues(name).Inc()
+			err := next(c)
+			if err != nil {
+				botErrors.WithLabelValues(name).Inc()
+			}
+			return err
+		}
+	}
+}
+
+// Transport - RoundTripper, измеряющий вызовы Telegram Bot API (токен в метки не попадает)
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		method := telegramMethod(req.URL.Path)
+		start := time.Now()
+
+		resp, err := base.RoundTrip(req)
+
+		telegramDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		switch {
+		case err != nil:
+			telegramRequests.WithLabelValues(method, "network_error").Inc()
+		case resp.StatusCode >= http.StatusBadRequest:
+			telegramRequests.WithLabelValues(method, strconv.Itoa(resp.StatusCode)).Inc()
+		default:
+			telegramRequests.WithLabelValues(method, "ok").Inc()
+		}
+
+		return resp, err
+	})
+}
+
+// telegramMethod - имя метода из пути /bot<token>/<method> или "file" для /file/bot<token>/...
+func telegramMethod(path string) string {
+	if strings.HasPrefix(path, "/file/") {
+		return "file"
+	}
+	if i := strings.LastIndex(path, "/"); i >= 0 && i < len(path)-1 {
+		return path[i+1:]
+	}
+	return "unknown"
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,54 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"taro-api/internal/lib/metrics"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+func TestObserveThrottle(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := metrics.ObserveThrottle(middleware.ThrottleBacklog(1, 0, time.Second),
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"code":"rate_limited"}`))
+		},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.Write([]byte("ok"))
+	}))
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+	<-entered
+
+	// единственный слот занят, очереди нет: запрос отклоняется ответом rejected
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusTooManyRequests || w.Body.String() != `{"code":"rate_limited"}` {
+		t.Errorf("rejected = %d %q, want 429 with the JSON body only", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	close(release)
+	<-done
+	if first.Code != http.StatusOK || first.Body.String() != "ok" {
+		t.Errorf("passed = %d %q, want 200 ok", first.Code, first.Body.String())
+	}
+}
@@ -0,0 +1,128 @@
+package practice
+
+import (
+	"slices"
+	"strings"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/tarot"
+	"unicode"
+)
+
+// passScore - с какой оценки толкование позиции считается верным
+const passScore = 50
+
+// stopWords - служебные слова, по которым ключевые слова не сравниваются
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "into": true,
+	"для": true, "или": true, "без": true, "над": true, "под": true, "при": true,
+}
+
+// Grade - оценка толкования одной позиции
+type Grade struct {
+	// Score - от 0 до 100: каждое из первых двух найденных ключевых слов дает половину
+	Score   int
+	Correct bool
+	// Matched - ключевые слова значения, найденные в толковании
+	Matched []string
+	// Keywords - все ключевые слова значения карты в выпавшем положении
+	Keywords []string
+	// Opposite - в толковании больше слов из значения карты в другом положении
+	Opposite bool
+}
+
+// GradeAnswer - оценивает толкование text карты d по ключевым словам ее значения на языке l.
+// Слова сравниваются по общему началу, чтобы засчитывались другие формы слова
+func GradeAnswer(l i18n.Lang, d tarot.Drawn, text string) Grade {
+	words := tokenize(text)
+
+	keywords := d.Keywords(l)
+	opposite := tarot.Drawn{Card: d.Card, Reversed: !d.Reversed}.Keywords(l)
+
+	g := Grade{Matched: []string{}, Keywords: keywords}
+	for _, kw := range keywords {
+		if mentions(words, kw) {
+			g.Matched = append(g.Matched, kw)
+		}
+	}
+
+	oppositeMatched := 0
+	for _, kw := range opposite {
+		if mentions(words, kw) {
+			oppositeMatched++
+		}
+	}
+	g.Opposite = oppositeMatched > len(g.Matched)
+
+	need := min(2, len(keywords))
+	if need > 0 {
+		g.Score = min(len(g.Matched), need) * 100 / need
+	}
+	g.Correct = g.Score >= passScore
+	return g
+}
+
+// mentions - упоминает ли текст ключевое слово: достаточно любого значимого слова из него
+func mentions(words []string, keyword string) bool {
+	for _, kw := range tokenize(keyword) {
+		for _, w := range words {
+			if similar(kw, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// similar - формы одного слова: общее начало не короче пяти букв либо не короче трех букв
+// и у обоих слов после него не больше двух букв окончания. Короткое слово в начале длинного
+// ("art" и "artist", "hope" и "hopeless") само по себе формой не считается
+func similar(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	shorter := min(len(ra), len(rb))
+
+	common := 0
+	for common < shorter && ra[common] == rb[common] {
+		common++
+	}
+	if common >= 5 {
+		return true
+	}
+	return common >= 3 && len(ra)-common <= 2 && len(rb)-common <= 2
+}
+
+// tokenize - значимые слова текста в нижнем регистре, "ё" приравнивается к "е"
+func tokenize(s string) []string {
+	s = strings.ReplaceAll(strings.ToLower(s), "ё", "е")
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) >= 3 && !stopWords[f] {
+			words = append(words, f)
+		}
+	}
+	return words
+}
+
+// Feedback - отзыв на толкование позиции на языке l: что найдено и чего не хватило
+func (g Grade) Feedback(l i18n.Lang) string {
+	missed := make([]string, 0, len(g.Keywords))
+	for _, kw := range g.Keywords {
+		if !slices.Contains(g.Matched, kw) {
+			missed = append(missed, kw)
+		}
+	}
+
+	switch {
+	case g.Opposite:
+		return i18n.T(l, i18n.PracticeFeedbackOpposite, strings.Join(g.Keywords, ", "))
+	case len(g.Matched) == 0:
+		return i18n.T(l, i18n.PracticeFeedbackMissed, strings.Join(g.Keywords, ", "))
+	case g.Score == 100 || len(missed) == 0:
+		return i18n.T(l, i18n.PracticeFeedbackGood, strings.Join(g.Matched, ", "))
+	default:
+		return i18n.T(l, i18n.PracticeFeedbackPartial, strings.Join(g.Matched, ", "), strings.Join(missed, ", "))
+	}
+}
@@ -0,0 +1,37 @@
+package practice
+
+import "testing"
+
+func TestSimilar(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"love", "love", true},
+		{"love", "loved", true},
+		{"change", "changes", true},
+		{"transform", "transformation", true},
+		{"сила", "силы", true},
+		{"новый", "новое", true},
+		{"перемены", "перемен", true},
+		// короткое слово в начале другого - не форма
+		{"art", "artist", false},
+		{"end", "endurance", false},
+		{"car", "career", false},
+		{"hope", "hopeless", false},
+		{"new", "news", true},
+		{"luck", "lucky", true},
+		{"peace", "pleasure", false},
+		{"начало", "начинания", false},
+		{"fear", "fool", false},
+	}
+
+	for _, tt := range tests {
+		if got := similar(tt.a, tt.b); got != tt.want {
+			t.Errorf("similar(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := similar(tt.b, tt.a); got != tt.want {
+			t.Errorf("similar(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
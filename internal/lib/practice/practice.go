@@ -0,0 +1,196 @@
+// Package practice - тренировка в чтении раскладов: случайный расклад с вопросом, оценка
+// толкования по ключевым словам карт и повторение карт, которые даются хуже (система Лейтнера)
+package practice
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/tarot"
+	"time"
+)
+
+// maxSpreadSize - в тренировке только короткие расклады, толковать десять позиций утомительно
+const maxSpreadSize = 3
+
+// intervals - через сколько повторять карту из ящика с этим номером. В нулевом ящике -
+// карты, истолкованные неверно: они повторяются в следующей тренировке
+var intervals = []time.Duration{
+	0,
+	24 * time.Hour,
+	3 * 24 * time.Hour,
+	7 * 24 * time.Hour,
+	14 * 24 * time.Hour,
+	30 * 24 * time.Hour,
+}
+
+// Question - вопрос к тренировочному раскладу
+type Question struct {
+	ID   string
+	Text tarot.Text
+}
+
+var questions = []Question{
+	{"career", tarot.Text{i18n.EN: "What should I know about my career right now?", i18n.RU: "Что мне важно знать о работе сейчас?"}},
+	{"love", tarot.Text{i18n.EN: "How will my love life develop?", i18n.RU: "Как будут развиваться мои отношения?"}},
+	{"money", tarot.Text{i18n.EN: "What affects my finances?", i18n.RU: "Что влияет на мои финансы?"}},
+	{"move", tarot.Text{i18n.EN: "Is it the right time to move?", i18n.RU: "Стоит ли сейчас переезжать?"}},
+	{"friend", tarot.Text{i18n.EN: "What is going on with my friendship?", i18n.RU: "Что происходит в моей дружбе?"}},
+	{"growth", tarot.Text{i18n.EN: "What helps me grow?", i18n.RU: "Что поможет мне расти?"}},
+	{"decision", tarot.Text{i18n.EN: "What should I consider before a big decision?", i18n.RU: "Что учесть перед важным решением?"}},
+	{"month", tarot.Text{i18n.EN: "What does the coming month hold?", i18n.RU: "Что ждет меня в ближайший месяц?"}},
+}
+
+// QuestionByID - вопрос по идентификатору
+func QuestionByID(id string) (Question, bool) {
+	for _, q := range questions {
+		if q.ID == id {
+			return q, true
+		}
+	}
+	return Question{}, false
+}
+
+// Progress - как пользователь толкует карту: попытки, верные ответы и когда ее повторить
+type Progress struct {
+	Card     string
+	Attempts int
+	Correct  int
+	// Box - ящик Лейтнера: верный ответ переносит карту в следующий, ошибка - в нулевой
+	Box   int
+	DueAt time.Time
+}
+
+// Accuracy - доля верных толкований, 0 - карту еще не толковали
+func (p Progress) Accuracy() float64 {
+	if p.Attempts == 0 {
+		return 0
+	}
+	return float64(p.Correct) / float64(p.Attempts)
+}
+
+// Schedule - ящик и срок повторения карты из ящика box после ответа
+func Schedule(box int, correct bool, now time.Time) (int, time.Time) {
+	if !correct {
+		return 0, now
+	}
+
+	box = min(box+1, len(intervals)-1)
+	return box, now.Add(intervals[box])
+}
+
+// Exercise - новое упражнение: расклад, вопрос и карты по позициям
+type Exercise struct {
+	Spread   tarot.Spread
+	Question Question
+	Cards    []tarot.Drawn
+}
+
+// Next - следующее упражнение. progress - успехи пользователя по картам; карты выбираются так:
+// сначала те, что пора повторить (с самым ранним сроком), затем те, что еще не толковались,
+// затем те, что скоро пора повторить
+func Next(progress []Progress, now time.Time) Exercise {
+	var short []tarot.Spread
+	for _, s := range tarot.Spreads() {
+		if s.Size() <= maxSpreadSize {
+			short = append(short, s)
+		}
+	}
+	spread := short[rand.IntN(len(short))]
+
+	return Exercise{
+		Spread:   spread,
+		Question: questions[rand.IntN(len(questions))],
+		Cards:    pick(progress, now, spread.Size()),
+	}
+}
+
+// pick - n карт для упражнения в порядке очереди повторения, см. Next
+func pick(progress []Progress, now time.Time, n int) []tarot.Drawn {
+	seen := make(map[string]bool, len(progress))
+	for _, p := range progress {
+		seen[p.Card] = true
+	}
+
+	var due, later []tarot.Card
+	for _, p := range sortByDue(progress) {
+		card, ok := tarot.ByID(p.Card)
+		if !ok {
+			continue
+		}
+		if p.DueAt.After(now) {
+			later = append(later, card)
+		} else {
+			due = append(due, card)
+		}
+	}
+
+	var fresh []tarot.Card
+	for _, i := range rand.Perm(len(tarot.Deck())) {
+		card := tarot.Deck()[i]
+		if !seen[card.ID] {
+			fresh = append(fresh, card)
+		}
+	}
+
+	queue := append(append(due, fresh...), later...)
+	drawn := make([]tarot.Drawn, 0, n)
+	for _, card := range queue[:min(n, len(queue))] {
+		drawn = append(drawn, tarot.Drawn{Card: card, Reversed: rand.IntN(2) == 1})
+	}
+
+	// позиции в раскладе не должны зависеть от очереди
+	rand.Shuffle(len(drawn), func(i, j int) { drawn[i], drawn[j] = drawn[j], drawn[i] })
+	return drawn
+}
+
+// sortByDue - копия progress по сроку повторения, при равном сроке - хуже истолкованные раньше
+func sortByDue(progress []Progress) []Progress {
+	sorted := append([]Progress(nil), progress...)
+	slices.SortStableFunc(sorted, func(a, b Progress) int {
+		if c := a.DueAt.Compare(b.DueAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Accuracy(), b.Accuracy())
+	})
+	return sorted
+}
+
+// Streak - серия дней подряд с тренировкой после тренировки в день today (YYYY-MM-DD).
+// lastDay и streak - последний день с тренировкой и серия на тот день
+func Streak(lastDay string, streak int, today string) int {
+	switch lastDay {
+	case today:
+		return max(streak, 1)
+	case yesterday(today):
+		return streak + 1
+	default:
+		return 1
+	}
+}
+
+// CurrentStreak - серия на день today: если вчера и сегодня тренировки не было, она прервалась
+func CurrentStreak(lastDay string, streak int, today string) int {
+	if lastDay == today || lastDay == yesterday(today) {
+		return streak
+	}
+	return 0
+}
+
+// Today - текущая дата в часовом поясе tz (пусто или неизвестный пояс - UTC)
+func Today(tz string, now time.Time) string {
+	now = now.UTC()
+	if loc, err := time.LoadLocation(tz); err == nil {
+		now = now.In(loc)
+	}
+	return now.Format(time.DateOnly)
+}
+
+func yesterday(day string) string {
+	t, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		return ""
+	}
+	return t.AddDate(0, 0, -1).Format(time.DateOnly)
+}
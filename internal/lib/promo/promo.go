@@ -0,0 +1,221 @@
+// Package promo - промокоды и подарочные коды: формат кодов, генерация и параметры выпуска
+package promo
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Типы награды
+const (
+	// RewardBalance - начисление на баланс
+	RewardBalance = "balance"
+	// RewardEntitlement - доступ к платной возможности (например, premium) на срок или навсегда
+	RewardEntitlement = "entitlement"
+)
+
+// EntitlementPremium - премиум-доступ
+const EntitlementPremium = "premium"
+
+const (
+	// MaxBatch - сколько кодов можно выпустить за раз
+	MaxBatch = 10000
+	// длина случайной части кода
+	randomLen = 8
+	// без похожих символов 0/O и 1/I/L, чтобы код было легко переписать с картинки
+	alphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+)
+
+var codeRe = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+// Options - параметры выпуска кодов
+type Options struct {
+	// Code - фиксированный код; если пусто - Count случайных кодов с префиксом Prefix
+	Code   string
+	Count  int
+	Prefix string
+
+	RewardType  string
+	Amount      int64
+	Entitlement string
+	// Days - срок доступа, 0 - навсегда
+	Days int
+
+	// MaxRedemptions - сколько раз код можно активировать всего, 0 - без ограничения
+	MaxRedemptions int
+	// PerUserLimit - сколько раз один пользователь может активировать код
+	PerUserLimit int
+	// ValidFrom, ValidUntil - окно действия, nil - без ограничения
+	ValidFrom  *time.Time
+	ValidUntil *time.Time
+}
+
+// Normalize - код в каноническом виде: без пробелов, в верхнем регистре
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ValidCode - допустим ли код (после Normalize)
+func ValidCode(code string) bool {
+	return codeRe.MatchString(code)
+}
+
+// Generate - n случайных кодов вида PREFIX-XXXXXXXX
+func Generate(n int, prefix string) ([]string, error) {
+	codes := make([]string, 0, n)
+	seen := make(map[string]struct{}, n)
+
+	for len(codes) < n {
+		var b strings.Builder
+		if prefix != "" {
+			b.WriteString(prefix)
+			b.WriteByte('-')
+		}
+		for i := 0; i < randomLen; i++ {
+			idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return nil, err
+			}
+			b.WriteByte(alphabet[idx.Int64()])
+		}
+
+		code := b.String()
+		if _, ok := seen[code]; ok {
+			continue
+		}
+		seen[code] = struct{}{}
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// ParseOptions - параметры выпуска из аргументов команды администратора вида key=value:
+//
+//	code=AUTUMN | count=100 [prefix=XMAS]
+//	balance=50 | entitlement=premium [days=30]
+//	[max=100] [per_user=1] [from=2026-10-01] [until=2026-10-31]
+//
+// Для случайных кодов по умолчанию max=1: каждый код - подарок одному человеку
+func ParseOptions(args []string) (Options, error) {
+	opts := Options{PerUserLimit: 1, MaxRedemptions: -1}
+
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || value == "" {
+			return opts, fmt.Errorf("argument %q must be key=value", arg)
+		}
+
+		var err error
+		switch strings.ToLower(key) {
+		case "code":
+			opts.Code = Normalize(value)
+		case "count":
+			opts.Count, err = strconv.Atoi(value)
+		case "prefix":
+			opts.Prefix = Normalize(value)
+		case "balance":
+			opts.RewardType = RewardBalance
+			opts.Amount, err = strconv.ParseInt(value, 10, 64)
+		case "entitlement":
+			opts.RewardType = RewardEntitlement
+			opts.Entitlement = strings.ToLower(value)
+		case "days":
+			opts.Days, err = strconv.Atoi(value)
+		case "max":
+			opts.MaxRedemptions, err = strconv.Atoi(value)
+		case "per_user":
+			opts.PerUserLimit, err = strconv.Atoi(value)
+		case "from":
+			opts.ValidFrom, err = parseDate(value)
+		case "until":
+			opts.ValidUntil, err = parseDate(value)
+			if opts.ValidUntil != nil {
+				// включительно: код действует весь последний день
+				end := opts.ValidUntil.Add(24*time.Hour - time.Nanosecond)
+				opts.ValidUntil = &end
+			}
+		default:
+			return opts, fmt.Errorf("unknown argument %q", key)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s: %q", key, value)
+		}
+	}
+
+	if opts.MaxRedemptions < 0 {
+		opts.MaxRedemptions = 0
+		if opts.Code == "" {
+			opts.MaxRedemptions = 1
+		}
+	}
+
+	return opts, opts.Validate()
+}
+
+// Validate - проверяет согласованность параметров
+func (o Options) Validate() error {
+	var errs []error
+
+	switch {
+	case o.Code != "" && o.Count != 0:
+		errs = append(errs, errors.New("use either code or count"))
+	case o.Code != "" && !ValidCode(o.Code):
+		errs = append(errs, errors.New("code must be 3-32 characters A-Z, 0-9, _ or -"))
+	case o.Code == "" && (o.Count < 1 || o.Count > MaxBatch):
+		errs = append(errs, fmt.Errorf("count must be between 1 and %d", MaxBatch))
+	}
+	if o.Prefix != "" && !ValidCode(o.Prefix) {
+		errs = append(errs, errors.New("prefix must be 3-32 characters A-Z, 0-9, _ or -"))
+	}
+
+	switch o.RewardType {
+	case RewardBalance:
+		if o.Amount <= 0 {
+			errs = append(errs, errors.New("balance must be positive"))
+		}
+	case RewardEntitlement:
+		if o.Entitlement != EntitlementPremium {
+			errs = append(errs, fmt.Errorf("unknown entitlement %q", o.Entitlement))
+		}
+		if o.Days < 0 {
+			errs = append(errs, errors.New("days must not be negative"))
+		}
+	default:
+		errs = append(errs, errors.New("reward is required: balance=N or entitlement=premium"))
+	}
+
+	if o.MaxRedemptions < 0 {
+		errs = append(errs, errors.New("max must not be negative"))
+	}
+	if o.PerUserLimit < 1 {
+		errs = append(errs, errors.New("per_user must be positive"))
+	}
+	if o.ValidFrom != nil && o.ValidUntil != nil && !o.ValidUntil.After(*o.ValidFrom) {
+		errs = append(errs, errors.New("until must be after from"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Codes - коды для выпуска: фиксированный или сгенерированные
+func (o Options) Codes() ([]string, error) {
+	if o.Code != "" {
+		return []string{o.Code}, nil
+	}
+	return Generate(o.Count, o.Prefix)
+}
+
+func parseDate(value string) (*time.Time, error) {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
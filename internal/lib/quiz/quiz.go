@@ -0,0 +1,238 @@
+// Package quiz - карточки для заучивания значений карт: вопросы с вариантами ответа и со
+// свободным ответом, расписание повторений по алгоритму SM-2 и достижения за выученные карты
+package quiz
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/practice"
+	"taro-api/internal/lib/tarot"
+	"time"
+	"unicode"
+)
+
+// Kind - вид вопроса
+type Kind string
+
+// Виды вопросов
+const (
+	// KindCardMeaning - по карте назвать ее значение
+	KindCardMeaning Kind = "card_meaning"
+	// KindMeaningCard - по значению назвать карту
+	KindMeaningCard Kind = "meaning_card"
+	// KindOrientation - по значению определить, прямая карта или перевернутая
+	KindOrientation Kind = "orientation"
+)
+
+// Kinds - все виды вопросов; карта выучена, когда выучены ее карточки всех видов
+var Kinds = []Kind{KindCardMeaning, KindMeaningCard, KindOrientation}
+
+// Format - как пользователь отвечает на вопрос
+type Format string
+
+// Форматы ответа
+const (
+	FormatChoice Format = "choice"
+	FormatText   Format = "text"
+)
+
+// choiceOptions - вариантов ответа в вопросе с выбором
+const choiceOptions = 4
+
+// AchievementMajorArcana - достижение за выученные старшие арканы
+const AchievementMajorArcana = "major_arcana_master"
+
+// Question - вопрос по карте. Варианты ответа - карты: в вопросе по карте показываются их
+// значения, по значению - их названия, о положении - прямое и перевернутое положение карты
+type Question struct {
+	Kind   Kind
+	Format Format
+	Card   tarot.Drawn
+	// Options и Answer - варианты и номер верного, только для FormatChoice
+	Options []tarot.Drawn
+	Answer  int
+}
+
+// Result - проверка ответа
+type Result struct {
+	Correct bool
+	// Quality - оценка по шкале SM-2 для Review
+	Quality int
+}
+
+// Normalize - допустимые виды и формат вопроса: пустые kinds - любые виды. На вопрос
+// о положении свободно не ответить, поэтому в FormatText он не задается, а если допустим
+// только он - задается с выбором
+func Normalize(kinds []Kind, format Format) ([]Kind, Format) {
+	if len(kinds) == 0 {
+		kinds = Kinds
+	}
+	if format != FormatText {
+		return kinds, format
+	}
+
+	text := slices.DeleteFunc(slices.Clone(kinds), func(k Kind) bool { return k == KindOrientation })
+	if len(text) == 0 {
+		return kinds, FormatChoice
+	}
+	return text, format
+}
+
+// Next - следующий вопрос: сначала карточка, которую пора повторить, затем новая карточка
+// в порядке колоды (старшие арканы первыми), затем та, которую пора повторить раньше других.
+// kinds и format - см. Normalize
+func Next(items []Item, kinds []Kind, format Format, now time.Time) Question {
+	kinds, format = Normalize(kinds, format)
+
+	card, kind := schedule(items, kinds, now)
+	return newQuestion(card, kind, format)
+}
+
+// schedule - карта и вид следующей карточки, см. Next
+func schedule(items []Item, kinds []Kind, now time.Time) (tarot.Card, Kind) {
+	seen := make(map[string][]Kind, len(items))
+	var allowed []Item
+	for _, it := range items {
+		if slices.Contains(kinds, it.Kind) {
+			seen[it.Card] = append(seen[it.Card], it.Kind)
+			allowed = append(allowed, it)
+		}
+	}
+	slices.SortStableFunc(allowed, func(a, b Item) int { return a.DueAt.Compare(b.DueAt) })
+
+	if len(allowed) > 0 && !allowed[0].DueAt.After(now) {
+		if card, ok := tarot.ByID(allowed[0].Card); ok {
+			return card, allowed[0].Kind
+		}
+	}
+
+	for _, card := range tarot.Deck() {
+		fresh := slices.DeleteFunc(slices.Clone(kinds), func(k Kind) bool {
+			return slices.Contains(seen[card.ID], k)
+		})
+		if len(fresh) > 0 {
+			return card, fresh[rand.IntN(len(fresh))]
+		}
+	}
+
+	for _, it := range allowed {
+		if card, ok := tarot.ByID(it.Card); ok {
+			return card, it.Kind
+		}
+	}
+	return tarot.Deck()[0], kinds[0]
+}
+
+func newQuestion(card tarot.Card, kind Kind, format Format) Question {
+	q := Question{
+		Kind:   kind,
+		Format: format,
+		Card:   tarot.Drawn{Card: card, Reversed: rand.IntN(2) == 1},
+	}
+	if format != FormatChoice {
+		return q
+	}
+
+	if kind == KindOrientation {
+		q.Options = []tarot.Drawn{{Card: card}, {Card: card, Reversed: true}}
+		if q.Card.Reversed {
+			q.Answer = 1
+		}
+		return q
+	}
+
+	q.Options = []tarot.Drawn{q.Card}
+	for _, c := range distractors(card, choiceOptions-1) {
+		q.Options = append(q.Options, tarot.Drawn{Card: c, Reversed: q.Card.Reversed})
+	}
+	rand.Shuffle(len(q.Options), func(i, j int) { q.Options[i], q.Options[j] = q.Options[j], q.Options[i] })
+	q.Answer = slices.IndexFunc(q.Options, func(d tarot.Drawn) bool { return d.Card.ID == card.ID })
+	return q
+}
+
+// distractors - n других карт того же аркана, чтобы неверные варианты не отличались с первого взгляда
+func distractors(card tarot.Card, n int) []tarot.Card {
+	deck := tarot.Deck()
+	cards := make([]tarot.Card, 0, n)
+	for _, i := range rand.Perm(len(deck)) {
+		if len(cards) == n {
+			break
+		}
+		if c := deck[i]; c.ID != card.ID && c.Arcana == card.Arcana {
+			cards = append(cards, c)
+		}
+	}
+	return cards
+}
+
+// Check - проверяет ответ: option - номер выбранного варианта для FormatChoice, text -
+// свободный ответ на языке l. Значение карты оценивается по ключевым словам, как в практике
+// чтения раскладов; название карты принимается на любом языке
+func Check(q Question, l i18n.Lang, option int, text string) Result {
+	if q.Format == FormatChoice {
+		if option == q.Answer {
+			return Result{Correct: true, Quality: QualityCorrect}
+		}
+		return Result{Quality: QualityWrong}
+	}
+
+	switch q.Kind {
+	case KindCardMeaning:
+		g := practice.GradeAnswer(l, q.Card, text)
+		switch {
+		case g.Score == 100:
+			return Result{Correct: true, Quality: QualityEasy}
+		case g.Correct:
+			return Result{Correct: true, Quality: QualityHard}
+		}
+	case KindMeaningCard:
+		if NamesCard(q.Card.Card, text) {
+			return Result{Correct: true, Quality: QualityCorrect}
+		}
+	}
+	return Result{Quality: QualityWrong}
+}
+
+// NamesCard - text - название карты на любом языке или ее ID, без учета регистра и знаков
+func NamesCard(card tarot.Card, text string) bool {
+	answer := normalize(text)
+	if answer == "" {
+		return false
+	}
+	if answer == normalize(card.ID) {
+		return true
+	}
+	for _, name := range card.Name {
+		if answer == normalize(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// MajorArcanaMastered - выучены ли все старшие арканы: для каждого выучены карточки всех видов
+func MajorArcanaMastered(items []Item) bool {
+	mastered := make(map[string]int, len(items))
+	for _, it := range items {
+		if it.Mastered() {
+			mastered[it.Card]++
+		}
+	}
+
+	for _, card := range tarot.Deck() {
+		if card.Arcana == tarot.Major && mastered[card.ID] < len(Kinds) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize - только буквы и цифры в нижнем регистре, "ё" приравнивается к "е"
+func normalize(s string) string {
+	s = strings.ReplaceAll(strings.ToLower(s), "ё", "е")
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
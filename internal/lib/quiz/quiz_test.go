@@ -0,0 +1,131 @@
+package quiz_test
+
+import (
+	"slices"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/quiz"
+	"taro-api/internal/lib/tarot"
+	"testing"
+	"time"
+)
+
+func card(t *testing.T, id string) tarot.Card {
+	t.Helper()
+	c, ok := tarot.ByID(id)
+	if !ok {
+		t.Fatalf("card %s not found", id)
+	}
+	return c
+}
+
+func TestCheck(t *testing.T) {
+	fool := tarot.Drawn{Card: card(t, "fool")}
+
+	choice := quiz.Question{Kind: quiz.KindCardMeaning, Format: quiz.FormatChoice, Card: fool,
+		Options: []tarot.Drawn{{Card: card(t, "magician")}, fool}, Answer: 1}
+	meaning := quiz.Question{Kind: quiz.KindCardMeaning, Format: quiz.FormatText, Card: fool}
+	name := quiz.Question{Kind: quiz.KindMeaningCard, Format: quiz.FormatText, Card: fool}
+
+	tests := []struct {
+		name   string
+		q      quiz.Question
+		l      i18n.Lang
+		option int
+		text   string
+		want   quiz.Result
+	}{
+		{name: "right option", q: choice, option: 1, want: quiz.Result{Correct: true, Quality: quiz.QualityCorrect}},
+		{name: "wrong option", q: choice, option: 0, want: quiz.Result{Quality: quiz.QualityWrong}},
+		{name: "option out of range", q: choice, option: 7, want: quiz.Result{Quality: quiz.QualityWrong}},
+		{name: "full meaning", q: meaning, l: i18n.EN, text: "New beginnings and freedom",
+			want: quiz.Result{Correct: true, Quality: quiz.QualityEasy}},
+		{name: "partial meaning", q: meaning, l: i18n.EN, text: "spontaneity",
+			want: quiz.Result{Correct: true, Quality: quiz.QualityHard}},
+		{name: "wrong meaning", q: meaning, l: i18n.EN, text: "willpower", want: quiz.Result{Quality: quiz.QualityWrong}},
+		{name: "name in english", q: name, l: i18n.RU, text: "the fool!", want: quiz.Result{Correct: true, Quality: quiz.QualityCorrect}},
+		{name: "name in russian", q: name, l: i18n.EN, text: "  ШУТ ", want: quiz.Result{Correct: true, Quality: quiz.QualityCorrect}},
+		{name: "card id", q: name, l: i18n.EN, text: "fool", want: quiz.Result{Correct: true, Quality: quiz.QualityCorrect}},
+		{name: "other card", q: name, l: i18n.EN, text: "The Magician", want: quiz.Result{Quality: quiz.QualityWrong}},
+		{name: "empty answer", q: name, l: i18n.EN, text: "", want: quiz.Result{Quality: quiz.QualityWrong}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quiz.Check(tt.q, tt.l, tt.option, tt.text); got != tt.want {
+				t.Errorf("Check = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	now := time.Now()
+
+	// новый пользователь начинает со старших арканов по порядку
+	q := quiz.Next(nil, nil, quiz.FormatChoice, now)
+	if q.Card.Card.ID != "fool" {
+		t.Errorf("first question is about %s, want fool", q.Card.Card.ID)
+	}
+	if len(q.Options) == 0 || q.Options[q.Answer].Card.ID != "fool" {
+		t.Errorf("options = %v, answer %d", q.Options, q.Answer)
+	}
+	if q.Kind != quiz.KindOrientation && len(q.Options) != 4 {
+		t.Errorf("%s question has %d options, want 4", q.Kind, len(q.Options))
+	}
+	for _, o := range q.Options {
+		if o.Card.Arcana != tarot.Major {
+			t.Errorf("option %s is not a major arcana", o.Card.ID)
+		}
+	}
+
+	// вопрос о положении задается с вариантами
+	q = quiz.Next(nil, []quiz.Kind{quiz.KindOrientation}, quiz.FormatText, now)
+	if q.Format != quiz.FormatChoice || len(q.Options) != 2 || q.Options[q.Answer].Reversed != q.Card.Reversed {
+		t.Errorf("orientation question = %+v", q)
+	}
+
+	// карточка, которую пора повторить, идет раньше новых
+	items := []quiz.Item{
+		{Card: "fool", Kind: quiz.KindCardMeaning, DueAt: now.Add(time.Hour)},
+		{Card: "star", Kind: quiz.KindMeaningCard, DueAt: now.Add(-time.Hour)},
+	}
+	q = quiz.Next(items, nil, quiz.FormatText, now)
+	if q.Card.Card.ID != "star" || q.Kind != quiz.KindMeaningCard || q.Options != nil {
+		t.Errorf("question = %s %s %s, want due star meaning_card text", q.Card.Card.ID, q.Kind, q.Format)
+	}
+
+	// без карточек к повторению - новая: у fool остались другие виды
+	q = quiz.Next(items[:1], nil, quiz.FormatText, now)
+	if q.Card.Card.ID != "fool" || q.Kind != quiz.KindMeaningCard {
+		t.Errorf("question = %s %s, want fool meaning_card", q.Card.Card.ID, q.Kind)
+	}
+}
+
+func TestMajorArcanaMastered(t *testing.T) {
+	var items []quiz.Item
+	for _, c := range tarot.Deck() {
+		if c.Arcana != tarot.Major {
+			continue
+		}
+		for _, k := range quiz.Kinds {
+			items = append(items, quiz.Item{Card: c.ID, Kind: k, Repetitions: 3})
+		}
+	}
+	if !quiz.MajorArcanaMastered(items) {
+		t.Error("all major arcana are mastered")
+	}
+
+	// одной карточке не хватает верного ответа
+	missing := slices.Clone(items)
+	missing[len(missing)-1].Repetitions = 2
+	if quiz.MajorArcanaMastered(missing) {
+		t.Error("mastered with an unlearned card")
+	}
+
+	if quiz.MajorArcanaMastered(items[:len(items)-1]) {
+		t.Error("mastered without one kind of a card")
+	}
+	if quiz.MajorArcanaMastered(nil) {
+		t.Error("mastered without items")
+	}
+}
@@ -0,0 +1,75 @@
+package quiz
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// initialEase - коэффициент легкости новой карточки
+	initialEase = 2.5
+	// minEase - ниже коэффициент не опускается, иначе трудная карточка повторяется бесконечно часто
+	minEase = 1.3
+	// relearnDelay - через сколько повторить карточку после ошибки, чтобы она вернулась
+	// в этой же сессии, но не сразу следующим вопросом
+	relearnDelay = 10 * time.Minute
+	// masteredRepetitions - сколько верных ответов подряд нужно, чтобы карточка считалась выученной
+	masteredRepetitions = 3
+)
+
+// Оценки ответа по шкале SM-2: 0-2 - ошибка, 3-5 - верный ответ с трудом, уверенно, легко
+const (
+	QualityWrong   = 1
+	QualityHard    = 3
+	QualityCorrect = 4
+	QualityEasy    = 5
+)
+
+// Item - карточка пользователя: вопросы одного вида по одной карте
+type Item struct {
+	Card string
+	Kind Kind
+	// Repetitions - верных ответов подряд, ошибка обнуляет
+	Repetitions int
+	// Interval - через сколько дней повторять после последнего верного ответа
+	Interval int
+	Ease     float64
+	DueAt    time.Time
+}
+
+// Mastered - карточка выучена: на нее ответили верно несколько раз подряд
+func (it Item) Mastered() bool {
+	return it.Repetitions >= masteredRepetitions
+}
+
+// Review - карточка после ответа с оценкой quality (0-5) по алгоритму SM-2: верный ответ
+// увеличивает интервал (1 день, 6 дней, затем умножение на коэффициент легкости),
+// ошибка возвращает карточку в начало. Коэффициент меняется после каждого ответа
+func Review(it Item, quality int, now time.Time) Item {
+	if it.Ease == 0 {
+		it.Ease = initialEase
+	}
+	quality = min(max(quality, 0), 5)
+
+	miss := float64(5 - quality)
+	it.Ease = max(it.Ease+0.1-miss*(0.08+miss*0.02), minEase)
+
+	if quality < QualityHard {
+		it.Repetitions = 0
+		it.Interval = 0
+		it.DueAt = now.Add(relearnDelay)
+		return it
+	}
+
+	switch it.Repetitions {
+	case 0:
+		it.Interval = 1
+	case 1:
+		it.Interval = 6
+	default:
+		it.Interval = int(math.Round(float64(it.Interval) * it.Ease))
+	}
+	it.Repetitions++
+	it.DueAt = now.AddDate(0, 0, it.Interval)
+	return it
+}
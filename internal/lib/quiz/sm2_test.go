@@ -0,0 +1,99 @@
+package quiz_test
+
+import (
+	"math"
+	"taro-api/internal/lib/quiz"
+	"testing"
+	"time"
+)
+
+func TestReview(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		qualities []int
+		want      quiz.Item
+	}{
+		{
+			name:      "first answer",
+			qualities: []int{quiz.QualityCorrect},
+			want:      quiz.Item{Repetitions: 1, Interval: 1, Ease: 2.5},
+		},
+		{
+			name:      "second answer",
+			qualities: []int{quiz.QualityCorrect, quiz.QualityCorrect},
+			want:      quiz.Item{Repetitions: 2, Interval: 6, Ease: 2.5},
+		},
+		{
+			name:      "interval grows by ease",
+			qualities: []int{quiz.QualityCorrect, quiz.QualityCorrect, quiz.QualityCorrect, quiz.QualityCorrect},
+			want:      quiz.Item{Repetitions: 4, Interval: 38, Ease: 2.5},
+		},
+		{
+			name:      "easy raises ease",
+			qualities: []int{quiz.QualityEasy, quiz.QualityEasy, quiz.QualityEasy},
+			want:      quiz.Item{Repetitions: 3, Interval: 17, Ease: 2.8},
+		},
+		{
+			name:      "hard lowers ease",
+			qualities: []int{quiz.QualityHard, quiz.QualityHard, quiz.QualityHard},
+			want:      quiz.Item{Repetitions: 3, Interval: 12, Ease: 2.08},
+		},
+		{
+			name:      "wrong resets",
+			qualities: []int{quiz.QualityCorrect, quiz.QualityCorrect, quiz.QualityWrong},
+			want:      quiz.Item{Repetitions: 0, Interval: 0, Ease: 1.96},
+		},
+		{
+			name:      "ease floor",
+			qualities: []int{quiz.QualityWrong, quiz.QualityWrong, quiz.QualityWrong, 0, 0},
+			want:      quiz.Item{Ease: 1.3},
+		},
+		{
+			name:      "quality out of range",
+			qualities: []int{-3, 9},
+			want:      quiz.Item{Repetitions: 1, Interval: 1, Ease: 1.8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := quiz.Item{Card: "fool", Kind: quiz.KindCardMeaning}
+			for _, q := range tt.qualities {
+				it = quiz.Review(it, q, now)
+			}
+
+			if it.Repetitions != tt.want.Repetitions || it.Interval != tt.want.Interval ||
+				math.Abs(it.Ease-tt.want.Ease) > 1e-9 {
+				t.Errorf("item = %d repetitions, %d days, ease %.2f; want %d, %d, %.2f",
+					it.Repetitions, it.Interval, it.Ease, tt.want.Repetitions, tt.want.Interval, tt.want.Ease)
+			}
+
+			wantDue := now.AddDate(0, 0, tt.want.Interval)
+			if tt.want.Interval == 0 {
+				// после ошибки карточка возвращается в этой же сессии
+				wantDue = now.Add(10 * time.Minute)
+			}
+			if !it.DueAt.Equal(wantDue) {
+				t.Errorf("due at %s, want %s", it.DueAt, wantDue)
+			}
+		})
+	}
+}
+
+func TestMastered(t *testing.T) {
+	it := quiz.Item{}
+	for i := 0; i < 3; i++ {
+		if it.Mastered() {
+			t.Fatalf("mastered after %d correct answers", i)
+		}
+		it = quiz.Review(it, quiz.QualityCorrect, time.Now())
+	}
+	if !it.Mastered() {
+		t.Error("not mastered after 3 correct answers")
+	}
+	if quiz.Review(it, quiz.QualityWrong, time.Now()).Mastered() {
+		t.Error("mastered after a wrong answer")
+	}
+}
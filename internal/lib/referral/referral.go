@@ -0,0 +1,29 @@
+// Package referral - реферальные коды: код - это Telegram ID пригласившего с префиксом
+package referral
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// prefix - префикс кода в ссылках t.me/<bot>?startapp=ref_<id>
+const prefix = "ref_"
+
+// ErrInvalidCode - код не содержит Telegram ID
+var ErrInvalidCode = errors.New("invalid referral code")
+
+// Code - реферальный код пользователя
+func Code(telegramID int64) string {
+	return prefix + strconv.FormatInt(telegramID, 10)
+}
+
+// ParseCode - Telegram ID пригласившего из кода; принимается и код без префикса,
+// как в параметре referralID старых версий Mini App
+func ParseCode(code string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(code), prefix), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCode
+	}
+	return id, nil
+}
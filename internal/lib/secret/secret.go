@@ -0,0 +1,26 @@
+// Package secret - секреты, которые можно заменить без перезапуска процесса
+package secret
+
+import "sync/atomic"
+
+// Token - текущее значение токена; читается на каждый запрос, меняется при ротации
+type Token struct {
+	v atomic.Pointer[string]
+}
+
+// NewToken - создает токен с начальным значением
+func NewToken(value string) *Token {
+	t := &Token{}
+	t.Set(value)
+	return t
+}
+
+// Get - текущее значение токена
+func (t *Token) Get() string {
+	return *t.v.Load()
+}
+
+// Set - заменяет значение токена
+func (t *Token) Set(value string) {
+	t.v.Store(&value)
+}
@@ -0,0 +1,136 @@
+// Package session - выпуск и проверка токенов доступа (JWT HS256) и refresh токенов
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Возможные ошибки
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token is expired")
+)
+
+const (
+	refreshTokenSize = 32
+	issuer           = "taroki-api"
+)
+
+// jwtHeader - заголовок всегда одинаковый, поэтому кодируется один раз
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims - содержимое токена доступа
+type Claims struct {
+	Issuer       string `json:"iss"`
+	Subject      int64  `json:"sub"`
+	SessionID    string `json:"sid"`
+	IssuedAt     int64  `json:"iat"`
+	ExpiresAt    int64  `json:"exp"`
+	FirstName    string `json:"first_name,omitempty"`
+	LastName     string `json:"last_name,omitempty"`
+	Username     string `json:"username,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"`
+	IsPremium    bool   `json:"is_premium,omitempty"`
+	// Bot - имя бота, через который пользователь вошел
+	Bot string `json:"bot,omitempty"`
+}
+
+// Manager - выпускает и проверяет токены
+type Manager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewManager - конструктор менеджера токенов
+func NewManager(secret []byte, accessTTL, refreshTTL time.Duration) *Manager {
+	return &Manager{
+		secret:     secret,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// AccessTTL - время жизни токена доступа
+func (m *Manager) AccessTTL() time.Duration {
+	return m.accessTTL
+}
+
+// RefreshExpiresAt - время истечения refresh токена, выпущенного сейчас
+func (m *Manager) RefreshExpiresAt() time.Time {
+	return time.Now().Add(m.refreshTTL)
+}
+
+// IssueAccess - подписывает токен доступа, заполняя служебные поля
+func (m *Manager) IssueAccess(claims Claims) (string, error) {
+	now := time.Now()
+	claims.Issuer = issuer
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(m.accessTTL).Unix()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + m.sign(unsigned), nil
+}
+
+// ParseAccess - проверяет подпись и срок действия токена доступа
+func (m *Manager) ParseAccess(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(m.sign(parts[0]+"."+parts[1])), []byte(parts[2])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Issuer != issuer || claims.Subject == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+
+	return &claims, nil
+}
+
+func (m *Manager) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NewRefreshToken - генерирует refresh токен и его хэш для хранения в базе
+func NewRefreshToken() (token, hash string, err error) {
+	buf := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken - хэш refresh токена (в базе хранится только он)
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,108 @@
+package session_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"taro-api/internal/lib/session"
+	"testing"
+	"time"
+)
+
+var secret = []byte(strings.Repeat("s", 32))
+
+func TestIssueParseAccess(t *testing.T) {
+	m := session.NewManager(secret, time.Minute, time.Hour)
+
+	token, err := m.IssueAccess(session.Claims{Subject: 42, SessionID: "sid", Username: "user", Bot: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := m.ParseAccess(token)
+	if err != nil {
+		t.Fatalf("ParseAccess: %v", err)
+	}
+	if claims.Subject != 42 || claims.SessionID != "sid" || claims.Username != "user" || claims.Bot != "main" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if claims.ExpiresAt-claims.IssuedAt != 60 {
+		t.Errorf("token lives %d s, want 60", claims.ExpiresAt-claims.IssuedAt)
+	}
+}
+
+func TestParseAccessInvalid(t *testing.T) {
+	m := session.NewManager(secret, time.Minute, time.Hour)
+
+	token, err := m.IssueAccess(session.Claims{Subject: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+
+	other, _ := session.NewManager([]byte(strings.Repeat("o", 32)), time.Minute, time.Hour).
+		IssueAccess(session.Claims{Subject: 42})
+
+	// подписанный тем же секретом, но с другим заголовком или содержимым
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	sign := func(unsigned string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(unsigned))
+		return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	payload := encode(`{"iss":"taroki-api","sub":42,"exp":` + "9999999999" + `}`)
+
+	tests := map[string]string{
+		"empty":             "",
+		"two parts":         parts[0] + "." + parts[1],
+		"tampered payload":  parts[0] + "." + encode(`{"iss":"taroki-api","sub":1,"exp":9999999999}`) + "." + parts[2],
+		"tampered sig":      parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2])),
+		"wrong secret":      other,
+		"alg none":          encode(`{"alg":"none","typ":"JWT"}`) + "." + payload + ".",
+		"alg none signed":   sign(encode(`{"alg":"none","typ":"JWT"}`) + "." + payload),
+		"alg HS512":         sign(encode(`{"alg":"HS512","typ":"JWT"}`) + "." + payload),
+		"other issuer":      sign(parts[0] + "." + encode(`{"iss":"other","sub":42,"exp":9999999999}`)),
+		"no subject":        sign(parts[0] + "." + encode(`{"iss":"taroki-api","exp":9999999999}`)),
+		"payload not json":  sign(parts[0] + "." + encode(`not json`)),
+		"payload not b64":   sign(parts[0] + ".!!!"),
+		"refresh not token": "dGVzdA",
+	}
+	for name, token := range tests {
+		if _, err := m.ParseAccess(token); !errors.Is(err, session.ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestParseAccessExpired(t *testing.T) {
+	m := session.NewManager(secret, -time.Second, time.Hour)
+
+	token, err := m.IssueAccess(session.Claims{Subject: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.ParseAccess(token); !errors.Is(err, session.ErrExpiredToken) {
+		t.Errorf("err = %v, want ErrExpiredToken", err)
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	token, hash, err := session.NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != session.HashRefreshToken(token) || hash == token {
+		t.Errorf("hash %q does not match token %q", hash, token)
+	}
+
+	other, _, err := session.NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == token {
+		t.Error("refresh tokens repeat")
+	}
+}
@@ -0,0 +1,234 @@
+package sharecard
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // изображения карт в каталоге могут быть в JPEG
+	"io/fs"
+	"math"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/tarot"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/vector"
+)
+
+// cardImageExts - расширения изображений карт в каталоге, в порядке поиска
+var cardImageExts = []string{".png", ".jpg"}
+
+// cardFace - лицевая сторона карты размером w×h: изображение из каталога,
+// а если его нет - нарисованная карта с номером, знаком масти и названием
+func (rr *Renderer) cardFace(l i18n.Lang, card tarot.Card, w, h int) (*image.RGBA, error) {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	src, err := rr.cardImage(card.ID)
+	if err != nil {
+		return nil, err
+	}
+	if src != nil {
+		xdraw.CatmullRom.Scale(img, img.Bounds(), src, src.Bounds(), draw.Src, nil)
+		return img, nil
+	}
+
+	drawCard(img, l, card)
+	return img, nil
+}
+
+// cardImage - изображение карты <id>.png или <id>.jpg из каталога; nil - каталог
+// не задан или в нем нет этой карты
+func (rr *Renderer) cardImage(id string) (image.Image, error) {
+	if rr.images == nil {
+		return nil, nil
+	}
+
+	for _, ext := range cardImageExts {
+		f, err := rr.images.Open(id + ext)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("card %s: %w", id, err)
+		}
+
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("card %s: %w", id, err)
+		}
+		return img, nil
+	}
+
+	return nil, nil
+}
+
+// drawCard - рисует карту: двойная рамка, римский номер, знак аркана или масти, название
+func drawCard(dst *image.RGBA, l i18n.Lang, card tarot.Card) {
+	b := dst.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	draw.Draw(dst, b, image.NewUniform(cardColor), image.Point{}, draw.Src)
+	border := max(w/60, 2)
+	strokeRect(dst, b, border, gold)
+	inset := w / 14
+	strokeRect(dst, b.Inset(inset), max(border/2, 1), gold)
+
+	// у фигур младших арканов номера нет, их название и так на карте
+	if card.Arcana == tarot.Major || card.Number <= 10 {
+		size := float64(w) / 7
+		drawText(dst, face(bold, size), gold, w/2, inset+int(size*1.4), alignCenter, roman(card.Number))
+	}
+
+	drawEmblem(dst, card, float64(w)/2, float64(h)*0.45, float64(w)*0.26)
+
+	size := float64(w) / 9
+	lineHeight := int(size * 1.2)
+	f := face(regular, size)
+	lines := wrap(f, card.Name.In(l), w-2*inset-2*border-4, 2)
+	y := h - inset - int(size*0.8) - (len(lines)-1)*lineHeight
+	for _, line := range lines {
+		drawText(dst, f, textColor, w/2, y, alignCenter, line)
+		y += lineHeight
+	}
+}
+
+// drawEmblem - знак в центре карты: звезда у старших арканов, у младших - жезл, кубок,
+// меч или пентакль. cx, cy - центр знака, s - его половинный размер
+func drawEmblem(dst *image.RGBA, card tarot.Card, cx, cy, s float64) {
+	b := dst.Bounds()
+	z := vector.NewRasterizer(b.Dx(), b.Dy())
+
+	switch card.Suit {
+	case tarot.Wands:
+		polygon(z, [][2]float64{
+			{cx - 0.5*s, cy + s}, {cx - 0.38*s, cy + 1.08*s},
+			{cx + 0.5*s, cy - 0.6*s}, {cx + 0.38*s, cy - 0.68*s},
+		})
+		circle(z, cx+0.5*s, cy-0.72*s, 0.16*s, false)
+	case tarot.Cups:
+		bowl := make([][2]float64, 0, 17)
+		for i := 0; i <= 16; i++ {
+			a := math.Pi * float64(i) / 16
+			bowl = append(bowl, [2]float64{cx + 0.7*s*math.Cos(a), cy - 0.35*s + 0.7*s*math.Sin(a)})
+		}
+		polygon(z, bowl)
+		rect(z, cx-0.07*s, cy+0.3*s, cx+0.07*s, cy+0.8*s)
+		rect(z, cx-0.4*s, cy+0.8*s, cx+0.4*s, cy+0.92*s)
+	case tarot.Swords:
+		polygon(z, [][2]float64{
+			{cx, cy - s}, {cx + 0.12*s, cy - 0.75*s}, {cx + 0.12*s, cy + 0.45*s},
+			{cx - 0.12*s, cy + 0.45*s}, {cx - 0.12*s, cy - 0.75*s},
+		})
+		rect(z, cx-0.5*s, cy+0.45*s, cx+0.5*s, cy+0.57*s)
+		rect(z, cx-0.07*s, cy+0.57*s, cx+0.07*s, cy+0.95*s)
+	case tarot.Pentacles:
+		// кольцо: внутренний круг обходится в обратную сторону и вычитается
+		circle(z, cx, cy, 0.8*s, false)
+		circle(z, cx, cy, 0.68*s, true)
+		polygon(z, star(cx, cy, 5, 0.62*s, 0.24*s))
+	default:
+		polygon(z, star(cx, cy, 8, s, 0.42*s))
+	}
+
+	z.Draw(dst, b, image.NewUniform(gold), image.Point{})
+}
+
+// star - вершины звезды с n лучами: внешний радиус outer, внутренний inner, луч вверх
+func star(cx, cy float64, n int, outer, inner float64) [][2]float64 {
+	points := make([][2]float64, 0, 2*n)
+	for i := 0; i < 2*n; i++ {
+		r := outer
+		if i%2 == 1 {
+			r = inner
+		}
+		a := math.Pi*float64(i)/float64(n) - math.Pi/2
+		points = append(points, [2]float64{cx + r*math.Cos(a), cy + r*math.Sin(a)})
+	}
+	return points
+}
+
+func polygon(z *vector.Rasterizer, points [][2]float64) {
+	z.MoveTo(float32(points[0][0]), float32(points[0][1]))
+	for _, p := range points[1:] {
+		z.LineTo(float32(p[0]), float32(p[1]))
+	}
+	z.ClosePath()
+}
+
+func rect(z *vector.Rasterizer, x0, y0, x1, y1 float64) {
+	polygon(z, [][2]float64{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}})
+}
+
+// circle - круг из отрезков; reverse - обход против часовой стрелки, чтобы вырезать
+// круг из фигуры, нарисованной по часовой
+func circle(z *vector.Rasterizer, cx, cy, r float64, reverse bool) {
+	const segments = 48
+
+	points := make([][2]float64, 0, segments)
+	for i := 0; i < segments; i++ {
+		a := 2 * math.Pi * float64(i) / segments
+		if reverse {
+			a = -a
+		}
+		points = append(points, [2]float64{cx + r*math.Cos(a), cy + r*math.Sin(a)})
+	}
+	polygon(z, points)
+}
+
+// strokeRect - рамка толщиной width по внутреннему краю r
+func strokeRect(dst draw.Image, r image.Rectangle, width int, c color.Color) {
+	src := image.NewUniform(c)
+	draw.Draw(dst, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+width), src, image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(r.Min.X, r.Max.Y-width, r.Max.X, r.Max.Y), src, image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(r.Min.X, r.Min.Y, r.Min.X+width, r.Max.Y), src, image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(r.Max.X-width, r.Min.Y, r.Max.X, r.Max.Y), src, image.Point{}, draw.Src)
+}
+
+// rotate180 - карта в перевернутом положении
+func rotate180(src *image.RGBA) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(b)
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dst.SetRGBA(b.Dx()-1-x, b.Dy()-1-y, src.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
+
+// rotate90 - карта, лежащая поперек (поворот по часовой стрелке)
+func rotate90(src *image.RGBA) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dst.SetRGBA(b.Dy()-1-y, x, src.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
+
+// roman - номер карты римскими цифрами, у Шута - 0
+func roman(n int) string {
+	if n <= 0 {
+		return "0"
+	}
+
+	numerals := []struct {
+		value  int
+		symbol string
+	}{
+		{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+	}
+
+	s := ""
+	for _, r := range numerals {
+		for n >= r.value {
+			s += r.symbol
+			n -= r.value
+		}
+	}
+	return s
+}
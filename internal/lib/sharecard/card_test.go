@@ -0,0 +1,23 @@
+package sharecard
+
+import "testing"
+
+func TestRoman(t *testing.T) {
+	tests := map[int]string{
+		-1: "0",
+		0:  "0",
+		1:  "I",
+		4:  "IV",
+		5:  "V",
+		9:  "IX",
+		14: "XIV",
+		19: "XIX",
+		21: "XXI",
+	}
+
+	for n, want := range tests {
+		if got := roman(n); got != want {
+			t.Errorf("roman(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// Package sharecard - картинка расклада для сторис и чатов: карты на местах позиций расклада,
+// вопрос, фирменная рамка и QR со ссылкой на Mini App. Рисуется целиком на Go со встроенными
+// шрифтами, без сети и внешних программ
+package sharecard
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"io/fs"
+	"math"
+	"strconv"
+	"strings"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/tarot"
+	"taro-api/internal/storage/db"
+
+	"golang.org/x/image/vector"
+	"rsc.io/qr"
+)
+
+const (
+	// Width, Height - размер картинки, 9:16 как у сторис
+	Width  = 1080
+	Height = 1920
+
+	// brand - название на картинке
+	brand = "TAROKI"
+	// frame - отступ фирменной рамки от края
+	frame = 48
+	// padding - отступ содержимого от рамки
+	padding = 40
+	// cardAspect - отношение высоты карты к ширине, как у колоды таро
+	cardAspect = 1.7
+	// maxCardWidth - ширина карты в раскладах из одной-трех карт, чтобы они не заняли весь стол
+	maxCardWidth = 280
+	// qrSize - сторона QR кода с отступом вокруг
+	qrSize = 170
+)
+
+var (
+	backgroundTop    = color.RGBA{0x1b, 0x10, 0x35, 0xff}
+	backgroundBottom = color.RGBA{0x0b, 0x08, 0x18, 0xff}
+	gold             = color.RGBA{0xd4, 0xaf, 0x37, 0xff}
+	textColor        = color.RGBA{0xf3, 0xee, 0xff, 0xff}
+	mutedColor       = color.RGBA{0xb8, 0xa9, 0xd9, 0xff}
+	cardColor        = color.RGBA{0x24, 0x17, 0x4d, 0xff}
+)
+
+// Области картинки сверху вниз: заголовок и вопрос, стол с картами, список позиций, подвал с QR
+var (
+	table  = image.Rect(frame+padding, 470, Width-frame-padding, 1250)
+	legend = image.Rect(frame+padding, 1280, Width-frame-padding, 1670)
+)
+
+// Renderer - рисует картинки раскладов
+type Renderer struct {
+	// images - изображения карт <id>.png или <id>.jpg, nil - карты рисуются сами
+	images fs.FS
+}
+
+// New создает Renderer. images - каталог изображений карт, nil - карты рисуются без изображений
+func New(images fs.FS) *Renderer {
+	return &Renderer{images: images}
+}
+
+// Options - что показать на картинке кроме карт
+type Options struct {
+	// Link - ссылка в QR коде (реферальная ссылка автора расклада), пусто - картинка без QR
+	Link string
+	// Question - показать вопрос; картинка уходит за пределы приложения, поэтому вопрос
+	// показывается, только если автор это разрешил
+	Question bool
+}
+
+// Render - PNG картинка расклада r на языке l
+func (rr *Renderer) Render(w io.Writer, l i18n.Lang, r *db.Reading, opts Options) error {
+	img, err := rr.Draw(l, r, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("encode png: %w", err)
+	}
+	return nil
+}
+
+// Draw - как Render, но возвращает картинку без кодирования
+func (rr *Renderer) Draw(l i18n.Lang, r *db.Reading, opts Options) (*image.RGBA, error) {
+	spread, _ := tarot.SpreadByID(r.Spread)
+
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	drawBackground(img)
+
+	center := Width / 2
+	drawText(img, face(bold, 64), gold, center, 160, alignCenter, brand)
+	draw.Draw(img, image.Rect(center-100, 190, center+100, 192), image.NewUniform(gold), image.Point{}, draw.Src)
+
+	width := Width - 2*(frame+padding)
+	title := face(regular, 42)
+	drawText(img, title, textColor, center, 260, alignCenter, truncate(title, spread.Name.In(l), width))
+
+	if opts.Question && r.Question != "" {
+		question := face(regular, 34)
+		y := 320
+		for _, line := range wrap(question, r.Question, width, 3) {
+			drawText(img, question, mutedColor, center, y, alignCenter, line)
+			y += 46
+		}
+	}
+
+	cards := make([]tarot.Drawn, 0, len(r.Cards))
+	for _, c := range r.Cards {
+		card, _ := tarot.ByID(c.Card)
+		cards = append(cards, tarot.Drawn{Card: card, Reversed: c.Reversed})
+	}
+
+	if err := rr.drawTable(img, l, slots(spread, len(cards)), cards); err != nil {
+		return nil, err
+	}
+	drawLegend(img, l, spread, cards)
+
+	if err := drawFooter(img, l, opts.Link); err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// slots - места карт расклада; для расклада без раскладки (неизвестного или с другим
+// числом карт) - ряды по пять карт
+func slots(spread tarot.Spread, n int) []tarot.Slot {
+	if len(spread.Layout) == n {
+		return spread.Layout
+	}
+
+	layout := make([]tarot.Slot, 0, n)
+	for i := range n {
+		layout = append(layout, tarot.Slot{X: 1.2 * float64(i%5), Y: 1.1 * float64(i/5)})
+	}
+	return layout
+}
+
+// drawTable - карты на своих местах, раскладка вписывается в область table по центру
+func (rr *Renderer) drawTable(img *image.RGBA, l i18n.Lang, layout []tarot.Slot, cards []tarot.Drawn) error {
+	if len(cards) == 0 {
+		return nil
+	}
+
+	// границы раскладки в ширинах карты
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, s := range layout {
+		halfW, halfH := 0.5, cardAspect/2
+		if s.Crossed {
+			halfW, halfH = halfH, halfW
+		}
+		x, y := s.X, s.Y*cardAspect
+		minX, maxX = min(minX, x-halfW), max(maxX, x+halfW)
+		minY, maxY = min(minY, y-halfH), max(maxY, y+halfH)
+	}
+
+	cw := min(float64(table.Dx())/(maxX-minX), float64(table.Dy())/(maxY-minY), maxCardWidth)
+	w, h := int(cw), int(cw*cardAspect)
+	originX := float64(table.Min.X) + (float64(table.Dx())-(maxX-minX)*cw)/2 - minX*cw
+	originY := float64(table.Min.Y) + (float64(table.Dy())-(maxY-minY)*cw)/2 - minY*cw
+
+	for i, d := range cards {
+		card, err := rr.cardFace(l, d.Card, w, h)
+		if err != nil {
+			return err
+		}
+		if d.Reversed {
+			card = rotate180(card)
+		}
+		if layout[i].Crossed {
+			card = rotate90(card)
+		}
+
+		cx := int(originX + layout[i].X*cw)
+		cy := int(originY + layout[i].Y*cardAspect*cw)
+		b := card.Bounds()
+		at := image.Rect(cx-b.Dx()/2, cy-b.Dy()/2, cx-b.Dx()/2+b.Dx(), cy-b.Dy()/2+b.Dy())
+		draw.Draw(img, at, card, image.Point{}, draw.Over)
+
+		// номер на углу карты, чтобы не закрывать название перевернутой карты
+		drawBadge(img, at.Min.X, at.Min.Y, max(w/9, 14), i+1)
+	}
+	return nil
+}
+
+// drawBadge - номер позиции в золотом круге радиусом r с центром x, y
+func drawBadge(img *image.RGBA, x, y, r, n int) {
+	z := vector.NewRasterizer(2*r, 2*r)
+	circle(z, float64(r), float64(r), float64(r), false)
+	z.Draw(img, image.Rect(x-r, y-r, x+r, y+r), image.NewUniform(gold), image.Point{})
+
+	f := face(bold, float64(r)*1.2)
+	drawText(img, f, cardColor, x, y+int(float64(r)*0.42), alignCenter, strconv.Itoa(n))
+}
+
+// drawLegend - позиции расклада с названиями выпавших карт
+func drawLegend(img *image.RGBA, l i18n.Lang, spread tarot.Spread, cards []tarot.Drawn) {
+	f := face(regular, 28)
+	y := legend.Min.Y + 28
+	for i, d := range cards {
+		if y > legend.Max.Y {
+			return
+		}
+
+		name := d.Card.Name.In(l)
+		if d.Reversed {
+			name = i18n.T(l, i18n.BotCardReversed, name)
+		}
+		position := ""
+		if i < spread.Size() {
+			position = spread.Positions[i].In(l)
+		}
+
+		line := i18n.T(l, i18n.ShareCardPosition, i+1, position, name)
+		drawText(img, f, textColor, legend.Min.X, y, alignLeft, truncate(f, line, legend.Dx()))
+		y += 38
+	}
+}
+
+// drawFooter - приглашение и QR со ссылкой; без ссылки - только приглашение по центру
+func drawFooter(img *image.RGBA, l i18n.Lang, link string) error {
+	invite := face(bold, 34)
+	if link == "" {
+		drawText(img, invite, gold, Width/2, Height-frame-padding-30, alignCenter, i18n.T(l, i18n.ShareCardInvite))
+		return nil
+	}
+
+	code, err := qr.Encode(link, qr.M)
+	if err != nil {
+		return fmt.Errorf("encode qr: %w", err)
+	}
+
+	at := image.Rect(Width-frame-padding-qrSize, Height-frame-padding-qrSize, Width-frame-padding, Height-frame-padding)
+	drawQR(img, at, code)
+
+	left := frame + padding
+	width := at.Min.X - padding - left
+	drawText(img, invite, gold, left, at.Min.Y+70, alignLeft, truncate(invite, i18n.T(l, i18n.ShareCardInvite), width))
+
+	small := face(regular, 26)
+	display := strings.TrimPrefix(link, "https://")
+	drawText(img, small, mutedColor, left, at.Min.Y+115, alignLeft, truncate(small, display, width))
+	return nil
+}
+
+// drawQR - QR код в квадрате at на белом фоне с отступом в два модуля
+func drawQR(img *image.RGBA, at image.Rectangle, code *qr.Code) {
+	const quiet = 2
+
+	draw.Draw(img, at, image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	module := at.Dx() / (code.Size + 2*quiet)
+	offset := (at.Dx() - module*code.Size) / 2
+	black := image.NewUniform(color.Black)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.Black(x, y) {
+				continue
+			}
+			px, py := at.Min.X+offset+x*module, at.Min.Y+offset+y*module
+			draw.Draw(img, image.Rect(px, py, px+module, py+module), black, image.Point{}, draw.Src)
+		}
+	}
+}
+
+// drawBackground - вертикальный градиент и двойная золотая рамка
+func drawBackground(img *image.RGBA) {
+	for y := 0; y < Height; y++ {
+		t := float64(y) / Height
+		c := color.RGBA{
+			R: lerp(backgroundTop.R, backgroundBottom.R, t),
+			G: lerp(backgroundTop.G, backgroundBottom.G, t),
+			B: lerp(backgroundTop.B, backgroundBottom.B, t),
+			A: 0xff,
+		}
+		draw.Draw(img, image.Rect(0, y, Width, y+1), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+
+	outer := image.Rect(frame, frame, Width-frame, Height-frame)
+	strokeRect(img, outer, 4, gold)
+	strokeRect(img, outer.Inset(12), 1, gold)
+}
+
+func lerp(a, b uint8, t float64) uint8 {
+	return uint8(float64(a) + (float64(b)-float64(a))*t)
+}
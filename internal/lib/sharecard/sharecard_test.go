@@ -0,0 +1,151 @@
+package sharecard
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"math"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/tarot"
+	"taro-api/internal/storage/db"
+	"testing"
+)
+
+// newReading - расклад spread из первых n карт колоды, каждая вторая перевернута
+func newReading(spread string, n int) *db.Reading {
+	r := &db.Reading{Spread: spread, Question: "What should I focus on this week?"}
+	for i, card := range tarot.Deck()[:n] {
+		r.Cards = append(r.Cards, db.ReadingCard{Card: card.ID, Reversed: i%2 == 1})
+	}
+	return r
+}
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+	err := New(nil).Render(&buf, i18n.RU, newReading("three", 3), Options{Link: "https://t.me/taroki_test_bot/app?startapp=ref"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != Width || b.Dy() != Height {
+		t.Errorf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), Width, Height)
+	}
+}
+
+func TestDrawQuestion(t *testing.T) {
+	rr := New(nil)
+	reading := newReading("three", 3)
+	noQuestion := *reading
+	noQuestion.Question = ""
+
+	draw := func(r *db.Reading, opts Options) *image.RGBA {
+		t.Helper()
+		img, err := rr.Draw(i18n.EN, r, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+
+	blank := draw(&noQuestion, Options{})
+	if !bytes.Equal(draw(reading, Options{}).Pix, blank.Pix) {
+		t.Error("question is drawn without Options.Question")
+	}
+	if bytes.Equal(draw(reading, Options{Question: true}).Pix, blank.Pix) {
+		t.Error("question is not drawn with Options.Question")
+	}
+}
+
+func TestDrawSpreads(t *testing.T) {
+	rr := New(nil)
+
+	tests := []struct {
+		name   string
+		spread string
+		cards  int
+	}{
+		{name: "celtic cross", spread: "celtic-cross", cards: 10},
+		// раскладка на три карты, а карт пять - ряды по пять, подписи без позиций
+		{name: "more cards than layout", spread: "three", cards: 5},
+		{name: "fewer cards than layout", spread: "celtic-cross", cards: 2},
+		{name: "unknown spread", spread: "unknown", cards: 7},
+		{name: "no cards", spread: "three", cards: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := rr.Draw(i18n.EN, newReading(tt.spread, tt.cards), Options{Question: true}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// TestDrawTableCrossed - карта на месте Crossed лежит поперек, остальные - вертикально
+func TestDrawTableCrossed(t *testing.T) {
+	rr := New(nil)
+	card := []tarot.Drawn{{Card: tarot.Deck()[0]}}
+
+	for _, crossed := range []bool{false, true} {
+		img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+		if err := rr.drawTable(img, i18n.EN, []tarot.Slot{{Crossed: crossed}}, card); err != nil {
+			t.Fatal(err)
+		}
+
+		b := opaqueBounds(img)
+		if wide := b.Dx() > b.Dy(); wide != crossed {
+			t.Errorf("crossed = %v: card bounds %v", crossed, b)
+		}
+		if !b.In(table) {
+			t.Errorf("crossed = %v: card bounds %v outside the table %v", crossed, b, table)
+		}
+	}
+
+	celtic, _ := tarot.SpreadByID("celtic-cross")
+	if !celtic.Layout[1].Crossed {
+		t.Fatal("second card of the celtic cross is not crossed")
+	}
+}
+
+func TestSlots(t *testing.T) {
+	celtic, _ := tarot.SpreadByID("celtic-cross")
+
+	if got := slots(celtic, celtic.Size()); len(got) != celtic.Size() || got[1] != celtic.Layout[1] {
+		t.Errorf("slots(celtic cross, %d) = %v, want the spread layout", celtic.Size(), got)
+	}
+
+	got := slots(celtic, 7)
+	want := []tarot.Slot{
+		{X: 0, Y: 0}, {X: 1.2, Y: 0}, {X: 2.4, Y: 0}, {X: 3.6, Y: 0}, {X: 4.8, Y: 0},
+		{X: 0, Y: 1.1}, {X: 1.2, Y: 1.1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("slots(celtic cross, 7) = %v, want %v", got, want)
+	}
+	for i := range want {
+		if math.Abs(got[i].X-want[i].X) > 1e-9 || math.Abs(got[i].Y-want[i].Y) > 1e-9 || got[i].Crossed {
+			t.Errorf("slot %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if got := slots(tarot.Spread{}, 0); len(got) != 0 {
+		t.Errorf("slots(no spread, 0) = %v, want empty", got)
+	}
+}
+
+// opaqueBounds - границы нарисованного на прозрачной картинке
+func opaqueBounds(img *image.RGBA) image.Rectangle {
+	var b image.Rectangle
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if img.RGBAAt(x, y).A != 0 {
+				b = b.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return b
+}
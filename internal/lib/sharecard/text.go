@@ -0,0 +1,107 @@
+package sharecard
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Шрифты Go встроены в бинарник и покрывают латиницу и кириллицу
+var (
+	regular = mustParse(goregular.TTF)
+	bold    = mustParse(gobold.TTF)
+)
+
+func mustParse(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic("sharecard: " + err.Error())
+	}
+	return f
+}
+
+// face - шрифт f размером size пикселей. Face не потокобезопасен, поэтому создается
+// на каждую картинку
+func face(f *opentype.Font, size float64) font.Face {
+	ff, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		// ошибка возможна только при неверных опциях, картинка все равно должна получиться
+		return basicfont.Face7x13
+	}
+	return ff
+}
+
+// align - выравнивание строки относительно x
+type align int
+
+const (
+	alignLeft align = iota
+	alignCenter
+)
+
+// drawText - строка s с базовой линией y; при alignCenter x - середина строки
+func drawText(dst draw.Image, f font.Face, c color.Color, x, y int, a align, s string) {
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: f}
+	if a == alignCenter {
+		x -= d.MeasureString(s).Round() / 2
+	}
+	d.Dot = fixed.P(x, y)
+	d.DrawString(s)
+}
+
+// wrap - разбивает s по словам на строки не шире width, не больше maxLines строк;
+// не поместившееся обрезается многоточием
+func wrap(f font.Face, s string, width, maxLines int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line == "" || font.MeasureString(f, candidate).Round() <= width {
+			line = candidate
+			continue
+		}
+
+		lines = append(lines, line)
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] += "…"
+	}
+	for i, l := range lines {
+		lines[i] = truncate(f, l, width)
+	}
+	return lines
+}
+
+// truncate - обрезает s многоточием до ширины width
+func truncate(f font.Face, s string, width int) string {
+	if font.MeasureString(f, s).Round() <= width {
+		return s
+	}
+
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		cut := strings.TrimRight(string(runes), " ") + "…"
+		if font.MeasureString(f, cut).Round() <= width {
+			return cut
+		}
+	}
+	return ""
+}
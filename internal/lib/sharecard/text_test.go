@@ -0,0 +1,67 @@
+package sharecard
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+)
+
+func TestWrap(t *testing.T) {
+	f := face(regular, 34)
+	const width = 400
+
+	tests := []struct {
+		name     string
+		s        string
+		maxLines int
+		want     int
+		ellipsis bool
+	}{
+		{name: "empty", s: "", maxLines: 3, want: 0},
+		{name: "one line", s: "Will it work out?", maxLines: 3, want: 1},
+		{name: "several lines", s: strings.Repeat("question ", 4), maxLines: 3, want: 2},
+		{name: "too many lines", s: strings.Repeat("question ", 20), maxLines: 3, want: 3, ellipsis: true},
+		{name: "long word", s: strings.Repeat("a", 100), maxLines: 3, want: 1, ellipsis: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := wrap(f, tt.s, width, tt.maxLines)
+			if len(lines) != tt.want {
+				t.Fatalf("lines = %q, want %d lines", lines, tt.want)
+			}
+			for _, line := range lines {
+				if w := font.MeasureString(f, line).Round(); w > width {
+					t.Errorf("line %q is %d wide, want at most %d", line, w, width)
+				}
+			}
+			if len(lines) > 0 && strings.HasSuffix(lines[len(lines)-1], "…") != tt.ellipsis {
+				t.Errorf("last line = %q, ellipsis %v", lines[len(lines)-1], tt.ellipsis)
+			}
+			if !tt.ellipsis && strings.Join(lines, " ") != strings.Join(strings.Fields(tt.s), " ") {
+				t.Errorf("lines = %q lose words of %q", lines, tt.s)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	f := face(regular, 34)
+
+	if got := truncate(f, "Fool", 400); got != "Fool" {
+		t.Errorf("fitting text = %q, want unchanged", got)
+	}
+
+	got := truncate(f, "The Wheel of Fortune, reversed", 200)
+	if !strings.HasSuffix(got, "…") || strings.HasSuffix(got, " …") {
+		t.Errorf("truncated = %q, want an ellipsis without a space before it", got)
+	}
+	if w := font.MeasureString(f, got).Round(); w > 200 {
+		t.Errorf("truncated %q is %d wide, want at most 200", got, w)
+	}
+
+	if got := truncate(f, "Fool", 1); got != "" {
+		t.Errorf("nothing fits: %q, want empty", got)
+	}
+}
@@ -0,0 +1,95 @@
+// Package sharetoken - подписанные токены ссылок на картинки раскладов. Картинку загружают
+// серверы Telegram без авторизации, поэтому доступ к ней дает не ID расклада, а токен, который
+// автор получает на время и в котором записано, показывать ли вопрос
+package sharetoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Возможные ошибки
+var (
+	ErrInvalidToken = errors.New("invalid share token")
+	ErrExpiredToken = errors.New("share token is expired")
+)
+
+const (
+	// domain - подпись токенов картинок не совпадет с подписью других токенов на том же секрете
+	domain = "taroki-share-v1."
+	// payloadSize - ID расклада, срок действия (unix) и флаги
+	payloadSize = 16 + 8 + 1
+
+	flagQuestion = 1 << 0
+)
+
+// Claims - содержимое токена
+type Claims struct {
+	ReadingID uuid.UUID
+	// Question - автор разрешил показать вопрос на картинке
+	Question  bool
+	ExpiresAt time.Time
+}
+
+// Signer - выпускает и проверяет токены
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewSigner - конструктор, ttl - срок действия выпущенных токенов
+func NewSigner(secret []byte, ttl time.Duration) *Signer {
+	return &Signer{secret: secret, ttl: ttl}
+}
+
+// Issue - токен на картинку расклада readingID
+func (s *Signer) Issue(readingID uuid.UUID, question bool) (string, time.Time) {
+	expiresAt := time.Now().Add(s.ttl).Truncate(time.Second)
+
+	payload := make([]byte, payloadSize)
+	copy(payload, readingID[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(expiresAt.Unix()))
+	if question {
+		payload[24] |= flagQuestion
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + s.sign(encoded), expiresAt
+}
+
+// Parse - проверяет подпись и срок действия токена
+func (s *Signer) Parse(token string) (Claims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(s.sign(encoded)), []byte(signature)) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(payload) != payloadSize {
+		return Claims{}, ErrInvalidToken
+	}
+
+	claims := Claims{
+		ReadingID: uuid.UUID(payload[:16]),
+		Question:  payload[24]&flagQuestion != 0,
+		ExpiresAt: time.Unix(int64(binary.BigEndian.Uint64(payload[16:24])), 0),
+	}
+	if !time.Now().Before(claims.ExpiresAt) {
+		return Claims{}, ErrExpiredToken
+	}
+
+	return claims, nil
+}
+
+func (s *Signer) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(domain + encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
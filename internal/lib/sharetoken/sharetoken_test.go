@@ -0,0 +1,63 @@
+package sharetoken_test
+
+import (
+	"errors"
+	"strings"
+	"taro-api/internal/lib/sharetoken"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var secret = []byte(strings.Repeat("s", 32))
+
+func TestIssueParse(t *testing.T) {
+	signer := sharetoken.NewSigner(secret, time.Hour)
+	id := uuid.New()
+
+	for _, question := range []bool{false, true} {
+		token, expiresAt := signer.Issue(id, question)
+
+		claims, err := signer.Parse(token)
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		if claims.ReadingID != id || claims.Question != question || !claims.ExpiresAt.Equal(expiresAt) {
+			t.Errorf("claims = %+v, want reading %s, question %v, expires %s", claims, id, question, expiresAt)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	signer := sharetoken.NewSigner(secret, time.Hour)
+	token, _ := signer.Issue(uuid.New(), false)
+	other, _ := sharetoken.NewSigner([]byte(strings.Repeat("o", 32)), time.Hour).Issue(uuid.New(), false)
+
+	payload, signature, _ := strings.Cut(token, ".")
+	// флаг вопроса нельзя включить, не зная секрета
+	forged := payload[:len(payload)-1] + "E." + signature
+
+	tests := map[string]string{
+		"empty":        "",
+		"no signature": payload,
+		"other secret": other,
+		"forged":       forged,
+		"bad payload":  "AAAA." + signature,
+		"reading id":   uuid.NewString(),
+	}
+	for name, token := range tests {
+		if _, err := signer.Parse(token); !errors.Is(err, sharetoken.ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestParseExpired(t *testing.T) {
+	signer := sharetoken.NewSigner(secret, -time.Second)
+	token, _ := signer.Issue(uuid.New(), false)
+
+	if _, err := signer.Parse(token); !errors.Is(err, sharetoken.ErrExpiredToken) {
+		t.Errorf("err = %v, want ErrExpiredToken", err)
+	}
+}
@@ -0,0 +1,282 @@
+package tarot
+
+import (
+	"strings"
+	"taro-api/internal/lib/i18n"
+)
+
+// meaning - строка таблицы колоды, ключевые слова перечислены через запятую
+type meaning struct {
+	id, en, ru             string
+	uprightEN, uprightRU   string
+	reversedEN, reversedRU string
+}
+
+var majorArcana = []meaning{
+	{"fool", "The Fool", "Шут",
+		"new beginnings, spontaneity, freedom, faith", "новое начало, спонтанность, свобода, доверие",
+		"recklessness, naivety, risk", "безрассудство, наивность, риск"},
+	{"magician", "The Magician", "Маг",
+		"willpower, skill, manifestation", "сила воли, мастерство, воплощение",
+		"manipulation, untapped talent, deception", "манипуляция, нереализованный талант, обман"},
+	{"high-priestess", "The High Priestess", "Верховная Жрица",
+		"intuition, mystery, inner knowledge", "интуиция, тайна, внутреннее знание",
+		"secrets, disconnection from intuition, withdrawal", "секреты, потеря связи с интуицией, замкнутость"},
+	{"empress", "The Empress", "Императрица",
+		"abundance, fertility, nurturing", "изобилие, плодородие, забота",
+		"dependence, creative block, smothering", "зависимость, творческий застой, гиперопека"},
+	{"emperor", "The Emperor", "Император",
+		"authority, structure, stability", "власть, порядок, стабильность",
+		"tyranny, rigidity, lack of discipline", "тирания, негибкость, недисциплинированность"},
+	{"hierophant", "The Hierophant", "Иерофант",
+		"tradition, teaching, belief", "традиция, обучение, вера",
+		"rebellion, dogma, unconventionality", "бунт, догматизм, нестандартность"},
+	{"lovers", "The Lovers", "Влюбленные",
+		"love, harmony, choice", "любовь, гармония, выбор",
+		"disharmony, imbalance, wrong choice", "разлад, дисбаланс, неверный выбор"},
+	{"chariot", "The Chariot", "Колесница",
+		"victory, determination, control", "победа, решимость, контроль",
+		"lack of direction, aggression, loss of control", "отсутствие цели, агрессия, потеря контроля"},
+	{"strength", "Strength", "Сила",
+		"courage, patience, compassion", "смелость, терпение, сострадание",
+		"self-doubt, weakness, insecurity", "неуверенность, слабость, сомнения в себе"},
+	{"hermit", "The Hermit", "Отшельник",
+		"introspection, solitude, guidance", "самоанализ, уединение, наставление",
+		"isolation, loneliness, withdrawal", "изоляция, одиночество, отчуждение"},
+	{"wheel-of-fortune", "Wheel of Fortune", "Колесо Фортуны",
+		"cycles, luck, destiny", "циклы, удача, судьба",
+		"bad luck, resistance to change, setbacks", "неудача, сопротивление переменам, откат"},
+	{"justice", "Justice", "Справедливость",
+		"fairness, truth, law", "справедливость, истина, закон",
+		"injustice, dishonesty, unaccountability", "несправедливость, нечестность, безответственность"},
+	{"hanged-man", "The Hanged Man", "Повешенный",
+		"surrender, new perspective, pause", "смирение, новый взгляд, пауза",
+		"stalling, resistance, indecision", "застой, сопротивление, нерешительность"},
+	{"death", "Death", "Смерть",
+		"endings, transformation, transition", "завершение, трансформация, переход",
+		"fear of change, stagnation, holding on", "страх перемен, застой, цепляние за прошлое"},
+	{"temperance", "Temperance", "Умеренность",
+		"balance, moderation, patience", "баланс, умеренность, терпение",
+		"excess, imbalance, haste", "излишество, дисбаланс, спешка"},
+	{"devil", "The Devil", "Дьявол",
+		"attachment, temptation, materialism", "привязанность, искушение, материализм",
+		"release, freedom, breaking chains", "освобождение, свобода, разрыв оков"},
+	{"tower", "The Tower", "Башня",
+		"upheaval, sudden change, revelation", "потрясение, внезапные перемены, откровение",
+		"avoided disaster, fear of change, delay", "предотвращенная беда, страх перемен, отсрочка"},
+	{"star", "The Star", "Звезда",
+		"hope, renewal, inspiration", "надежда, обновление, вдохновение",
+		"despair, discouragement, lack of faith", "отчаяние, уныние, потеря веры"},
+	{"moon", "The Moon", "Луна",
+		"illusion, intuition, fear", "иллюзия, интуиция, страх",
+		"clarity, release of fear, truth revealed", "ясность, освобождение от страха, раскрытие правды"},
+	{"sun", "The Sun", "Солнце",
+		"joy, success, vitality", "радость, успех, жизненная сила",
+		"temporary sadness, delay, overconfidence", "временная грусть, задержка, самоуверенность"},
+	{"judgement", "Judgement", "Суд",
+		"rebirth, calling, reckoning", "возрождение, призвание, подведение итогов",
+		"self-doubt, refusal of the call, harsh judgement", "сомнения, отказ от призвания, суровая самокритика"},
+	{"world", "The World", "Мир",
+		"completion, fulfillment, wholeness", "завершение, исполнение, целостность",
+		"incompletion, delays, lack of closure", "незавершенность, задержки, недосказанность"},
+}
+
+// ranks - достоинства младших арканов от туза до короля: идентификатор и названия
+var ranks = []struct{ id, en, ru string }{
+	{"ace", "Ace", "Туз"},
+	{"two", "Two", "Двойка"},
+	{"three", "Three", "Тройка"},
+	{"four", "Four", "Четверка"},
+	{"five", "Five", "Пятерка"},
+	{"six", "Six", "Шестерка"},
+	{"seven", "Seven", "Семерка"},
+	{"eight", "Eight", "Восьмерка"},
+	{"nine", "Nine", "Девятка"},
+	{"ten", "Ten", "Десятка"},
+	{"page", "Page", "Паж"},
+	{"knight", "Knight", "Рыцарь"},
+	{"queen", "Queen", "Королева"},
+	{"king", "King", "Король"},
+}
+
+// minorArcana - значения младших арканов по мастям в порядке ranks;
+// id и названия карт собираются из достоинства и масти
+var minorArcana = []struct {
+	suit     Suit
+	en, ru   string
+	meanings []meaning
+}{
+	{Wands, "Wands", "Жезлов", []meaning{
+		{uprightEN: "inspiration, new venture, potential", uprightRU: "вдохновение, новое дело, потенциал",
+			reversedEN: "delays, lack of motivation, false start", reversedRU: "задержки, нехватка мотивации, фальстарт"},
+		{uprightEN: "planning, decisions, discovery", uprightRU: "планирование, решения, открытия",
+			reversedEN: "fear of the unknown, poor planning, hesitation", reversedRU: "страх неизвестности, плохое планирование, колебания"},
+		{uprightEN: "expansion, foresight, progress", uprightRU: "расширение, дальновидность, прогресс",
+			reversedEN: "obstacles, delays, frustration", reversedRU: "препятствия, задержки, разочарование"},
+		{uprightEN: "celebration, home, harmony", uprightRU: "праздник, дом, гармония",
+			reversedEN: "instability, conflict at home, transition", reversedRU: "нестабильность, домашние ссоры, переезд"},
+		{uprightEN: "competition, conflict, rivalry", uprightRU: "соперничество, конфликт, борьба",
+			reversedEN: "avoiding conflict, agreement, inner tension", reversedRU: "уход от конфликта, примирение, внутреннее напряжение"},
+		{uprightEN: "victory, recognition, success", uprightRU: "победа, признание, успех",
+			reversedEN: "ego, fall from grace, lack of recognition", reversedRU: "самолюбование, падение, непризнание"},
+		{uprightEN: "defense, perseverance, challenge", uprightRU: "оборона, стойкость, вызов",
+			reversedEN: "exhaustion, giving up, overwhelm", reversedRU: "истощение, капитуляция, перегрузка"},
+		{uprightEN: "speed, movement, news", uprightRU: "скорость, движение, новости",
+			reversedEN: "delays, frustration, waiting", reversedRU: "задержки, раздражение, ожидание"},
+		{uprightEN: "resilience, persistence, boundaries", uprightRU: "стойкость, упорство, границы",
+			reversedEN: "paranoia, fatigue, defensiveness", reversedRU: "подозрительность, усталость, оборонительность"},
+		{uprightEN: "burden, responsibility, hard work", uprightRU: "бремя, ответственность, тяжелый труд",
+			reversedEN: "delegation, release, burnout", reversedRU: "делегирование, освобождение, выгорание"},
+		{uprightEN: "enthusiasm, exploration, free spirit", uprightRU: "энтузиазм, исследование, свобода духа",
+			reversedEN: "impatience, lack of direction, bad news", reversedRU: "нетерпение, отсутствие цели, плохие вести"},
+		{uprightEN: "energy, passion, adventure", uprightRU: "энергия, страсть, приключение",
+			reversedEN: "haste, recklessness, frustration", reversedRU: "поспешность, безрассудство, раздражение"},
+		{uprightEN: "confidence, courage, determination", uprightRU: "уверенность, смелость, решительность",
+			reversedEN: "jealousy, insecurity, demanding", reversedRU: "ревность, неуверенность, требовательность"},
+		{uprightEN: "leadership, vision, entrepreneurship", uprightRU: "лидерство, видение, предпринимательство",
+			reversedEN: "impulsiveness, arrogance, high expectations", reversedRU: "импульсивность, высокомерие, завышенные ожидания"},
+	}},
+	{Cups, "Cups", "Кубков", []meaning{
+		{uprightEN: "love, new feelings, compassion", uprightRU: "любовь, новые чувства, сострадание",
+			reversedEN: "emotional loss, blocked feelings, emptiness", reversedRU: "эмоциональная потеря, подавленные чувства, пустота"},
+		{uprightEN: "partnership, unity, attraction", uprightRU: "партнерство, единство, притяжение",
+			reversedEN: "breakup, imbalance, tension", reversedRU: "разрыв, дисбаланс, напряжение"},
+		{uprightEN: "friendship, celebration, community", uprightRU: "дружба, праздник, общение",
+			reversedEN: "gossip, isolation, overindulgence", reversedRU: "сплетни, изоляция, излишества"},
+		{uprightEN: "apathy, contemplation, reevaluation", uprightRU: "апатия, созерцание, переоценка",
+			reversedEN: "new motivation, acceptance, awareness", reversedRU: "новая мотивация, принятие, осознанность"},
+		{uprightEN: "loss, regret, grief", uprightRU: "потеря, сожаление, горе",
+			reversedEN: "acceptance, moving on, forgiveness", reversedRU: "принятие, движение дальше, прощение"},
+		{uprightEN: "nostalgia, childhood, memories", uprightRU: "ностальгия, детство, воспоминания",
+			reversedEN: "living in the past, naivety, leaving home", reversedRU: "жизнь прошлым, наивность, взросление"},
+		{uprightEN: "choices, fantasy, illusion", uprightRU: "выбор, фантазии, иллюзия",
+			reversedEN: "clarity, decision, reality check", reversedRU: "ясность, решение, трезвый взгляд"},
+		{uprightEN: "walking away, disillusionment, search", uprightRU: "уход, разочарование, поиск",
+			reversedEN: "fear of change, aimlessness, staying", reversedRU: "страх перемен, бесцельность, нежелание уходить"},
+		{uprightEN: "contentment, wishes fulfilled, satisfaction", uprightRU: "удовлетворение, исполнение желаний, довольство",
+			reversedEN: "greed, dissatisfaction, smugness", reversedRU: "жадность, неудовлетворенность, самодовольство"},
+		{uprightEN: "happiness, family, harmony", uprightRU: "счастье, семья, гармония",
+			reversedEN: "broken family, disconnection, misaligned values", reversedRU: "разлад в семье, отчуждение, разные ценности"},
+		{uprightEN: "creativity, intuition, messages", uprightRU: "творчество, интуиция, послания",
+			reversedEN: "emotional immaturity, creative block, insecurity", reversedRU: "эмоциональная незрелость, творческий застой, неуверенность"},
+		{uprightEN: "romance, charm, following the heart", uprightRU: "романтика, обаяние, зов сердца",
+			reversedEN: "moodiness, unrealistic expectations, jealousy", reversedRU: "капризность, нереальные ожидания, ревность"},
+		{uprightEN: "empathy, care, emotional security", uprightRU: "эмпатия, забота, эмоциональная опора",
+			reversedEN: "codependency, insecurity, emotional overwhelm", reversedRU: "созависимость, неуверенность, эмоциональная перегрузка"},
+		{uprightEN: "emotional balance, diplomacy, generosity", uprightRU: "эмоциональное равновесие, дипломатия, щедрость",
+			reversedEN: "manipulation, moodiness, coldness", reversedRU: "манипуляция, переменчивость, холодность"},
+	}},
+	{Swords, "Swords", "Мечей", []meaning{
+		{uprightEN: "clarity, truth, breakthrough", uprightRU: "ясность, истина, прорыв",
+			reversedEN: "confusion, chaos, misinformation", reversedRU: "путаница, хаос, дезинформация"},
+		{uprightEN: "stalemate, difficult choice, avoidance", uprightRU: "тупик, трудный выбор, избегание",
+			reversedEN: "indecision, information overload, relief", reversedRU: "нерешительность, избыток информации, облегчение"},
+		{uprightEN: "heartbreak, grief, sorrow", uprightRU: "разбитое сердце, горе, печаль",
+			reversedEN: "healing, forgiveness, recovery", reversedRU: "исцеление, прощение, восстановление"},
+		{uprightEN: "rest, recovery, contemplation", uprightRU: "отдых, восстановление, размышление",
+			reversedEN: "restlessness, burnout, stagnation", reversedRU: "беспокойство, выгорание, застой"},
+		{uprightEN: "conflict, defeat, winning at all costs", uprightRU: "конфликт, поражение, победа любой ценой",
+			reversedEN: "reconciliation, making amends, past resentment", reversedRU: "примирение, заглаживание вины, старые обиды"},
+		{uprightEN: "transition, moving on, relief", uprightRU: "переход, движение вперед, облегчение",
+			reversedEN: "unfinished business, resistance, baggage", reversedRU: "незавершенные дела, сопротивление, груз прошлого"},
+		{uprightEN: "deception, strategy, stealth", uprightRU: "обман, стратегия, скрытность",
+			reversedEN: "confession, conscience, getting caught", reversedRU: "признание, совесть, разоблачение"},
+		{uprightEN: "restriction, entrapment, self-limitation", uprightRU: "ограничения, ловушка, самоограничение",
+			reversedEN: "release, new perspective, freedom", reversedRU: "освобождение, новый взгляд, свобода"},
+		{uprightEN: "anxiety, worry, nightmares", uprightRU: "тревога, беспокойство, кошмары",
+			reversedEN: "hope, recovery, easing despair", reversedRU: "надежда, восстановление, облегчение"},
+		{uprightEN: "ending, betrayal, rock bottom", uprightRU: "конец, предательство, дно",
+			reversedEN: "recovery, regeneration, resisting the end", reversedRU: "восстановление, возрождение, нежелание отпустить"},
+		{uprightEN: "curiosity, new ideas, vigilance", uprightRU: "любопытство, новые идеи, бдительность",
+			reversedEN: "gossip, haste, empty words", reversedRU: "сплетни, поспешность, пустые слова"},
+		{uprightEN: "ambition, action, drive", uprightRU: "амбиции, действие, напор",
+			reversedEN: "impulsiveness, no direction, burnout", reversedRU: "импульсивность, отсутствие цели, выгорание"},
+		{uprightEN: "independence, clear thinking, honesty", uprightRU: "независимость, ясность мысли, честность",
+			reversedEN: "coldness, bitterness, harshness", reversedRU: "холодность, озлобленность, резкость"},
+		{uprightEN: "intellect, authority, truth", uprightRU: "интеллект, авторитет, истина",
+			reversedEN: "abuse of power, manipulation, cruelty", reversedRU: "злоупотребление властью, манипуляция, жестокость"},
+	}},
+	{Pentacles, "Pentacles", "Пентаклей", []meaning{
+		{uprightEN: "opportunity, prosperity, new venture", uprightRU: "возможность, процветание, новое начинание",
+			reversedEN: "lost opportunity, poor planning, scarcity", reversedRU: "упущенная возможность, плохое планирование, нехватка"},
+		{uprightEN: "balance, adaptability, priorities", uprightRU: "баланс, гибкость, приоритеты",
+			reversedEN: "overwhelm, disorganization, overcommitment", reversedRU: "перегрузка, неорганизованность, обилие обязательств"},
+		{uprightEN: "teamwork, craftsmanship, learning", uprightRU: "командная работа, мастерство, обучение",
+			reversedEN: "lack of teamwork, mediocrity, disharmony", reversedRU: "разобщенность, посредственность, разлад"},
+		{uprightEN: "security, saving, control", uprightRU: "безопасность, накопление, контроль",
+			reversedEN: "greed, materialism, overspending", reversedRU: "жадность, материализм, транжирство"},
+		{uprightEN: "hardship, loss, isolation", uprightRU: "трудности, потеря, изоляция",
+			reversedEN: "recovery, improvement, help arrives", reversedRU: "восстановление, улучшение, помощь"},
+		{uprightEN: "generosity, charity, sharing", uprightRU: "щедрость, благотворительность, обмен",
+			reversedEN: "debt, one-sided charity, strings attached", reversedRU: "долги, неравный обмен, подарки с условием"},
+		{uprightEN: "patience, investment, long-term view", uprightRU: "терпение, вложения, долгосрочный взгляд",
+			reversedEN: "impatience, poor returns, wasted effort", reversedRU: "нетерпение, низкая отдача, напрасные усилия"},
+		{uprightEN: "diligence, skill, mastery", uprightRU: "усердие, навык, мастерство",
+			reversedEN: "perfectionism, lack of focus, shortcuts", reversedRU: "перфекционизм, рассеянность, халтура"},
+		{uprightEN: "abundance, independence, luxury", uprightRU: "изобилие, независимость, роскошь",
+			reversedEN: "overwork, financial setbacks, superficiality", reversedRU: "переработка, финансовые потери, поверхностность"},
+		{uprightEN: "wealth, legacy, family", uprightRU: "богатство, наследие, семья",
+			reversedEN: "financial failure, family disputes, loss", reversedRU: "финансовый крах, семейные споры, утрата"},
+		{uprightEN: "ambition, diligence, new opportunity", uprightRU: "стремление, прилежание, новая возможность",
+			reversedEN: "procrastination, lack of progress, missed chances", reversedRU: "прокрастинация, нет прогресса, упущенные шансы"},
+		{uprightEN: "hard work, routine, reliability", uprightRU: "трудолюбие, рутина, надежность",
+			reversedEN: "boredom, laziness, stagnation", reversedRU: "скука, лень, застой"},
+		{uprightEN: "practicality, nurturing, comfort", uprightRU: "практичность, забота, уют",
+			reversedEN: "self-neglect, work-life imbalance, smothering", reversedRU: "пренебрежение собой, дисбаланс работы и жизни, гиперопека"},
+		{uprightEN: "wealth, security, discipline", uprightRU: "богатство, надежность, дисциплина",
+			reversedEN: "greed, stubbornness, obsession with status", reversedRU: "жадность, упрямство, погоня за статусом"},
+	}},
+}
+
+var (
+	deck = buildDeck()
+	byID = indexDeck(deck)
+)
+
+func buildDeck() []Card {
+	cards := make([]Card, 0, 78)
+
+	for i, m := range majorArcana {
+		cards = append(cards, card(m, Major, "", i))
+	}
+
+	for _, suit := range minorArcana {
+		for i, m := range suit.meanings {
+			rank := ranks[i]
+			m.id = rank.id + "-of-" + string(suit.suit)
+			m.en = rank.en + " of " + suit.en
+			m.ru = rank.ru + " " + suit.ru
+			cards = append(cards, card(m, Minor, suit.suit, i+1))
+		}
+	}
+
+	return cards
+}
+
+func card(m meaning, arcana Arcana, suit Suit, number int) Card {
+	return Card{
+		ID:       m.id,
+		Arcana:   arcana,
+		Suit:     suit,
+		Number:   number,
+		Name:     Text{i18n.EN: m.en, i18n.RU: m.ru},
+		Upright:  Keywords{i18n.EN: split(m.uprightEN), i18n.RU: split(m.uprightRU)},
+		Reversed: Keywords{i18n.EN: split(m.reversedEN), i18n.RU: split(m.reversedRU)},
+	}
+}
+
+func indexDeck(cards []Card) map[string]Card {
+	index := make(map[string]Card, len(cards))
+	for _, c := range cards {
+		index[c.ID] = c
+	}
+	return index
+}
+
+func split(list string) []string {
+	words := strings.Split(list, ",")
+	for i := range words {
+		words[i] = strings.TrimSpace(words[i])
+	}
+	return words
+}
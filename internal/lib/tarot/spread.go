@@ -0,0 +1,102 @@
+package tarot
+
+import "taro-api/internal/lib/i18n"
+
+// Spread - расклад: позиции, на которые выкладываются карты, и их места на столе
+type Spread struct {
+	ID        string
+	Name      Text
+	Positions []Text
+	// Layout - место карты каждой позиции, по порядку позиций
+	Layout []Slot
+}
+
+// Slot - место карты в раскладе: центр карты в размерах карты (X - в ширинах, Y - в высотах)
+// от левого верхнего угла стола. Crossed - карта лежит поперек, как вторая в кельтском кресте.
+// Места рассчитаны на пропорции карт таро, высота - около 1.7 ширины
+type Slot struct {
+	X, Y    float64
+	Crossed bool
+}
+
+// Size - сколько карт нужно для расклада
+func (s Spread) Size() int {
+	return len(s.Positions)
+}
+
+var spreads = []Spread{
+	{
+		ID:   "three",
+		Name: Text{i18n.EN: "Past, present, future", i18n.RU: "Прошлое, настоящее, будущее"},
+		Positions: []Text{
+			{i18n.EN: "Past", i18n.RU: "Прошлое"},
+			{i18n.EN: "Present", i18n.RU: "Настоящее"},
+			{i18n.EN: "Future", i18n.RU: "Будущее"},
+		},
+		Layout: []Slot{{X: 0, Y: 0}, {X: 1.2, Y: 0}, {X: 2.4, Y: 0}},
+	},
+	{
+		ID:   "advice",
+		Name: Text{i18n.EN: "Situation, obstacle, advice", i18n.RU: "Ситуация, препятствие, совет"},
+		Positions: []Text{
+			{i18n.EN: "Situation", i18n.RU: "Ситуация"},
+			{i18n.EN: "Obstacle", i18n.RU: "Препятствие"},
+			{i18n.EN: "Advice", i18n.RU: "Совет"},
+		},
+		Layout: []Slot{{X: 0, Y: 0}, {X: 1.2, Y: 0}, {X: 2.4, Y: 0}},
+	},
+	{
+		ID:   "relationship",
+		Name: Text{i18n.EN: "Relationship", i18n.RU: "Отношения"},
+		Positions: []Text{
+			{i18n.EN: "You", i18n.RU: "Вы"},
+			{i18n.EN: "Partner", i18n.RU: "Партнер"},
+			{i18n.EN: "Connection", i18n.RU: "Связь между вами"},
+		},
+		Layout: []Slot{{X: 0, Y: 0}, {X: 2.4, Y: 0}, {X: 1.2, Y: 1.1}},
+	},
+	{
+		ID:   "celtic-cross",
+		Name: Text{i18n.EN: "Celtic cross", i18n.RU: "Кельтский крест"},
+		Positions: []Text{
+			{i18n.EN: "Situation", i18n.RU: "Ситуация"},
+			{i18n.EN: "Challenge", i18n.RU: "Вызов"},
+			{i18n.EN: "Foundation", i18n.RU: "Основа"},
+			{i18n.EN: "Recent past", i18n.RU: "Недавнее прошлое"},
+			{i18n.EN: "Conscious goal", i18n.RU: "Осознанная цель"},
+			{i18n.EN: "Near future", i18n.RU: "Ближайшее будущее"},
+			{i18n.EN: "Yourself", i18n.RU: "Вы сами"},
+			{i18n.EN: "Environment", i18n.RU: "Окружение"},
+			{i18n.EN: "Hopes and fears", i18n.RU: "Надежды и страхи"},
+			{i18n.EN: "Outcome", i18n.RU: "Итог"},
+		},
+		// крест слева, посох из четырех карт справа снизу вверх
+		Layout: []Slot{
+			{X: 1.4, Y: 1.65},
+			{X: 1.4, Y: 1.65, Crossed: true},
+			{X: 1.4, Y: 2.75},
+			{X: 0, Y: 1.65},
+			{X: 1.4, Y: 0.55},
+			{X: 2.8, Y: 1.65},
+			{X: 4.2, Y: 3.3},
+			{X: 4.2, Y: 2.2},
+			{X: 4.2, Y: 1.1},
+			{X: 4.2, Y: 0},
+		},
+	},
+}
+
+// Spreads - доступные расклады
+func Spreads() []Spread {
+	return spreads
+}
+
+// SpreadByID - расклад по идентификатору
+func SpreadByID(id string) (Spread, bool) {
+	for _, s := range spreads {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Spread{}, false
+}
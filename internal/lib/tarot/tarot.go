@@ -0,0 +1,170 @@
+// Package tarot - колода таро: карты с названиями и ключевыми словами на языках пользователей,
+// расклады и вытягивание карт
+package tarot
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"taro-api/internal/lib/i18n"
+	"time"
+)
+
+// Arcana - старший или младший аркан
+type Arcana string
+
+// Арканы
+const (
+	Major Arcana = "major"
+	Minor Arcana = "minor"
+)
+
+// Suit - масть младшего аркана, у старших арканов пустая
+type Suit string
+
+// Масти
+const (
+	Wands     Suit = "wands"
+	Cups      Suit = "cups"
+	Swords    Suit = "swords"
+	Pentacles Suit = "pentacles"
+)
+
+// Text - текст на нескольких языках
+type Text map[i18n.Lang]string
+
+// In - текст на языке l или на запасном языке (см. i18n.Chain)
+func (t Text) In(l i18n.Lang) string {
+	for _, lang := range i18n.Chain(l) {
+		if s, ok := t[lang]; ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// Keywords - ключевые слова значения карты на нескольких языках
+type Keywords map[i18n.Lang][]string
+
+// In - ключевые слова на языке l или на запасном языке
+func (k Keywords) In(l i18n.Lang) []string {
+	for _, lang := range i18n.Chain(l) {
+		if words, ok := k[lang]; ok {
+			return words
+		}
+	}
+	return nil
+}
+
+// Card - карта колоды
+type Card struct {
+	// ID - постоянный идентификатор карты, например "fool" или "ace-of-cups"
+	ID     string
+	Arcana Arcana
+	Suit   Suit
+	// Number - номер старшего аркана (0-21) или достоинство младшего (1 - туз, 11-14 - фигуры)
+	Number   int
+	Name     Text
+	Upright  Keywords
+	Reversed Keywords
+}
+
+// Drawn - вытянутая карта и ее положение
+type Drawn struct {
+	Card     Card
+	Reversed bool
+}
+
+// Keywords - ключевые слова карты в ее положении
+func (d Drawn) Keywords(l i18n.Lang) []string {
+	if d.Reversed {
+		return d.Card.Reversed.In(l)
+	}
+	return d.Card.Upright.In(l)
+}
+
+// Deck - все 78 карт: старшие арканы по номерам, затем младшие по мастям
+func Deck() []Card {
+	return deck
+}
+
+// ByID - карта по идентификатору
+func ByID(id string) (Card, bool) {
+	c, ok := byID[id]
+	return c, ok
+}
+
+// Search - карты, название которых на любом языке или идентификатор содержит запрос;
+// сначала те, что начинаются с запроса. limit <= 0 - без ограничения
+func Search(query string, limit int) []Card {
+	query = fold(query)
+	if query == "" {
+		return nil
+	}
+
+	var prefix, contains []Card
+	for _, c := range deck {
+		best := -1
+		for _, name := range append(names(c), c.ID) {
+			name = fold(name)
+			switch {
+			case strings.HasPrefix(name, query), strings.HasPrefix(strings.TrimPrefix(name, "the "), query):
+				best = 0
+			case best < 0 && strings.Contains(name, query):
+				best = 1
+			}
+		}
+
+		switch best {
+		case 0:
+			prefix = append(prefix, c)
+		case 1:
+			contains = append(contains, c)
+		}
+	}
+
+	found := append(prefix, contains...)
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+// Draw - n разных случайных карт, каждая может выпасть перевернутой
+func Draw(n int) []Drawn {
+	return draw(rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())), n)
+}
+
+// Daily - карта дня: одна и та же для пользователя в течение календарного дня day
+func Daily(telegramID int64, day time.Time) Drawn {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strconv.FormatInt(telegramID, 10) + ":" + day.Format(time.DateOnly)))
+	seed := h.Sum64()
+
+	return draw(rand.New(rand.NewPCG(seed, seed>>1)), 1)[0]
+}
+
+func draw(r *rand.Rand, n int) []Drawn {
+	n = min(max(n, 0), len(deck))
+
+	drawn := make([]Drawn, 0, n)
+	for _, i := range r.Perm(len(deck))[:n] {
+		drawn = append(drawn, Drawn{Card: deck[i], Reversed: r.IntN(2) == 1})
+	}
+	return drawn
+}
+
+func names(c Card) []string {
+	list := make([]string, 0, len(c.Name))
+	for _, name := range c.Name {
+		list = append(list, name)
+	}
+	return list
+}
+
+// fold - строка для поиска без учета регистра и различия "е" и "ё"
+func fold(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.ReplaceAll(s, "ё", "е")
+}
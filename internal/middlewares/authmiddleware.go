@@ -2,9 +2,21 @@ package middlewares
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/bots"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/lib/session"
+	"taro-api/internal/storage"
+	"time"
 
+	"github.com/google/uuid"
 	initdata "github.com/telegram-mini-apps/init-data-golang"
 )
 
@@ -13,45 +25,272 @@ type contextKey string
 const (
 	// InitDataKey - ключ контекста
 	InitDataKey = contextKey("init-data")
+	// AuthTypeKey - ключ контекста со способом авторизации
+	AuthTypeKey = contextKey("auth-type")
+	// SessionIDKey - ключ контекста с ID сессии (только для Bearer)
+	SessionIDKey = contextKey("session-id")
+	// BotKey - ключ контекста с именем бота, через который пришел пользователь
+	BotKey = contextKey("bot")
+
+	// AuthTypeTMA - авторизация по init data
+	AuthTypeTMA = "tma"
+	// AuthTypeBearer - авторизация по токену сессии
+	AuthTypeBearer = "Bearer"
+
+	// NonceHeader - заголовок с уникальным для каждого запроса значением
+	NonceHeader = "X-Request-Nonce"
+
+	// допустимое расхождение часов клиента и Telegram
+	maxClockSkew = time.Minute
 )
 
-// AuthMiddleware - мидлвейр для авторизации пользователей TMA
-func AuthMiddleware(token string) func(http.Handler) http.Handler {
+// AuthOptions - настройки проверки init data
+type AuthOptions struct {
+	// MaxAge - максимальный возраст init data, 0 - без ограничения
+	MaxAge time.Duration
+	// Nonces - кэш nonce, nil - защита от повтора отключена
+	Nonces *NonceCache
+	// RequireNonce - отклонять запросы без заголовка NonceHeader
+	RequireNonce bool
+	// ThirdPartyBotIDs - боты, init data которых принимаются по подписи Telegram
+	ThirdPartyBotIDs []int64
+	// PublicKey - публичный ключ Telegram для проверки подписи Ed25519
+	PublicKey ed25519.PublicKey
+	// Tokens - менеджер токенов сессий, nil - Bearer не принимается
+	Tokens *session.Manager
+	// Store - проверка отзыва сессий и блокировки пользователей
+	Store SessionStore
+}
+
+// SessionStore - хранилище сессий; вместе с проверкой доступа возвращает язык из настроек
+// пользователя
+type SessionStore interface {
+	SessionAccess(id uuid.UUID) (storage.Access, error)
+	UserAccess(telegramID int64) (storage.Access, error)
+}
+
+// AuthMiddleware - мидлвейр для авторизации пользователей TMA, init data принимаются от
+// любого из ботов; токены читаются на каждый запрос, поэтому их ротация не требует пересборки роутера
+func AuthMiddleware(registry *bots.Registry, opts AuthOptions) func(http.Handler) http.Handler {
 	fn := func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authParts := strings.Split(r.Header.Get("authorization"), " ")
 			if len(authParts) != 2 {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "missing authorization header")
 				return
 			}
 
 			authType := authParts[0]
 			authData := authParts[1]
 
-			switch authType {
-			case "tma":
-				if err := initdata.Validate(authData, token, 0); err != nil {
-					http.Error(w, err.Error(), http.StatusUnauthorized)
+			switch {
+			case authType == AuthTypeTMA:
+				botName, err := validate(authData, registry, opts)
+				if err != nil {
+					renderInitDataError(w, r, err)
 					return
 				}
 
 				initData, err := initdata.Parse(authData)
 				if err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+					resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, err.Error())
 					return
 				}
 
-				ctx := withInitData(r.Context(), initData)
-				*r = *r.WithContext(ctx)
-				next.ServeHTTP(w, r)
+				if initData.AuthDate().After(time.Now().Add(maxClockSkew)) {
+					resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeInitDataInvalid, "auth_date is in the future")
+					return
+				}
+
+				if code, msg := checkNonce(r, initData, opts); code != "" {
+					resp.RenderError(w, r, http.StatusUnauthorized, code, msg)
+					return
+				}
+
+				var language string
+				if opts.Store != nil {
+					access, err := opts.Store.UserAccess(initData.User.ID)
+					if err != nil {
+						resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to check user")
+						return
+					}
+					if !access.Allowed {
+						resp.RenderError(w, r, http.StatusForbidden, resp.CodeForbidden, "user is blocked")
+						return
+					}
+					language = access.Language
+				}
+
+				logger.Enrich(r.Context(),
+					slog.Int64("telegram_id", initData.User.ID),
+					slog.String("auth", AuthTypeTMA),
+					slog.String("bot", botName),
+				)
+
+				ctx := withInitData(r.Context(), initData, language)
+				ctx = context.WithValue(ctx, AuthTypeKey, AuthTypeTMA)
+				ctx = context.WithValue(ctx, BotKey, botName)
+				next.ServeHTTP(w, r.WithContext(ctx))
+			case strings.EqualFold(authType, AuthTypeBearer) && opts.Tokens != nil:
+				claims, err := opts.Tokens.ParseAccess(authData)
+				if errors.Is(err, session.ErrExpiredToken) {
+					resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeTokenExpired, "access token is expired")
+					return
+				}
+				if err != nil {
+					resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeTokenInvalid, "access token is invalid")
+					return
+				}
+
+				sessionID, err := uuid.Parse(claims.SessionID)
+				if err != nil {
+					resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeTokenInvalid, "access token is invalid")
+					return
+				}
+
+				var language string
+				if opts.Store != nil {
+					access, err := opts.Store.SessionAccess(sessionID)
+					if err != nil {
+						resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to check session")
+						return
+					}
+					if !access.Allowed {
+						resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeSessionRevoked, "session is revoked")
+						return
+					}
+					language = access.Language
+				}
+
+				logger.Enrich(r.Context(),
+					slog.Int64("telegram_id", claims.Subject),
+					slog.String("auth", AuthTypeBearer),
+					slog.String("bot", claims.Bot),
+				)
+
+				ctx := withInitData(r.Context(), claimsInitData(claims), language)
+				ctx = context.WithValue(ctx, AuthTypeKey, AuthTypeBearer)
+				ctx = context.WithValue(ctx, BotKey, claims.Bot)
+				ctx = context.WithValue(ctx, SessionIDKey, sessionID)
+				next.ServeHTTP(w, r.WithContext(ctx))
 			default:
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "unsupported authorization type")
 			}
 		})
 	}
 	return fn
 }
 
-func withInitData(ctx context.Context, initData initdata.InitData) context.Context {
+// validate - проверяет подписи наших ботов, а при их несовпадении - подписи сторонних ботов;
+// возвращает имя бота, которым подписаны init data
+func validate(authData string, registry *bots.Registry, opts AuthOptions) (string, error) {
+	var err error
+	for _, b := range registry.All() {
+		err = initdata.Validate(authData, b.Token.Get(), opts.MaxAge)
+		if err == nil {
+			return b.Name, nil
+		}
+		// ошибки формата и срока не зависят от токена, проверять остальных ботов бессмысленно
+		if !errors.Is(err, initdata.ErrSignInvalid) {
+			return "", err
+		}
+	}
+
+	if opts.PublicKey == nil {
+		return "", err
+	}
+
+	for _, botID := range opts.ThirdPartyBotIDs {
+		thirdPartyErr := validateThirdParty(authData, botID, opts.PublicKey, opts.MaxAge)
+		if thirdPartyErr == nil {
+			return ThirdPartyBotName(botID), nil
+		}
+		if !errors.Is(thirdPartyErr, initdata.ErrSignInvalid) {
+			return "", thirdPartyErr
+		}
+	}
+
+	return "", err
+}
+
+// ThirdPartyBotName - имя, которым помечаются пользователи стороннего бота
+func ThirdPartyBotName(botID int64) string {
+	return "third-party:" + strconv.FormatInt(botID, 10)
+}
+
+// checkNonce - не дает повторно использовать пару init data + nonce и ограничивает число
+// запросов с одними init data, в том числе без nonce
+func checkNonce(r *http.Request, initData initdata.InitData, opts AuthOptions) (string, string) {
+	nonce := r.Header.Get(NonceHeader)
+	if nonce == "" && opts.RequireNonce {
+		return resp.CodeNonceRequired, NonceHeader + " header is required"
+	}
+
+	if opts.Nonces == nil {
+		return "", ""
+	}
+
+	// hash уникален для init data и покрывает query_id, поэтому nonce привязывается к нему
+	if err := opts.Nonces.Add(initData.Hash, nonce); err != nil {
+		return resp.CodeReplayDetected, err.Error()
+	}
+
+	return "", ""
+}
+
+func renderInitDataError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, initdata.ErrExpired):
+		resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeInitDataExpired, "init data is expired")
+	case errors.Is(err, initdata.ErrSignInvalid):
+		resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeInitDataInvalid, "init data signature is invalid")
+	case errors.Is(err, initdata.ErrSignMissing):
+		resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeInitDataInvalid, "init data signature is missing")
+	case errors.Is(err, initdata.ErrAuthDateMissing):
+		resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeInitDataInvalid, "init data auth_date is missing")
+	default:
+		resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeInitDataInvalid, "init data has unexpected format")
+	}
+}
+
+// claimsInitData - init data из токена, чтобы обработчики не зависели от способа авторизации
+func claimsInitData(claims *session.Claims) initdata.InitData {
+	return initdata.InitData{
+		AuthDateRaw: int(claims.IssuedAt),
+		User: initdata.User{
+			ID:           claims.Subject,
+			FirstName:    claims.FirstName,
+			LastName:     claims.LastName,
+			Username:     claims.Username,
+			LanguageCode: claims.LanguageCode,
+			IsPremium:    claims.IsPremium,
+		},
+	}
+}
+
+// AuthType - способ авторизации текущего запроса
+func AuthType(ctx context.Context) string {
+	authType, _ := ctx.Value(AuthTypeKey).(string)
+	return authType
+}
+
+// Bot - имя бота, через который пришел пользователь текущего запроса
+func Bot(ctx context.Context) string {
+	name, _ := ctx.Value(BotKey).(string)
+	return name
+}
+
+// SessionID - ID сессии текущего запроса (только для Bearer)
+func SessionID(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(SessionIDKey).(uuid.UUID)
+	return id, ok
+}
+
+// withInitData - кладет init data в контекст. Язык ответа - выбранный в настройках, иначе язык
+// Telegram пользователя, иначе остается язык из Accept-Language
+func withInitData(ctx context.Context, initData initdata.InitData, language string) context.Context {
+	if language != "" || initData.User.LanguageCode != "" {
+		ctx = i18n.WithLang(ctx, i18n.Match(language, initData.User.LanguageCode))
+	}
 	return context.WithValue(ctx, InitDataKey, initData)
 }
@@ -0,0 +1,279 @@
+package middlewares_test
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"taro-api/internal/lib/bots"
+	"taro-api/internal/lib/i18n"
+	"taro-api/internal/lib/secret"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+const testToken = "123456:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// accessStore - хранилище с заданным результатом проверки доступа
+type accessStore struct {
+	access storage.Access
+	calls  int
+}
+
+func (s *accessStore) SessionAccess(uuid.UUID) (storage.Access, error) {
+	s.calls++
+	return s.access, nil
+}
+
+func (s *accessStore) UserAccess(int64) (storage.Access, error) {
+	s.calls++
+	return s.access, nil
+}
+
+func TestAuthMiddlewareLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		telegramLang   string
+		storedLang     string
+		acceptLanguage string
+		want           i18n.Lang
+	}{
+		{name: "settings", telegramLang: "en", storedLang: "ru", want: i18n.RU},
+		{name: "telegram", telegramLang: "ru", acceptLanguage: "en", want: i18n.RU},
+		{name: "accept-language", acceptLanguage: "ru-RU,ru;q=0.9", want: i18n.RU},
+		{name: "default", want: i18n.Default},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &accessStore{access: storage.Access{Allowed: true, Language: tt.storedLang}}
+
+			var got i18n.Lang
+			handler := middlewares.Language(middlewares.AuthMiddleware(
+				bots.NewRegistry(&bots.Bot{Name: "main", Token: secret.NewToken(testToken)}),
+				middlewares.AuthOptions{MaxAge: time.Hour, Store: store},
+			)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = i18n.FromContext(r.Context())
+			})))
+
+			r := httptest.NewRequest(http.MethodGet, "/me", nil)
+			r.Header.Set("Authorization", "tma "+signInitData(t, 42, tt.telegramLang))
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			if got != tt.want {
+				t.Errorf("lang = %q, want %q", got, tt.want)
+			}
+			// язык читается тем же запросом, что и проверка блокировки
+			if store.calls != 1 {
+				t.Errorf("store called %d times, want 1", store.calls)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareBlocked(t *testing.T) {
+	store := &accessStore{access: storage.Access{Allowed: false}}
+
+	handler := middlewares.AuthMiddleware(
+		bots.NewRegistry(&bots.Bot{Name: "main", Token: secret.NewToken(testToken)}),
+		middlewares.AuthOptions{MaxAge: time.Hour, Store: store},
+	)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("blocked user reached the handler")
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/me", nil)
+	r.Header.Set("Authorization", "tma "+signInitData(t, 42, "ru"))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+// signInitData - init data пользователя, подписанные тестовым ботом
+func signInitData(t *testing.T, telegramID int64, languageCode string) string {
+	t.Helper()
+
+	user, err := json.Marshal(map[string]any{"id": telegramID, "first_name": "Test", "language_code": languageCode})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authDate := time.Now()
+	payload := map[string]string{"user": string(user), "query_id": "AAH-test"}
+
+	values := url.Values{}
+	for k, v := range payload {
+		values.Set(k, v)
+	}
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+	values.Set("hash", initdata.Sign(payload, testToken, authDate))
+
+	return values.Encode()
+}
+
+func TestAuthMiddlewareReplay(t *testing.T) {
+	handler := middlewares.AuthMiddleware(
+		bots.NewRegistry(&bots.Bot{Name: "main", Token: secret.NewToken(testToken)}),
+		middlewares.AuthOptions{MaxAge: time.Hour, Nonces: middlewares.NewNonceCache(time.Hour, 3)},
+	)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	initData := signInitData(t, 42, "ru")
+	do := func(data, nonce string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/me", nil)
+		r.Header.Set("Authorization", "tma "+data)
+		if nonce != "" {
+			r.Header.Set(middlewares.NonceHeader, nonce)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	tests := []struct {
+		name   string
+		data   string
+		nonce  string
+		status int
+	}{
+		{name: "first", data: initData, nonce: "a", status: http.StatusOK},
+		{name: "replay", data: initData, nonce: "a", status: http.StatusUnauthorized},
+		{name: "new nonce", data: initData, nonce: "b", status: http.StatusOK},
+		{name: "without nonce", data: initData, status: http.StatusOK},
+		// лимит init data исчерпан: новый nonce не помогает
+		{name: "over limit", data: initData, nonce: "c", status: http.StatusUnauthorized},
+		{name: "over limit without nonce", data: initData, status: http.StatusUnauthorized},
+		{name: "other init data", data: signInitData(t, 43, "ru"), nonce: "a", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		w := do(tt.data, tt.nonce)
+		if w.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d: %s", tt.name, w.Code, tt.status, w.Body.String())
+		}
+		if tt.status == http.StatusUnauthorized && !strings.Contains(w.Body.String(), "replay_detected") {
+			t.Errorf("%s: body = %s, want replay_detected", tt.name, w.Body.String())
+		}
+	}
+}
+
+func TestAuthMiddlewareRequireNonce(t *testing.T) {
+	handler := middlewares.AuthMiddleware(
+		bots.NewRegistry(&bots.Bot{Name: "main", Token: secret.NewToken(testToken)}),
+		middlewares.AuthOptions{MaxAge: time.Hour, Nonces: middlewares.NewNonceCache(time.Hour, 0), RequireNonce: true},
+	)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("request without nonce reached the handler")
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/me", nil)
+	r.Header.Set("Authorization", "tma "+signInitData(t, 42, "ru"))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "nonce_required") {
+		t.Errorf("status = %d: %s, want 401 nonce_required", w.Code, w.Body.String())
+	}
+}
+
+func TestAuthMiddlewareThirdParty(t *testing.T) {
+	const botID = 777
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotBot string
+	handler := middlewares.AuthMiddleware(
+		bots.NewRegistry(&bots.Bot{Name: "main", Token: secret.NewToken(testToken)}),
+		middlewares.AuthOptions{MaxAge: time.Hour, ThirdPartyBotIDs: []int64{botID}, PublicKey: publicKey},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBot = middlewares.Bot(r.Context())
+	}))
+
+	tests := []struct {
+		name    string
+		botID   int64
+		key     ed25519.PrivateKey
+		tamper  bool
+		authAge time.Duration
+		status  int
+	}{
+		{name: "valid", botID: botID, key: privateKey, status: http.StatusOK},
+		{name: "other bot", botID: botID + 1, key: privateKey, status: http.StatusUnauthorized},
+		{name: "tampered", botID: botID, key: privateKey, tamper: true, status: http.StatusUnauthorized},
+		{name: "other key", botID: botID, key: otherKey, status: http.StatusUnauthorized},
+		{name: "expired", botID: botID, key: privateKey, authAge: 2 * time.Hour, status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBot = ""
+			values := signThirdParty(t, tt.botID, tt.key, time.Now().Add(-tt.authAge))
+			if tt.tamper {
+				values.Set("user", `{"id":43,"first_name":"Test"}`)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/me", nil)
+			r.Header.Set("Authorization", "tma "+values.Encode())
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.status == http.StatusOK && gotBot != middlewares.ThirdPartyBotName(botID) {
+				t.Errorf("bot = %q, want %q", gotBot, middlewares.ThirdPartyBotName(botID))
+			}
+		})
+	}
+}
+
+// signThirdParty - init data, подписанные Telegram для стороннего бота botID
+// (https://core.telegram.org/bots/webapps#validating-data-for-third-party-use)
+func signThirdParty(t *testing.T, botID int64, key ed25519.PrivateKey, authDate time.Time) url.Values {
+	t.Helper()
+
+	values := url.Values{}
+	values.Set("user", `{"id":42,"first_name":"Test"}`)
+	values.Set("query_id", "AAH-third-party")
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+
+	pairs := make([]string, 0, len(values))
+	for k := range values {
+		pairs = append(pairs, k+"="+values.Get(k))
+	}
+	sort.Strings(pairs)
+	dataCheck := strconv.FormatInt(botID, 10) + ":WebAppData\n" + strings.Join(pairs, "\n")
+
+	values.Set("signature", base64.RawURLEncoding.EncodeToString(ed25519.Sign(key, []byte(dataCheck))))
+	// hash подписан токеном стороннего бота, нашим ботам он не подходит
+	values.Set("hash", strings.Repeat("0", 64))
+	return values
+}
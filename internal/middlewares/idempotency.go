@@ -0,0 +1,174 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/storage"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+const (
+	// IdempotencyKeyHeader - заголовок с ключом, одинаковым для всех повторов одного запроса
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader - ответ взят из сохраненных, запрос повторно не выполнялся
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 255
+	// тела запросов, меняющих баланс, маленькие; больше - подозрительно
+	maxIdempotentBody = 64 << 10
+)
+
+// IdempotencyStore - хранилище ключей идемпотентности
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(telegramID int64, key, fingerprint string, ttl time.Duration) (*storage.IdempotentResponse, error)
+	SaveIdempotentResponse(telegramID int64, key string, resp storage.IdempotentResponse) error
+	ReleaseIdempotencyKey(telegramID int64, key string) error
+}
+
+// Idempotency - защита запросов, меняющих баланс, от повторов (двойное нажатие, повтор WebView).
+// Ответ на запрос с заголовком Idempotency-Key хранится ttl и отдается на повторы без выполнения
+// обработчика; тот же ключ с другим телом - 422. Ключи у каждого пользователя свои, поэтому
+// ставится после AuthMiddleware. Запросы без заголовка выполняются как обычно
+func Idempotency(log *slog.Logger, store IdempotencyStore, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log := logger.FromRequest(r, log).With(slog.String("op", "middlewares.Idempotency"))
+
+			if len(key) > maxIdempotencyKeyLen {
+				resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, IdempotencyKeyHeader+" is too long")
+				return
+			}
+
+			initData, ok := r.Context().Value(InitDataKey).(initdata.InitData)
+			if !ok {
+				resp.RenderError(w, r, http.StatusUnauthorized, resp.CodeUnauthorized, "init data not found")
+				return
+			}
+			userID := initData.User.ID
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			if err != nil {
+				resp.RenderError(w, r, http.StatusBadRequest, resp.CodeBadRequest, "failed to read request")
+				return
+			}
+			if len(body) > maxIdempotentBody {
+				resp.RenderError(w, r, http.StatusRequestEntityTooLarge, resp.CodeBadRequest, "request body is too large")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			saved, err := store.ReserveIdempotencyKey(userID, key, fingerprint(r, body), ttl)
+			switch {
+			case errors.Is(err, storage.ErrIdempotencyMismatch):
+				resp.RenderError(w, r, http.StatusUnprocessableEntity, resp.CodeIdempotencyMismatch,
+					IdempotencyKeyHeader+" is already used with another request")
+				return
+			case errors.Is(err, storage.ErrIdempotencyInProgress):
+				resp.RenderError(w, r, http.StatusConflict, resp.CodeIdempotencyInProgress,
+					"request with this "+IdempotencyKeyHeader+" is still in progress")
+				return
+			case err != nil:
+				log.Error("failed to reserve idempotency key", slog.String("error", err.Error()))
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.CodeInternal, "failed to process request")
+				return
+			case saved != nil:
+				log.Info("idempotent response replayed")
+				replay(w, saved)
+				return
+			}
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+			// заголовки до обработчика (CORS, X-Request-ID) у повтора будут свои
+			before := w.Header().Clone()
+
+			completed := false
+			defer func() {
+				// обработчик упал или ответил ошибкой сервера: ключ освобождается для повтора
+				if completed {
+					return
+				}
+				if err := store.ReleaseIdempotencyKey(userID, key); err != nil {
+					log.Error("failed to release idempotency key", slog.String("error", err.Error()))
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				return
+			}
+
+			err = store.SaveIdempotentResponse(userID, key, storage.IdempotentResponse{
+				Status:      status,
+				ContentType: ww.Header().Get("Content-Type"),
+				Header:      handlerHeader(before, ww.Header()),
+				Body:        buf.Bytes(),
+			})
+			if err != nil {
+				// ответ уже отправлен; незавершенный ключ освободится, повтор выполнится заново
+				log.Error("failed to save idempotent response", slog.String("error", err.Error()))
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// fingerprint - отпечаток запроса: тот же ключ допустим только с тем же методом, путем и телом
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// handlerHeader - заголовки, которые выставил обработчик: новые или измененные после before.
+// Content-Type хранится отдельно, Content-Length у повтора посчитается заново
+func handlerHeader(before, after http.Header) map[string][]string {
+	var header map[string][]string
+	for name, values := range after {
+		if name == "Content-Type" || name == "Content-Length" || slices.Equal(before[name], values) {
+			continue
+		}
+		if header == nil {
+			header = make(map[string][]string)
+		}
+		header[name] = slices.Clone(values)
+	}
+	return header
+}
+
+func replay(w http.ResponseWriter, saved *storage.IdempotentResponse) {
+	for name, values := range saved.Header {
+		w.Header()[name] = values
+	}
+	if saved.ContentType != "" {
+		w.Header().Set("Content-Type", saved.ContentType)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(saved.Status)
+	_, _ = w.Write(saved.Body)
+}
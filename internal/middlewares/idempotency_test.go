@@ -0,0 +1,85 @@
+package middlewares_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage"
+	"testing"
+	"time"
+
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// idempotencyStore - ключи идемпотентности в памяти
+type idempotencyStore struct {
+	saved map[string]*storage.IdempotentResponse
+}
+
+func (s *idempotencyStore) ReserveIdempotencyKey(_ int64, key, _ string, _ time.Duration) (*storage.IdempotentResponse, error) {
+	return s.saved[key], nil
+}
+
+func (s *idempotencyStore) SaveIdempotentResponse(_ int64, key string, resp storage.IdempotentResponse) error {
+	s.saved[key] = &resp
+	return nil
+}
+
+func (s *idempotencyStore) ReleaseIdempotencyKey(int64, string) error {
+	return nil
+}
+
+func TestIdempotencyReplaysHeaders(t *testing.T) {
+	store := &idempotencyStore{saved: make(map[string]*storage.IdempotentResponse)}
+
+	calls := 0
+	handler := middlewares.Idempotency(slog.New(slog.NewTextHandler(io.Discard, nil)), store, time.Hour)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":1}`))
+		}))
+
+	request := func(requestID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/users/register", strings.NewReader(`{}`))
+		r.Header.Set(middlewares.IdempotencyKeyHeader, "register-1")
+		r = r.WithContext(context.WithValue(r.Context(), middlewares.InitDataKey,
+			initdata.InitData{User: initdata.User{ID: 42}}))
+
+		w := httptest.NewRecorder()
+		// заголовок внешнего мидлвейра у каждого запроса свой и не сохраняется
+		w.Header().Set(middlewares.RequestIDHeader, requestID)
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := request("first")
+	replayed := request("second")
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if replayed.Code != first.Code || replayed.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %q, want %d %q", replayed.Code, replayed.Body.String(), first.Code, first.Body.String())
+	}
+
+	for name, want := range map[string]string{
+		"Deprecation":                        "true",
+		"Content-Type":                       "application/json",
+		middlewares.IdempotentReplayedHeader: "true",
+		middlewares.RequestIDHeader:          "second",
+	} {
+		if got := replayed.Header().Get(name); got != want {
+			t.Errorf("replayed %s = %q, want %q", name, got, want)
+		}
+	}
+	if _, ok := store.saved["register-1"].Header[middlewares.RequestIDHeader]; ok {
+		t.Errorf("saved headers include %s set before the handler", middlewares.RequestIDHeader)
+	}
+}
@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// validateThirdParty - проверяет init data, подписанные Telegram для другого бота
+// (https://core.telegram.org/bots/webapps#validating-data-for-third-party-use)
+func validateThirdParty(raw string, botID int64, publicKey ed25519.PublicKey, expIn time.Duration) error {
+	q, err := url.ParseQuery(raw)
+	if err != nil {
+		return initdata.ErrUnexpectedFormat
+	}
+
+	var (
+		authDate  time.Time
+		signature string
+		pairs     = make([]string, 0, len(q))
+	)
+
+	for k, v := range q {
+		switch k {
+		case "hash":
+			continue
+		case "signature":
+			signature = v[0]
+			continue
+		case "auth_date":
+			if i, err := strconv.ParseInt(v[0], 10, 64); err == nil {
+				authDate = time.Unix(i, 0)
+			}
+		}
+		pairs = append(pairs, k+"="+v[0])
+	}
+
+	if signature == "" {
+		return initdata.ErrSignMissing
+	}
+
+	if expIn > 0 {
+		if authDate.IsZero() {
+			return initdata.ErrAuthDateMissing
+		}
+		if authDate.Add(expIn).Before(time.Now()) {
+			return initdata.ErrExpired
+		}
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(signature, "="))
+	if err != nil {
+		return initdata.ErrSignInvalid
+	}
+
+	sort.Strings(pairs)
+	dataCheck := strconv.FormatInt(botID, 10) + ":WebAppData\n" + strings.Join(pairs, "\n")
+
+	if !ed25519.Verify(publicKey, []byte(dataCheck), sig) {
+		return initdata.ErrSignInvalid
+	}
+
+	return nil
+}
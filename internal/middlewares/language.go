@@ -0,0 +1,14 @@
+package middlewares
+
+import (
+	"net/http"
+	"taro-api/internal/lib/i18n"
+)
+
+// Language - язык ответа по заголовку Accept-Language, пока пользователь неизвестен
+func Language(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+		next.ServeHTTP(w, r.WithContext(i18n.WithLang(r.Context(), lang)))
+	})
+}
@@ -0,0 +1,90 @@
+package middlewares
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNonceTTL = 24 * time.Hour
+	sweepInterval   = time.Minute
+)
+
+// Ошибки проверки nonce
+var (
+	ErrNonceUsed  = errors.New("request nonce has already been used")
+	ErrNonceLimit = errors.New("too many requests with the same init data")
+)
+
+// NonceCache - кэш использованных nonce для защиты от повторной отправки запросов. Nonce
+// выбирает клиент, поэтому повтор с новым nonce отличить нельзя: число запросов с одними
+// init data ограничено, и перехваченные init data можно использовать лишь ограниченное число раз
+type NonceCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	limit     int
+	items     map[string]*nonceEntry
+	lastSweep time.Time
+}
+
+// nonceEntry - запросы с одними init data
+type nonceEntry struct {
+	expires time.Time
+	nonces  map[string]struct{}
+	// requests - все запросы, включая запросы без nonce
+	requests int
+}
+
+// NewNonceCache - конструктор кэша, ttl - время хранения nonce, limit - сколько запросов
+// принимается с одними init data (0 - без ограничения)
+func NewNonceCache(ttl time.Duration, limit int) *NonceCache {
+	if ttl <= 0 {
+		ttl = defaultNonceTTL
+	}
+	return &NonceCache{
+		ttl:   ttl,
+		limit: limit,
+		items: make(map[string]*nonceEntry),
+	}
+}
+
+// Add - учитывает запрос с init data key и nonce (пустой - запрос без nonce). Возвращает
+// ErrNonceUsed, если nonce уже использовался, и ErrNonceLimit, если запросов с этими init
+// data больше лимита
+func (c *NonceCache) Add(key, nonce string) error {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastSweep) > sweepInterval {
+		for k, e := range c.items {
+			if now.After(e.expires) {
+				delete(c.items, k)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	e, ok := c.items[key]
+	if !ok || now.After(e.expires) {
+		e = &nonceEntry{expires: now.Add(c.ttl), nonces: make(map[string]struct{})}
+		c.items[key] = e
+	}
+
+	if nonce != "" {
+		if _, ok := e.nonces[nonce]; ok {
+			return ErrNonceUsed
+		}
+	}
+	if c.limit > 0 && e.requests >= c.limit {
+		return ErrNonceLimit
+	}
+
+	e.requests++
+	if nonce != "" {
+		e.nonces[nonce] = struct{}{}
+	}
+	return nil
+}
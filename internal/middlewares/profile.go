@@ -0,0 +1,134 @@
+package middlewares
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"taro-api/internal/lib/logger"
+	"taro-api/internal/storage"
+	"time"
+
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// ProfileStore - хранилище профилей Telegram
+type ProfileStore interface {
+	SyncTelegramProfile(telegramID int64, profile storage.TelegramProfile) error
+}
+
+// ProfileSync - сохраняет профиль Telegram из init data не чаще раза в interval для каждого
+// пользователя. Ставится после AuthMiddleware; для Bearer профиль не обновляется - в токене
+// снимок на момент входа, он может быть старше уже сохраненного
+func ProfileSync(log *slog.Logger, store ProfileStore, interval time.Duration) func(http.Handler) http.Handler {
+	synced := newSyncTimes(interval)
+
+	syncProfile := func(r *http.Request, user initdata.User) error {
+		err := store.SyncTelegramProfile(user.ID, storage.TelegramProfile{
+			FirstName:    user.FirstName,
+			LastName:     user.LastName,
+			Username:     user.Username,
+			LanguageCode: user.LanguageCode,
+			IsPremium:    user.IsPremium,
+			PhotoURL:     user.PhotoURL,
+		})
+		if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+			// профиль не критичен для ответа, повторим через interval
+			logger.FromRequest(r, log).Warn("failed to sync telegram profile",
+				slog.String("op", "middlewares.ProfileSync"),
+				slog.String("error", err.Error()),
+			)
+		}
+		return err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			initData, ok := r.Context().Value(InitDataKey).(initdata.InitData)
+			if !ok || AuthType(r.Context()) != AuthTypeTMA {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user := initData.User
+			if synced.claim(user.ID) {
+				err := syncProfile(r, user)
+				synced.setMissing(user.ID, errors.Is(err, storage.ErrUserNotFound))
+			}
+
+			next.ServeHTTP(w, r)
+
+			// пользователь мог быть создан этим запросом; GET и HEAD пользователей не создают,
+			// поэтому незарегистрированный пользователь не стоит лишних запросов к базе
+			if r.Method != http.MethodGet && r.Method != http.MethodHead && synced.missing(user.ID) {
+				err := syncProfile(r, user)
+				synced.setMissing(user.ID, errors.Is(err, storage.ErrUserNotFound))
+			}
+		})
+	}
+}
+
+// syncTimes - время последней синхронизации профиля по пользователям
+type syncTimes struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	items     map[int64]syncEntry
+	lastSweep time.Time
+}
+
+// syncEntry - последняя синхронизация профиля пользователя
+type syncEntry struct {
+	at time.Time
+	// missing - пользователя не было в базе
+	missing bool
+}
+
+func newSyncTimes(interval time.Duration) *syncTimes {
+	return &syncTimes{interval: interval, items: make(map[int64]syncEntry)}
+}
+
+// claim - пора ли обновить профиль пользователя; время синхронизации запоминается сразу, чтобы
+// параллельные запросы одного пользователя не обновляли профиль одновременно. Заодно убирает
+// устаревшие записи
+func (s *syncTimes) claim(telegramID int64) bool {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for id, entry := range s.items {
+			if now.Sub(entry.at) >= s.interval {
+				delete(s.items, id)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	entry, ok := s.items[telegramID]
+	if ok && now.Sub(entry.at) < s.interval {
+		return false
+	}
+
+	s.items[telegramID] = syncEntry{at: now, missing: entry.missing}
+	return true
+}
+
+// missing - при последней синхронизации пользователя не было в базе
+func (s *syncTimes) missing(telegramID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.items[telegramID].missing
+}
+
+// setMissing - запоминает, был ли пользователь в базе при синхронизации
+func (s *syncTimes) setMissing(telegramID int64, missing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.items[telegramID]; ok {
+		entry.missing = missing
+		s.items[telegramID] = entry
+	}
+}
@@ -0,0 +1,102 @@
+package middlewares_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage"
+	"testing"
+	"time"
+
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// profileStore - считает синхронизации профиля; пользователь существует после register
+type profileStore struct {
+	mu         sync.Mutex
+	registered bool
+	calls      int
+}
+
+func (s *profileStore) SyncTelegramProfile(int64, storage.TelegramProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if !s.registered {
+		return storage.ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *profileStore) register() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registered = true
+}
+
+func (s *profileStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func profileRequest(method string) *http.Request {
+	r := httptest.NewRequest(method, "/me", nil)
+	ctx := context.WithValue(r.Context(), middlewares.InitDataKey, initdata.InitData{User: initdata.User{ID: 42}})
+	ctx = context.WithValue(ctx, middlewares.AuthTypeKey, middlewares.AuthTypeTMA)
+	return r.WithContext(ctx)
+}
+
+func TestProfileSyncOncePerInterval(t *testing.T) {
+	store := &profileStore{registered: true}
+	handler := middlewares.ProfileSync(slog.New(slog.NewTextHandler(io.Discard, nil)), store, time.Hour)(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), profileRequest(http.MethodGet))
+		}()
+	}
+	wg.Wait()
+
+	if n := store.count(); n != 1 {
+		t.Errorf("profile synced %d times by concurrent requests, want 1", n)
+	}
+}
+
+func TestProfileSyncUnregistered(t *testing.T) {
+	store := &profileStore{}
+	handler := middlewares.ProfileSync(slog.New(slog.NewTextHandler(io.Discard, nil)), store, time.Hour)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				store.register()
+			}
+		}))
+
+	// незарегистрированный пользователь открывает приложение: один запрос к базе за interval
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), profileRequest(http.MethodGet))
+	}
+	if n := store.count(); n != 1 {
+		t.Fatalf("profile synced %d times for an unregistered user, want 1", n)
+	}
+
+	// регистрация: профиль сохраняется сразу после создания пользователя
+	handler.ServeHTTP(httptest.NewRecorder(), profileRequest(http.MethodPost))
+	if n := store.count(); n != 2 {
+		t.Fatalf("profile synced %d times after registration, want 2", n)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), profileRequest(http.MethodPost))
+	if n := store.count(); n != 2 {
+		t.Errorf("profile synced %d times after it was saved, want 2", n)
+	}
+}
@@ -0,0 +1,159 @@
+// Package router - маршруты публичного HTTP API. Роутер собирается здесь, а не в main,
+// чтобы тесты проверяли его на соответствие спецификации OpenAPI
+package router
+
+import (
+	"context"
+	"log/slog"
+	"taro-api/internal/config"
+	"taro-api/internal/handlers/api/getuser"
+	"taro-api/internal/handlers/api/health"
+	"taro-api/internal/handlers/api/practice"
+	"taro-api/internal/handlers/api/promocode"
+	"taro-api/internal/handlers/api/quiz"
+	"taro-api/internal/handlers/api/readings"
+	"taro-api/internal/handlers/api/register"
+	"taro-api/internal/handlers/api/session"
+	"taro-api/internal/handlers/api/settings"
+	"taro-api/internal/helpers/telegram"
+	"taro-api/internal/lib/api/openapi"
+	resp "taro-api/internal/lib/api/response"
+	"taro-api/internal/lib/bots"
+	"taro-api/internal/lib/metrics"
+	tokens "taro-api/internal/lib/session"
+	"taro-api/internal/lib/sharecard"
+	"taro-api/internal/lib/sharetoken"
+	"taro-api/internal/middlewares"
+	"taro-api/internal/storage/db"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/cors"
+	"github.com/go-chi/httprate"
+)
+
+// botCheckTTL - как долго используется результат проверки Bot API в /readyz
+const botCheckTTL = 30 * time.Second
+
+// Deps - зависимости обработчиков API
+type Deps struct {
+	Log     *slog.Logger
+	Config  *config.Config
+	Storage *db.Storage
+	Bots    *bots.Registry
+	// Telegram - клиент Bot API бота по имени (аватары, проверка готовности)
+	Telegram   func(bot string) *telegram.Client
+	Auth       middlewares.AuthOptions
+	Tokens     *tokens.Manager
+	ShareCards *sharecard.Renderer
+	// ShareTokens - токены ссылок на картинки раскладов
+	ShareTokens *sharetoken.Signer
+	// ReferralLink - реферальная ссылка пользователя для картинок раскладов
+	ReferralLink func(telegramID int64) string
+	// NotifyAchievement - поздравление с достижением в боте
+	NotifyAchievement quiz.AchievementNotifier
+	// Jobs - фоновые задачи обработчиков, которые дожидаются при остановке
+	Jobs quiz.Jobs
+}
+
+// New - роутер API. Каждый маршрут должен быть описан в openapi.Spec
+func New(d Deps) *chi.Mux {
+	log, cfg, storage := d.Log, d.Config, d.Storage
+
+	router := chi.NewRouter()
+	router.NotFound(resp.NotFound)
+	router.MethodNotAllowed(resp.MethodNotAllowed)
+	router.Use(cors.Handler(cors.Options{
+		AllowedOrigins: cfg.Server.CORSOrigins,
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token",
+			middlewares.NonceHeader, middlewares.RequestIDHeader, middlewares.IdempotencyKeyHeader},
+		ExposedHeaders: []string{"Link", "Deprecation", middlewares.RequestIDHeader,
+			middlewares.IdempotentReplayedHeader},
+		AllowCredentials: false,
+		MaxAge:           300,
+	}))
+	router.Use(middlewares.RequestLogger(log))
+	router.Use(middlewares.Language)
+	router.Use(metrics.HTTP)
+	router.Use(middleware.Recoverer)
+	// middleware.URLFormat не ставится: он отрезает расширение от пути до маршрутизации,
+	// и /openapi.json и /readings/{id}/share.png перестали бы находиться
+	router.Use(httprate.Limit(cfg.Server.RateLimit, cfg.Server.RateWindow,
+		httprate.WithKeyByIP(),
+		httprate.WithLimitHandler(metrics.RateLimited(resp.RateLimited)),
+	))
+	router.Use(metrics.ObserveThrottle(middleware.ThrottleBacklog(cfg.Server.ThrottleLimit,
+		cfg.Server.ThrottleBacklog, cfg.Server.ThrottleTimeout), resp.RateLimited))
+
+	router.Get("/openapi.json", openapi.Handler(openapi.Spec(cfg.Server.IdempotencyTTL)))
+
+	// пробы для pm2 и балансировщика, без авторизации
+	router.Get("/healthz", health.Live())
+	checks := []health.Check{
+		{Name: "db", Func: storage.Ping},
+		{Name: "migrations", Func: storage.CheckMigrations},
+	}
+	for _, b := range d.Bots.All() {
+		client := d.Telegram(b.Name)
+		checks = append(checks, health.Cached(health.Check{Name: "bot:" + b.Name, Func: func(ctx context.Context) error {
+			_, err := client.GetMe(ctx)
+			return err
+		}}, botCheckTTL))
+	}
+	router.Get("/readyz", health.Ready(log, checks...))
+	router.Get("/version", health.Version())
+
+	router.Post("/auth/refresh", session.Refresh(log, storage, d.Tokens))
+	router.Post("/auth/telegram", session.Telegram(log, storage, d.Tokens,
+		d.Bots, cfg.Session.LoginWidgetMaxAge))
+
+	// картинку расклада загружают серверы Telegram без init data, доступ - по токену из ссылки
+	router.Get("/readings/{id}/share.png", readings.Share(log, storage, d.ShareCards, d.ShareTokens, d.ReferralLink))
+
+	router.Group(func(r chi.Router) {
+		r.Use(middlewares.AuthMiddleware(d.Bots, d.Auth))
+		r.Use(middlewares.ProfileSync(log, storage, cfg.InitData.ProfileSyncInterval))
+
+		// прокси для получения аватара пользователя
+		r.Get("/me/photo", getuser.Photo(log, func(bot string) getuser.AvatarGetter {
+			return d.Telegram(bot)
+		}))
+
+		if cfg.Server.LegacyGetMe {
+			log.Warn("HTTP_LEGACY_GET_ME is deprecated: GET /me registers users, " +
+				"migrate clients to POST /users/register and turn it off")
+			r.Get("/me", getuser.Legacy(log, storage))
+		} else {
+			r.Get("/me", getuser.New(log, storage))
+		}
+
+		// запросы, меняющие баланс, защищены от повторов ключом идемпотентности
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.Idempotency(log, storage, cfg.Server.IdempotencyTTL))
+
+			r.Post("/users/register", register.New(log, storage))
+			r.Post("/me/referral/claim", register.ClaimReferral(log, storage))
+			r.Post("/me/promo/redeem", promocode.Redeem(log, storage))
+		})
+
+		r.Get("/me/settings", settings.Get(log, storage))
+		r.Patch("/me/settings", settings.Update(log, storage))
+
+		r.Get("/readings/{id}", readings.Get(log, storage))
+		r.Post("/readings/{id}/share", readings.ShareLink(log, storage, d.ShareTokens))
+
+		r.Get("/me/practice/next", practice.Next(log, storage))
+		r.Post("/me/practice/{id}/answer", practice.Answer(log, storage))
+		r.Get("/me/practice/stats", practice.Stats(log, storage))
+
+		r.Get("/me/quiz/next", quiz.Next(log, storage))
+		r.Post("/me/quiz/answer", quiz.Answer(log, storage, d.NotifyAchievement, d.Jobs))
+
+		r.Post("/auth/session", session.New(log, storage, d.Tokens))
+		r.Post("/auth/logout", session.Logout(log, storage))
+	})
+
+	return router
+}
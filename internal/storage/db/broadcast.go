@@ -0,0 +1,317 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"taro-api/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateBroadcast - создает черновик рассылки и считает получателей сегмента на текущий момент
+func (s *Storage) CreateBroadcast(b *Broadcast) error {
+	const op = "storage.db.CreateBroadcast"
+
+	var total int64
+	if err := recipientsQuery(s.db, b).Count(&total).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	b.Status = BroadcastDraft
+	b.Total = int(total)
+
+	if err := s.db.Create(b).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetBroadcast - рассылка по ID
+func (s *Storage) GetBroadcast(id uuid.UUID) (*Broadcast, error) {
+	const op = "storage.db.GetBroadcast"
+
+	var b Broadcast
+	err := s.db.Where("id = ?", id).First(&b).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, storage.ErrBroadcastNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &b, nil
+}
+
+// RecentBroadcasts - последние рассылки, новые первыми
+func (s *Storage) RecentBroadcasts(limit int) ([]Broadcast, error) {
+	const op = "storage.db.RecentBroadcasts"
+
+	var list []Broadcast
+	if err := s.db.Order("created_at DESC").Limit(limit).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+// StartBroadcast - фиксирует получателей черновика и запускает рассылку
+func (s *Storage) StartBroadcast(id uuid.UUID) (*Broadcast, error) {
+	const op = "storage.db.StartBroadcast"
+
+	var b Broadcast
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := loadBroadcast(tx, id, &b, BroadcastDraft); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		recipients := recipientsQuery(tx, &b).
+			Select("?, telegram_id, ?, ?, ?", b.ID, DeliveryPending, now, now)
+		res := tx.Exec("INSERT INTO broadcast_recipients (broadcast_id, telegram_id, status, created_at, updated_at) ?",
+			recipients)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		return tx.Model(&b).Updates(map[string]any{
+			"status":     BroadcastRunning,
+			"total":      res.RowsAffected,
+			"started_at": now,
+		}).Error
+	})
+
+	if err := broadcastErr(op, err); err != nil {
+		return nil, err
+	}
+
+	return s.GetBroadcast(id)
+}
+
+// SetBroadcastStatus - переводит рассылку в статус to, если она в одном из статусов from
+func (s *Storage) SetBroadcastStatus(id uuid.UUID, to string, from ...string) (*Broadcast, error) {
+	const op = "storage.db.SetBroadcastStatus"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var b Broadcast
+		if err := loadBroadcast(tx, id, &b, from...); err != nil {
+			return err
+		}
+
+		changes := map[string]any{"status": to}
+		if to == BroadcastCancelled {
+			changes["finished_at"] = time.Now()
+		}
+
+		return tx.Model(&b).Updates(changes).Error
+	})
+
+	if err := broadcastErr(op, err); err != nil {
+		return nil, err
+	}
+
+	return s.GetBroadcast(id)
+}
+
+// RunningBroadcasts - рассылки, которые сейчас отправляются, старые первыми
+func (s *Storage) RunningBroadcasts() ([]Broadcast, error) {
+	const op = "storage.db.RunningBroadcasts"
+
+	var list []Broadcast
+	if err := s.db.Where("status = ?", BroadcastRunning).Order("created_at").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+// PendingRecipients - получатели, которым рассылка еще не отправлялась
+func (s *Storage) PendingRecipients(broadcastID uuid.UUID, limit int) ([]BroadcastRecipient, error) {
+	const op = "storage.db.PendingRecipients"
+
+	var list []BroadcastRecipient
+	err := s.db.Where("broadcast_id = ? AND status = ?", broadcastID, DeliveryPending).
+		Order("id").Limit(limit).Find(&list).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+// ClaimDelivery - отмечает начало отправки получателю перед вызовом Telegram; claimed = false,
+// если получатель уже не в очереди
+func (s *Storage) ClaimDelivery(r *BroadcastRecipient) (bool, error) {
+	const op = "storage.db.ClaimDelivery"
+
+	res := s.db.Model(r).Where("status = ?", DeliveryPending).Update("status", DeliverySending)
+	if res.Error != nil {
+		return false, fmt.Errorf("%s: %w", op, res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+// ReleaseDelivery - возвращает в очередь получателя, которому сообщение так и не отправилось
+// (остановка приложения во время ожидания)
+func (s *Storage) ReleaseDelivery(r *BroadcastRecipient) error {
+	const op = "storage.db.ReleaseDelivery"
+
+	err := s.db.Model(r).Where("status = ?", DeliverySending).Update("status", DeliveryPending).Error
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RecordDelivery - сохраняет результат доставки и обновляет счетчики рассылки; пользователь,
+// заблокировавший бота, помечается, чтобы следующие рассылки его пропускали
+func (s *Storage) RecordDelivery(r *BroadcastRecipient, status, errText string) error {
+	const op = "storage.db.RecordDelivery"
+
+	counter := map[string]string{
+		DeliverySent:    "sent",
+		DeliveryFailed:  "failed",
+		DeliveryBlocked: "blocked",
+	}[status]
+	if counter == "" {
+		return fmt.Errorf("%s: unknown delivery status %q", op, status)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(r).Where("status IN ?", []string{DeliveryPending, DeliverySending}).
+			Updates(map[string]any{"status": status, "error": errText})
+		if res.Error != nil {
+			return res.Error
+		}
+		// уже учтен (повтор после сбоя)
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&Broadcast{}).Where("id = ?", r.BroadcastID).
+			UpdateColumn(counter, gorm.Expr(counter+" + 1")).Error; err != nil {
+			return err
+		}
+
+		if status != DeliveryBlocked {
+			return nil
+		}
+		return tx.Model(&User{}).Where("telegram_id = ?", r.TelegramID).
+			Updates(map[string]any{"bot_blocked": true, "bot_blocked_at": time.Now()}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// FinishBroadcast - завершает запущенную рассылку, если получателей в очереди не осталось;
+// finished - завершена ли она этим вызовом
+func (s *Storage) FinishBroadcast(id uuid.UUID) (*Broadcast, bool, error) {
+	const op = "storage.db.FinishBroadcast"
+
+	var finished bool
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var pending int64
+		err := tx.Model(&BroadcastRecipient{}).
+			Where("broadcast_id = ? AND status = ?", id, DeliveryPending).
+			Count(&pending).Error
+		if err != nil || pending > 0 {
+			return err
+		}
+
+		res := tx.Model(&Broadcast{}).Where("id = ? AND status = ?", id, BroadcastRunning).
+			Updates(map[string]any{"status": BroadcastDone, "finished_at": time.Now()})
+		finished = res.RowsAffected > 0
+		return res.Error
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	b, err := s.GetBroadcast(id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return b, finished, nil
+}
+
+// SetBotBlocked - отмечает, что пользователь заблокировал бота или снова начал с ним диалог
+func (s *Storage) SetBotBlocked(telegramID int64, blocked bool) error {
+	const op = "storage.db.SetBotBlocked"
+
+	changes := map[string]any{"bot_blocked": blocked, "bot_blocked_at": nil}
+	if blocked {
+		changes["bot_blocked_at"] = time.Now()
+	}
+
+	err := s.db.Model(&User{}).Where("telegram_id = ? AND bot_blocked = ?", telegramID, !blocked).
+		Updates(changes).Error
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// recipientsQuery - пользователи сегмента рассылки, до которых может дойти сообщение бота
+func recipientsQuery(tx *gorm.DB, b *Broadcast) *gorm.DB {
+	q := tx.Model(&User{}).Where("blocked = ? AND bot_blocked = ?", false, false)
+
+	if b.IncludeUntagged {
+		q = q.Where("bot = ? OR bot = ''", b.Bot)
+	} else {
+		q = q.Where("bot = ?", b.Bot)
+	}
+
+	seg := b.Segment
+	if seg.Language != "" {
+		q = q.Where("lower(COALESCE(NULLIF(language, ''), language_code)) LIKE ?", strings.ToLower(seg.Language)+"%")
+	}
+	if seg.HasBalance {
+		q = q.Where("balance > 0")
+	}
+	if seg.HasReferrals {
+		q = q.Where("EXISTS (SELECT 1 FROM users r WHERE r.referrer_id = users.telegram_id)")
+	}
+	if seg.ActiveDays > 0 {
+		// профиль синхронизируется при заходах в Mini App
+		q = q.Where("profile_synced_at >= ?", time.Now().AddDate(0, 0, -seg.ActiveDays))
+	}
+
+	return q
+}
+
+// loadBroadcast - загружает рассылку и проверяет, что она в одном из статусов
+func loadBroadcast(tx *gorm.DB, id uuid.UUID, b *Broadcast, statuses ...string) error {
+	err := tx.Where("id = ?", id).First(b).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return storage.ErrBroadcastNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if b.Status == status {
+			return nil
+		}
+	}
+	return storage.ErrBroadcastState
+}
+
+func broadcastErr(op string, err error) error {
+	if err == nil || errors.Is(err, storage.ErrBroadcastNotFound) || errors.Is(err, storage.ErrBroadcastState) {
+		return err
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
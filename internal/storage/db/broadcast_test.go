@@ -0,0 +1,231 @@
+package db_test
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newBroadcastUsers - пользователи для проверки сегментов:
+//
+//	1 - бот main, язык ru в настройках, баланс, заходил сегодня
+//	2 - бот main, язык Telegram en, пригласил 3
+//	3 - без бота (пришел до нескольких ботов), язык Telegram ru
+//	4 - бот main, заблокирован администратором
+//	5 - бот main, заблокировал бота
+//	6 - другой бот
+func newBroadcastUsers(t *testing.T) (*db.Storage, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	s := newStorage(t, path)
+
+	for id, bot := range map[int64]string{1: "main", 2: "main", 3: "", 4: "main", 5: "main", 6: "other"} {
+		if _, _, err := s.RegisterUser(id, bot, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exec(t, path,
+		`UPDATE users SET language = 'ru', balance = 5, profile_synced_at = ? WHERE telegram_id = 1`, time.Now())
+	exec(t, path, `UPDATE users SET language_code = 'en' WHERE telegram_id = 2`)
+	exec(t, path, `UPDATE users SET language_code = 'ru', referrer_id = 2 WHERE telegram_id = 3`)
+	exec(t, path, `UPDATE users SET blocked = true WHERE telegram_id = 4`)
+	exec(t, path, `UPDATE users SET bot_blocked = true WHERE telegram_id = 5`)
+
+	return s, path
+}
+
+// exec - выполняет запрос к базе по path отдельным соединением
+func exec(t *testing.T, path, query string, args ...any) {
+	t.Helper()
+
+	conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := conn.DB()
+	defer sqlDB.Close()
+
+	if err := conn.Exec(query, args...).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateBroadcastSegments(t *testing.T) {
+	s, _ := newBroadcastUsers(t)
+
+	tests := []struct {
+		name      string
+		untagged  bool
+		segment   db.BroadcastSegment
+		wantTotal int
+	}{
+		{name: "bot users", wantTotal: 2},
+		{name: "with untagged", untagged: true, wantTotal: 3},
+		{name: "language", untagged: true, segment: db.BroadcastSegment{Language: "RU"}, wantTotal: 2},
+		{name: "balance", untagged: true, segment: db.BroadcastSegment{HasBalance: true}, wantTotal: 1},
+		{name: "referrals", untagged: true, segment: db.BroadcastSegment{HasReferrals: true}, wantTotal: 1},
+		{name: "active", untagged: true, segment: db.BroadcastSegment{ActiveDays: 7}, wantTotal: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &db.Broadcast{Bot: "main", IncludeUntagged: tt.untagged, Segment: tt.segment}
+			if err := s.CreateBroadcast(b); err != nil {
+				t.Fatal(err)
+			}
+			if b.Status != db.BroadcastDraft || b.Total != tt.wantTotal {
+				t.Errorf("broadcast = %s with %d recipients, want draft with %d", b.Status, b.Total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestStartBroadcast(t *testing.T) {
+	s, path := newBroadcastUsers(t)
+
+	b := &db.Broadcast{Bot: "main", IncludeUntagged: true}
+	if err := s.CreateBroadcast(b); err != nil {
+		t.Fatal(err)
+	}
+
+	started, err := s.StartBroadcast(b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Status != db.BroadcastRunning || started.Total != 3 || started.StartedAt == nil {
+		t.Errorf("started broadcast = %+v, want running with 3 recipients", started)
+	}
+
+	// получатели зафиксированы при запуске: новый пользователь сегмента их не меняет
+	if _, _, err := s.RegisterUser(7, "main", 0); err != nil {
+		t.Fatal(err)
+	}
+	exec(t, path, `UPDATE users SET balance = 0 WHERE telegram_id = 1`)
+
+	recipients, err := s.PendingRecipients(b.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recipientIDs(recipients); !slices.Equal(got, []int64{1, 2, 3}) {
+		t.Errorf("recipients = %v, want [1 2 3]", got)
+	}
+	for _, r := range recipients {
+		if r.Status != db.DeliveryPending || r.BroadcastID != b.ID {
+			t.Errorf("recipient = %+v, want pending of the broadcast", r)
+		}
+	}
+
+	if _, err := s.StartBroadcast(b.ID); !errors.Is(err, storage.ErrBroadcastState) {
+		t.Errorf("second start: err = %v, want ErrBroadcastState", err)
+	}
+}
+
+func TestRecordDelivery(t *testing.T) {
+	s, _ := newBroadcastUsers(t)
+
+	b := &db.Broadcast{Bot: "main", IncludeUntagged: true, CreatedBy: 1}
+	if err := s.CreateBroadcast(b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.StartBroadcast(b.ID); err != nil {
+		t.Fatal(err)
+	}
+	recipients, err := s.PendingRecipients(b.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claim := func(r *db.BroadcastRecipient, want bool) {
+		t.Helper()
+		claimed, err := s.ClaimDelivery(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claimed != want {
+			t.Fatalf("claim %d = %v, want %v", r.TelegramID, claimed, want)
+		}
+	}
+
+	sent, blocked, released := &recipients[0], &recipients[1], &recipients[2]
+
+	claim(sent, true)
+	claim(sent, false)
+	if err := s.RecordDelivery(sent, db.DeliverySent, ""); err != nil {
+		t.Fatal(err)
+	}
+	// повтор после сбоя не учитывается дважды
+	if err := s.RecordDelivery(sent, db.DeliverySent, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	claim(blocked, true)
+	if err := s.RecordDelivery(blocked, db.DeliveryBlocked, "bot was blocked by the user"); err != nil {
+		t.Fatal(err)
+	}
+
+	// отправка не состоялась: получатель возвращается в очередь
+	claim(released, true)
+	if err := s.ReleaseDelivery(released); err != nil {
+		t.Fatal(err)
+	}
+
+	// продолжение после рестарта: в очереди только неотправленные
+	pending, err := s.PendingRecipients(b.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recipientIDs(pending); !slices.Equal(got, []int64{released.TelegramID}) {
+		t.Errorf("pending = %v, want [%d]", got, released.TelegramID)
+	}
+
+	if _, finished, err := s.FinishBroadcast(b.ID); err != nil || finished {
+		t.Fatalf("finish with pending recipients = %v, %v; want not finished", finished, err)
+	}
+
+	claim(released, true)
+	if err := s.RecordDelivery(released, db.DeliveryFailed, "chat not found"); err != nil {
+		t.Fatal(err)
+	}
+
+	done, finished, err := s.FinishBroadcast(b.ID)
+	if err != nil || !finished {
+		t.Fatalf("finish = %v, %v; want finished", finished, err)
+	}
+	if done.Status != db.BroadcastDone || done.Sent != 1 || done.Blocked != 1 || done.Failed != 1 || done.FinishedAt == nil {
+		t.Errorf("finished broadcast = %+v, want done with 1 sent, 1 blocked, 1 failed", done)
+	}
+	if _, finished, _ := s.FinishBroadcast(b.ID); finished {
+		t.Error("broadcast finished twice")
+	}
+
+	// заблокировавший бота пропускается следующими рассылками
+	next := &db.Broadcast{Bot: "main", IncludeUntagged: true}
+	if err := s.CreateBroadcast(next); err != nil {
+		t.Fatal(err)
+	}
+	if next.Total != 2 {
+		t.Errorf("next broadcast has %d recipients, want 2", next.Total)
+	}
+
+	if err := s.RecordDelivery(sent, "unknown", ""); err == nil {
+		t.Error("unknown delivery status was recorded")
+	}
+}
+
+func recipientIDs(list []db.BroadcastRecipient) []int64 {
+	ids := make([]int64, 0, len(list))
+	for _, r := range list {
+		ids = append(ids, r.TelegramID)
+	}
+	slices.Sort(ids)
+	return ids
+}
@@ -3,10 +3,10 @@ package db
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"runtime"
-	"strconv"
+	"strings"
 	"sync"
-	"taro-api/internal/utils"
 	"time"
 
 	"gorm.io/driver/sqlite"
@@ -21,11 +21,30 @@ type Storage struct {
 	stickyErr error
 }
 
-// New - конструктор базы данных
-func New(ctx context.Context) (*Storage, error) {
+// models - все модели, которые мигрируются при старте
+var models = []any{
+	&User{},
+	&Session{},
+	&UsernameChange{},
+	&IdempotencyKey{},
+	&PromoCode{},
+	&PromoRedemption{},
+	&Entitlement{},
+	&Broadcast{},
+	&BroadcastRecipient{},
+	&Reading{},
+	&Practice{},
+	&PracticeCard{},
+	&QuizQuestion{},
+	&QuizCard{},
+	&Achievement{},
+}
+
+// New - конструктор базы данных, path - путь к файлу SQLite
+func New(ctx context.Context, path string) (*Storage, error) {
 	maxConns := 10 * runtime.GOMAXPROCS(0)
 
-	sqldb, err := gorm.Open(sqlite.Open("appdb.db"), &gorm.Config{})
+	sqldb, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -35,14 +54,118 @@ func New(ctx context.Context) (*Storage, error) {
 	db.SetMaxIdleConns(maxConns)
 	db.SetConnMaxLifetime(time.Hour)
 
-	if migrateErr := sqldb.AutoMigrate(
-		&User{}); migrateErr != nil {
-		fmt.Println("Sorry couldn't migrate'...")
+	if err := dedupeUsers(sqldb); err != nil {
+		return nil, fmt.Errorf("failed to deduplicate users: %w", err)
+	}
+	if err := numberPromoRedemptions(sqldb); err != nil {
+		return nil, fmt.Errorf("failed to number promo redemptions: %w", err)
+	}
+	if err := dedupeOpenPractices(sqldb); err != nil {
+		return nil, fmt.Errorf("failed to deduplicate open practices: %w", err)
+	}
+
+	// без миграции нет уникальных индексов, на которые полагаются регистрация и тренировки
+	if err := sqldb.AutoMigrate(models...); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
 	return &Storage{db: sqldb, ctx: ctx}, nil
 }
 
+// dedupeUsers - объединяет дубли пользователей перед созданием уникального индекса по telegram_id:
+// без индекса параллельные регистрации создавали по две записи, а начисления попадали в любую из
+// них. Остается самая ранняя запись: балансы дублей складываются, флаги (бонус за приглашение,
+// блокировки) объединяются, пригласивший берется из любой записи, где он указан
+func dedupeUsers(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&User{}) || migrator.HasIndex(&User{}, "TelegramID") {
+		return nil
+	}
+
+	const copies = `FROM users AS copy WHERE copy.telegram_id = users.telegram_id`
+	var set []string
+	if migrator.HasColumn(&User{}, "balance") {
+		set = append(set, `balance = (SELECT SUM(copy.balance) `+copies+`)`)
+	}
+	for _, flag := range []string{"referral_bonus_applied", "blocked", "bot_blocked"} {
+		if migrator.HasColumn(&User{}, flag) {
+			set = append(set, fmt.Sprintf(`%[1]s = (SELECT MAX(copy.%[1]s) %[2]s)`, flag, copies))
+		}
+	}
+	if migrator.HasColumn(&User{}, "referrer_id") {
+		set = append(set, `referrer_id = CASE WHEN referrer_id <> 0 THEN referrer_id
+			ELSE (SELECT MAX(copy.referrer_id) `+copies+`) END`)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var duplicated []int64
+		err := tx.Raw(`SELECT telegram_id FROM users GROUP BY telegram_id HAVING COUNT(*) > 1`).
+			Scan(&duplicated).Error
+		if err != nil || len(duplicated) == 0 {
+			return err
+		}
+
+		if len(set) > 0 {
+			err = tx.Exec(`UPDATE users SET `+strings.Join(set, ", ")+`
+				WHERE telegram_id IN ? AND NOT EXISTS (`+earlierUser+`)`, duplicated).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec(`DELETE FROM users WHERE EXISTS (` + earlierUser + `)`).Error; err != nil {
+			return err
+		}
+
+		slog.Warn("merged duplicate users", slog.Any("telegram_ids", duplicated))
+		return nil
+	})
+}
+
+// earlierUser - условие "есть более ранняя запись того же пользователя"
+const earlierUser = `SELECT 1 FROM users AS first
+	WHERE first.telegram_id = users.telegram_id
+		AND (first.created_at < users.created_at
+			OR (first.created_at = users.created_at AND first.id < users.id))`
+
+// numberPromoRedemptions - нумерует уже сделанные активации промокодов по пользователям перед
+// созданием уникального индекса (promo_code_id, telegram_id, seq)
+func numberPromoRedemptions(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&PromoRedemption{}) || migrator.HasColumn(&PromoRedemption{}, "Seq") {
+		return nil
+	}
+
+	if err := migrator.AddColumn(&PromoRedemption{}, "Seq"); err != nil {
+		return err
+	}
+
+	return db.Exec(`UPDATE promo_redemptions SET seq = (
+		SELECT COUNT(*) FROM promo_redemptions AS earlier
+		WHERE earlier.promo_code_id = promo_redemptions.promo_code_id
+			AND earlier.telegram_id = promo_redemptions.telegram_id
+			AND (earlier.created_at < promo_redemptions.created_at
+				OR (earlier.created_at = promo_redemptions.created_at AND earlier.id <= promo_redemptions.id))
+	)`).Error
+}
+
+// dedupeOpenPractices - удаляет лишние неотвеченные тренировочные расклады перед созданием
+// уникального индекса idx_practice_open: параллельные запросы следующего расклада создавали
+// по два. Остается самый поздний, его и выдавал GetOpenPractice
+func dedupeOpenPractices(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&Practice{}) || migrator.HasIndex(&Practice{}, "idx_practice_open") {
+		return nil
+	}
+
+	return db.Exec(`DELETE FROM practices WHERE answered_at IS NULL AND EXISTS (
+		SELECT 1 FROM practices AS last
+		WHERE last.telegram_id = practices.telegram_id AND last.answered_at IS NULL
+			AND (last.created_at > practices.created_at
+				OR (last.created_at = practices.created_at AND last.id > practices.id))
+	)`).Error
+}
+
 // CloseDatabaseConnection - Closes the database connection
 func (s *Storage) CloseDatabaseConnection() error {
 	const op = "storage.sql.Close"
@@ -62,63 +185,48 @@ func (s *Storage) CloseDatabaseConnection() error {
 	return s.stickyErr
 }
 
-// GetUserByTelegramID - Возвращает пользователя по его Telegram ID
-func (s *Storage) GetUserByTelegramID(telegramID, referralID int64, botToken string) (*User, error) {
-	var refID int64
+// Ping - проверяет соединение с базой
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "storage.sql.Ping"
 
-	// Размер вознаграждения для реферера
-	var inviteBonusAmount int64 = 5
-	// Размер вознаграждение для реферала
-	var referralBonusAmount int64 = 10
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	var exists bool
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	s.db.Raw("SELECT EXISTS(SELECT 1 FROM users WHERE telegram_id = ?) AS found",
-		telegramID).Scan(&exists)
+	return nil
+}
 
-	if referralID == telegramID {
-		refID = 0
-	} else {
-		refID = referralID
-	}
+// CheckMigrations - проверяет, что таблицы и колонки всех моделей существуют
+func (s *Storage) CheckMigrations(ctx context.Context) error {
+	const op = "storage.sql.CheckMigrations"
 
-	user := User{
-		TelegramID: telegramID,
-		ReferrerID: refID,
-	}
+	tx := s.db.WithContext(ctx)
+	migrator := tx.Migrator()
 
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("telegram_id = ?", telegramID).FirstOrCreate(&user).Error; err != nil {
-			return err
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
 		}
 
-		if refID != 0 && !user.ReferralBonusApplied && !exists {
-			user.Balance += referralBonusAmount
-			if err := tx.Model(&User{}).
-				Where("telegram_id = ?", refID).
-				UpdateColumn("balance", gorm.Expr("balance + ?", inviteBonusAmount)).Error; err != nil {
-				return err
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("%s: table %s is missing", op, stmt.Schema.Table)
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
 			}
-			if err := tx.Model(&user).
-				Update("balance", user.Balance).
-				Update("referral_bonus_applied", true).Error; err != nil {
-				return err
+			if !migrator.HasColumn(model, field.DBName) {
+				return fmt.Errorf("%s: column %s.%s is missing", op, stmt.Schema.Table, field.DBName)
 			}
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if err := s.db.Model(&user).Preload("Referrals").Find(&user).Error; err != nil {
-		return nil, err
 	}
 
-	userPhotoLink := utils.SumStrings("/photo/", strconv.FormatInt(telegramID, 10))
-	user.PhotoURL = userPhotoLink
-	
-	return &user, nil
+	return nil
 }
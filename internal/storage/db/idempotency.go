@@ -0,0 +1,125 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"taro-api/internal/storage"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// abandonedAfter - через сколько незавершенный запрос считается брошенным (процесс упал
+// посреди обработки), и ключ можно занять повторно
+const abandonedAfter = time.Minute
+
+// ReserveIdempotencyKey - занимает ключ для нового запроса. Если ключ уже использован тем же
+// запросом, возвращает сохраненный ответ; другим запросом - ErrIdempotencyMismatch;
+// если тот запрос еще выполняется - ErrIdempotencyInProgress
+func (s *Storage) ReserveIdempotencyKey(telegramID int64, key, fingerprint string,
+	ttl time.Duration) (*storage.IdempotentResponse, error) {
+	const op = "storage.db.ReserveIdempotencyKey"
+
+	var saved *storage.IdempotentResponse
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var existing IdempotencyKey
+		err := tx.Where("telegram_id = ? AND key = ?", telegramID, key).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+		case err != nil:
+			return err
+		case existing.ExpiresAt.Before(now),
+			!existing.Completed && now.Sub(existing.CreatedAt) > abandonedAfter:
+			if err := tx.Delete(&existing).Error; err != nil {
+				return err
+			}
+		case existing.Fingerprint != fingerprint:
+			return storage.ErrIdempotencyMismatch
+		case !existing.Completed:
+			return storage.ErrIdempotencyInProgress
+		default:
+			saved = &storage.IdempotentResponse{
+				Status:      existing.Status,
+				ContentType: existing.ContentType,
+				Header:      existing.Header,
+				Body:        existing.Body,
+			}
+			return nil
+		}
+
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&IdempotencyKey{
+			TelegramID:  telegramID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   now.Add(ttl),
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		// ключ успел занять параллельный запрос
+		if res.RowsAffected == 0 {
+			return storage.ErrIdempotencyInProgress
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, storage.ErrIdempotencyMismatch) || errors.Is(err, storage.ErrIdempotencyInProgress) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return saved, nil
+}
+
+// SaveIdempotentResponse - сохраняет ответ на запрос, занявший ключ
+func (s *Storage) SaveIdempotentResponse(telegramID int64, key string, resp storage.IdempotentResponse) error {
+	const op = "storage.db.SaveIdempotentResponse"
+
+	err := s.db.Model(&IdempotencyKey{}).
+		Where("telegram_id = ? AND key = ?", telegramID, key).
+		Select("completed", "status", "content_type", "header", "body").
+		Updates(&IdempotencyKey{
+			Completed:   true,
+			Status:      resp.Status,
+			ContentType: resp.ContentType,
+			Header:      resp.Header,
+			Body:        resp.Body,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey - освобождает ключ, если запрос не удался и его можно повторить
+func (s *Storage) ReleaseIdempotencyKey(telegramID int64, key string) error {
+	const op = "storage.db.ReleaseIdempotencyKey"
+
+	err := s.db.Where("telegram_id = ? AND key = ? AND completed = ?", telegramID, key, false).
+		Delete(&IdempotencyKey{}).Error
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys - удаляет ключи с истекшим сроком хранения
+func (s *Storage) DeleteExpiredIdempotencyKeys() (int64, error) {
+	const op = "storage.db.DeleteExpiredIdempotencyKeys"
+
+	res := s.db.Where("expires_at < ?", time.Now()).Delete(&IdempotencyKey{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("%s: %w", op, res.Error)
+	}
+
+	return res.RowsAffected, nil
+}
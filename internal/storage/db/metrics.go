@@ -0,0 +1,88 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const (
+	metricsStartKey = "metrics:start"
+	metricsName     = "metrics"
+)
+
+// RegisterMetrics - регистрирует метрики длительности запросов и состояния пула соединений
+func (s *Storage) RegisterMetrics(reg prometheus.Registerer) error {
+	const op = "storage.db.RegisterMetrics"
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "taroki",
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "SQLite query latency by operation.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation", "table"})
+
+	if err := reg.Register(duration); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := reg.Register(collectors.NewDBStatsCollector(sqlDB, "appdb")); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(metricsStartKey, time.Now())
+	}
+	after := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			start, ok := tx.InstanceGet(metricsStartKey)
+			if !ok {
+				return
+			}
+			table := tx.Statement.Table
+			if table == "" {
+				table = "raw"
+			}
+			duration.WithLabelValues(operation, table).Observe(time.Since(start.(time.Time)).Seconds())
+		}
+	}
+
+	cb := s.db.Callback()
+	hooks := []struct {
+		operation string
+		before    error
+		after     error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register(metricsName+":before_create", before),
+			cb.Create().After("gorm:create").Register(metricsName+":after_create", after("create"))},
+		{"query", cb.Query().Before("gorm:query").Register(metricsName+":before_query", before),
+			cb.Query().After("gorm:query").Register(metricsName+":after_query", after("query"))},
+		{"update", cb.Update().Before("gorm:update").Register(metricsName+":before_update", before),
+			cb.Update().After("gorm:update").Register(metricsName+":after_update", after("update"))},
+		{"delete", cb.Delete().Before("gorm:delete").Register(metricsName+":before_delete", before),
+			cb.Delete().After("gorm:delete").Register(metricsName+":after_delete", after("delete"))},
+		{"row", cb.Row().Before("gorm:row").Register(metricsName+":before_row", before),
+			cb.Row().After("gorm:row").Register(metricsName+":after_row", after("row"))},
+		{"raw", cb.Raw().Before("gorm:raw").Register(metricsName+":before_raw", before),
+			cb.Raw().After("gorm:raw").Register(metricsName+":after_raw", after("raw"))},
+	}
+
+	for _, h := range hooks {
+		if h.before != nil {
+			return fmt.Errorf("%s: %s: %w", op, h.operation, h.before)
+		}
+		if h.after != nil {
+			return fmt.Errorf("%s: %s: %w", op, h.operation, h.after)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"taro-api/internal/lib/practice"
+	"taro-api/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PracticeAnswer - оцененный ответ на тренировочный расклад
+type PracticeAnswer struct {
+	Answers []string
+	Score   int
+	// Correct - верно ли истолкована карта каждой позиции
+	Correct []bool
+}
+
+// PracticeStats - успехи пользователя в тренировках
+type PracticeStats struct {
+	// Streak - текущая серия дней подряд, прервавшаяся серия - 0
+	Streak     int
+	BestStreak int
+	Answered   int64
+	// AverageScore - средняя оценка отвеченных раскладов
+	AverageScore float64
+	Cards        []practice.Progress
+}
+
+// GetOpenPractice - тренировочный расклад пользователя, на который он еще не ответил
+func (s *Storage) GetOpenPractice(telegramID int64) (*Practice, error) {
+	const op = "storage.db.GetOpenPractice"
+
+	var p Practice
+	err := s.db.Where("telegram_id = ? AND answered_at IS NULL", telegramID).
+		Order("created_at DESC").First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, storage.ErrPracticeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &p, nil
+}
+
+// GetPractice - тренировочный расклад пользователя по ID; чужой расклад не находится
+func (s *Storage) GetPractice(id uuid.UUID, telegramID int64) (*Practice, error) {
+	const op = "storage.db.GetPractice"
+
+	var p Practice
+	err := s.db.Where("id = ? AND telegram_id = ?", id, telegramID).First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, storage.ErrPracticeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &p, nil
+}
+
+// CreatePractice - сохраняет тренировочный расклад зарегистрированного пользователя,
+// ID заполняется при создании. Если неотвеченный расклад уже создал параллельный запрос,
+// новый не сохраняется, а p заполняется существующим
+func (s *Storage) CreatePractice(p *Practice) error {
+	const op = "storage.db.CreatePractice"
+
+	var count int64
+	if err := s.db.Model(&User{}).Where("telegram_id = ?", p.TelegramID).Count(&count).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if count == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	// уникальный индекс idx_practice_open не пропустит второй неотвеченный расклад
+	res := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(p)
+	if res.Error != nil {
+		return fmt.Errorf("%s: %w", op, res.Error)
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+
+	var open Practice
+	if err := s.db.Where("telegram_id = ? AND answered_at IS NULL", p.TelegramID).First(&open).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	*p = open
+
+	return nil
+}
+
+// GetPracticeProgress - успехи пользователя по каждой карте, которую он толковал
+func (s *Storage) GetPracticeProgress(telegramID int64) ([]practice.Progress, error) {
+	const op = "storage.db.GetPracticeProgress"
+
+	progress, err := practiceProgress(s.db, telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return progress, nil
+}
+
+// AnswerPractice - сохраняет оцененный ответ, переносит карты по ящикам повторения
+// и продлевает серию дней в одной транзакции. Возвращает пользователя с новой серией
+func (s *Storage) AnswerPractice(id uuid.UUID, telegramID int64, a PracticeAnswer) (*User, error) {
+	const op = "storage.db.AnswerPractice"
+
+	var user User
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Where("telegram_id = ?", telegramID).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return storage.ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var p Practice
+		err = tx.Where("id = ? AND telegram_id = ?", id, telegramID).First(&p).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return storage.ErrPracticeNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if len(a.Correct) != len(p.Cards) {
+			return fmt.Errorf("got %d grades for %d cards", len(a.Correct), len(p.Cards))
+		}
+
+		// ответ сохраняется условно, чтобы повторная отправка не засчитала карты дважды
+		res := tx.Model(&Practice{}).
+			Where("id = ? AND answered_at IS NULL", id).
+			Updates(&Practice{Answers: a.Answers, Score: a.Score, AnsweredAt: &now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return storage.ErrPracticeAnswered
+		}
+
+		for i, c := range p.Cards {
+			if err := recordPracticeCard(tx, telegramID, c.Card, a.Correct[i], now); err != nil {
+				return err
+			}
+		}
+
+		today := practice.Today(user.Timezone, now)
+		user.PracticeStreak = practice.Streak(user.PracticeDay, user.PracticeStreak, today)
+		user.PracticeBestStreak = max(user.PracticeBestStreak, user.PracticeStreak)
+		user.PracticeDay = today
+
+		return tx.Model(&User{}).Where("telegram_id = ?", telegramID).Updates(map[string]any{
+			"practice_streak":      user.PracticeStreak,
+			"practice_best_streak": user.PracticeBestStreak,
+			"practice_day":         user.PracticeDay,
+		}).Error
+	})
+
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound),
+		errors.Is(err, storage.ErrPracticeNotFound),
+		errors.Is(err, storage.ErrPracticeAnswered):
+		return nil, err
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &user, nil
+}
+
+// GetPracticeStats - серия, средняя оценка и успехи по картам
+func (s *Storage) GetPracticeStats(telegramID int64) (*PracticeStats, error) {
+	const op = "storage.db.GetPracticeStats"
+
+	var user User
+	err := s.db.Select("timezone", "practice_streak", "practice_best_streak", "practice_day").
+		Where("telegram_id = ?", telegramID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, storage.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stats := PracticeStats{
+		Streak:     practice.CurrentStreak(user.PracticeDay, user.PracticeStreak, practice.Today(user.Timezone, time.Now())),
+		BestStreak: user.PracticeBestStreak,
+	}
+
+	var totals struct {
+		Answered     int64
+		AverageScore float64
+	}
+	err = s.db.Model(&Practice{}).
+		Select("COUNT(*) AS answered, COALESCE(AVG(score), 0) AS average_score").
+		Where("telegram_id = ? AND answered_at IS NOT NULL", telegramID).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	stats.Answered, stats.AverageScore = totals.Answered, totals.AverageScore
+
+	stats.Cards, err = practiceProgress(s.db, telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &stats, nil
+}
+
+// recordPracticeCard - учитывает ответ по карте и назначает срок ее повторения
+func recordPracticeCard(tx *gorm.DB, telegramID int64, card string, correct bool, now time.Time) error {
+	pc := PracticeCard{TelegramID: telegramID, Card: card}
+	err := tx.Where("telegram_id = ? AND card = ?", telegramID, card).First(&pc).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	pc.Attempts++
+	if correct {
+		pc.Correct++
+	}
+	pc.Box, pc.DueAt = practice.Schedule(pc.Box, correct, now)
+
+	return tx.Save(&pc).Error
+}
+
+func practiceProgress(tx *gorm.DB, telegramID int64) ([]practice.Progress, error) {
+	var cards []PracticeCard
+	if err := tx.Where("telegram_id = ?", telegramID).Order("due_at").Find(&cards).Error; err != nil {
+		return nil, err
+	}
+
+	progress := make([]practice.Progress, 0, len(cards))
+	for _, c := range cards {
+		progress = append(progress, practice.Progress{
+			Card:     c.Card,
+			Attempts: c.Attempts,
+			Correct:  c.Correct,
+			Box:      c.Box,
+			DueAt:    c.DueAt,
+		})
+	}
+	return progress, nil
+}
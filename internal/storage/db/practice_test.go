@@ -0,0 +1,116 @@
+package db_test
+
+import (
+	"path/filepath"
+	"taro-api/internal/storage/db"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCreatePracticeOneOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	s := newStorage(t, path)
+
+	if _, _, err := s.RegisterUser(42, "main", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// оба запроса не нашли неотвеченного расклада и создают свой
+	first := &db.Practice{TelegramID: 42, Spread: "three", Cards: []db.ReadingCard{{Card: "fool"}}}
+	second := &db.Practice{TelegramID: 42, Spread: "advice", Cards: []db.ReadingCard{{Card: "star"}}}
+	for _, p := range []*db.Practice{first, second} {
+		if err := s.CreatePractice(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if second.ID != first.ID || second.Spread != first.Spread {
+		t.Errorf("second practice = %s %s, want the open %s %s", second.ID, second.Spread, first.ID, first.Spread)
+	}
+
+	conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := conn.DB()
+	defer sqlDB.Close()
+
+	var n int64
+	if err := conn.Table("practices").Where("telegram_id = 42").Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("practices = %d, want 1", n)
+	}
+
+	// после ответа можно создать следующий
+	if _, err := s.AnswerPractice(first.ID, 42, db.PracticeAnswer{Answers: []string{"x"}, Correct: []bool{true}}); err != nil {
+		t.Fatal(err)
+	}
+	next := &db.Practice{TelegramID: 42, Spread: "three", Cards: []db.ReadingCard{{Card: "fool"}}}
+	if err := s.CreatePractice(next); err != nil {
+		t.Fatal(err)
+	}
+	if next.ID == first.ID {
+		t.Error("answered practice was returned as open")
+	}
+}
+
+func TestDedupeOpenPractices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	// база до уникального индекса: у пользователя 42 два неотвеченных расклада
+	conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Exec(`CREATE TABLE practices (id text PRIMARY KEY, created_at datetime,
+		telegram_id integer, answered_at datetime)`).Error
+	if err == nil {
+		err = conn.Exec(`INSERT INTO practices (id, created_at, telegram_id, answered_at) VALUES
+			('00000000-0000-0000-0000-000000000001', '2024-01-01 00:00:00', 42, NULL),
+			('00000000-0000-0000-0000-000000000002', '2024-01-02 00:00:00', 42, NULL),
+			('00000000-0000-0000-0000-000000000003', '2023-12-31 00:00:00', 42, '2024-01-01 00:00:00'),
+			('00000000-0000-0000-0000-000000000004', '2024-01-01 00:00:00', 43, NULL)`).Error
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := conn.DB()
+	sqlDB.Close()
+
+	newStorage(t, path)
+
+	conn, err = gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ = conn.DB()
+	defer sqlDB.Close()
+
+	var ids []string
+	if err := conn.Raw("SELECT id FROM practices ORDER BY id").Scan(&ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"00000000-0000-0000-0000-000000000002",
+		"00000000-0000-0000-0000-000000000003",
+		"00000000-0000-0000-0000-000000000004",
+	}
+	if len(ids) != len(want) {
+		t.Fatalf("practices = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("practices = %v, want %v", ids, want)
+			break
+		}
+	}
+
+	err = conn.Exec(`INSERT INTO practices (id, created_at, telegram_id) VALUES
+		('00000000-0000-0000-0000-000000000005', '2024-01-03 00:00:00', 43)`).Error
+	if err == nil {
+		t.Error("second open practice was inserted")
+	}
+}
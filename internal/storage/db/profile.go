@@ -0,0 +1,58 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"taro-api/internal/storage"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SyncTelegramProfile - сохраняет профиль Telegram пользователя, смена username пишется в историю
+func (s *Storage) SyncTelegramProfile(telegramID int64, profile storage.TelegramProfile) error {
+	const op = "storage.db.SyncTelegramProfile"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user User
+		err := tx.Select("id", "username", "profile_synced_at").
+			Where("telegram_id = ?", telegramID).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return storage.ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// при первой синхронизации прежнего username нет, записывать нечего
+		if user.ProfileSyncedAt != nil && user.Username != profile.Username {
+			change := UsernameChange{
+				TelegramID:  telegramID,
+				OldUsername: user.Username,
+				NewUsername: profile.Username,
+			}
+			if err := tx.Create(&change).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&user).Updates(map[string]any{
+			"first_name":         profile.FirstName,
+			"last_name":          profile.LastName,
+			"username":           profile.Username,
+			"language_code":      profile.LanguageCode,
+			"is_premium":         profile.IsPremium,
+			"telegram_photo_url": profile.PhotoURL,
+			"profile_synced_at":  time.Now(),
+		}).Error
+	})
+
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
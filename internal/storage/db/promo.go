@@ -0,0 +1,206 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"taro-api/internal/lib/promo"
+	"taro-api/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreatePromoCodes - выпускает коды с одинаковыми параметрами одним выпуском;
+// если хотя бы один код уже существует, не создается ни один
+func (s *Storage) CreatePromoCodes(codes []string, opts promo.Options, createdBy int64) (string, error) {
+	const op = "storage.db.CreatePromoCodes"
+
+	batch := uuid.NewString()
+
+	rows := make([]PromoCode, 0, len(codes))
+	for _, code := range codes {
+		rows = append(rows, PromoCode{
+			Code:            code,
+			Batch:           batch,
+			RewardType:      opts.RewardType,
+			Amount:          opts.Amount,
+			Entitlement:     opts.Entitlement,
+			EntitlementDays: opts.Days,
+			MaxRedemptions:  opts.MaxRedemptions,
+			PerUserLimit:    opts.PerUserLimit,
+			ValidFrom:       opts.ValidFrom,
+			ValidUntil:      opts.ValidUntil,
+			CreatedBy:       createdBy,
+		})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&PromoCode{}).Where("code IN ?", codes).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return storage.ErrPromoExists
+		}
+
+		return tx.CreateInBatches(rows, 500).Error
+	})
+
+	if errors.Is(err, storage.ErrPromoExists) {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return batch, nil
+}
+
+// RedeemPromoCode - активирует код: проверяет срок и лимиты и начисляет награду в одной
+// транзакции, как реферальный бонус. Возвращает активацию и пользователя с новым балансом
+func (s *Storage) RedeemPromoCode(telegramID int64, code string) (*PromoRedemption, *User, error) {
+	const op = "storage.db.RedeemPromoCode"
+
+	var redemption PromoRedemption
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var user User
+		err := tx.Select("id", "blocked").Where("telegram_id = ?", telegramID).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return storage.ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if user.Blocked {
+			return storage.ErrUserBlocked
+		}
+
+		var pc PromoCode
+		err = tx.Where("code = ?", promo.Normalize(code)).First(&pc).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return storage.ErrPromoNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if (pc.ValidFrom != nil && now.Before(*pc.ValidFrom)) || (pc.ValidUntil != nil && now.After(*pc.ValidUntil)) {
+			return storage.ErrPromoInactive
+		}
+
+		var used int64
+		err = tx.Model(&PromoRedemption{}).
+			Where("promo_code_id = ? AND telegram_id = ?", pc.ID, telegramID).
+			Count(&used).Error
+		if err != nil {
+			return err
+		}
+		if used >= int64(pc.PerUserLimit) {
+			return storage.ErrPromoAlreadyRedeemed
+		}
+
+		// счетчик увеличивается условно, чтобы параллельные активации не превысили лимит
+		res := tx.Model(&PromoCode{}).
+			Where("id = ? AND (max_redemptions = 0 OR redemptions < max_redemptions)", pc.ID).
+			UpdateColumn("redemptions", gorm.Expr("redemptions + 1"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return storage.ErrPromoExhausted
+		}
+
+		redemption = PromoRedemption{
+			PromoCodeID: pc.ID,
+			TelegramID:  telegramID,
+			Seq:         int(used) + 1,
+			Code:        pc.Code,
+			RewardType:  pc.RewardType,
+			Amount:      pc.Amount,
+			Entitlement: pc.Entitlement,
+		}
+
+		switch pc.RewardType {
+		case promo.RewardBalance:
+			if err := creditBalance(tx, telegramID, pc.Amount); err != nil {
+				return err
+			}
+		case promo.RewardEntitlement:
+			until, err := grantEntitlement(tx, telegramID, pc.Entitlement, pc.EntitlementDays, now)
+			if err != nil {
+				return err
+			}
+			redemption.EntitlementUntil = until
+		default:
+			return fmt.Errorf("unknown reward type %q", pc.RewardType)
+		}
+
+		// параллельная активация тем же пользователем успела занять этот номер
+		res = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&redemption)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return storage.ErrPromoAlreadyRedeemed
+		}
+
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound),
+		errors.Is(err, storage.ErrUserBlocked),
+		errors.Is(err, storage.ErrPromoNotFound),
+		errors.Is(err, storage.ErrPromoInactive),
+		errors.Is(err, storage.ErrPromoExhausted),
+		errors.Is(err, storage.ErrPromoAlreadyRedeemed):
+		return nil, nil, err
+	case err != nil:
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := loadUser(s.db, telegramID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &redemption, user, nil
+}
+
+// grantEntitlement - выдает или продлевает доступ на days дней (0 - навсегда) внутри транзакции tx;
+// действующий доступ продлевается от даты его окончания. Возвращает новую дату окончания
+func grantEntitlement(tx *gorm.DB, telegramID int64, name string, days int, now time.Time) (*time.Time, error) {
+	var ent Entitlement
+	err := tx.Where("telegram_id = ? AND name = ?", telegramID, name).First(&ent).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	found := err == nil
+
+	if found && ent.ExpiresAt == nil {
+		// уже бессрочный
+		return nil, nil
+	}
+
+	var expiresAt *time.Time
+	if days > 0 {
+		from := now
+		if found && ent.ExpiresAt.After(now) {
+			from = *ent.ExpiresAt
+		}
+		until := from.AddDate(0, 0, days)
+		expiresAt = &until
+	}
+
+	if !found {
+		ent = Entitlement{TelegramID: telegramID, Name: name, ExpiresAt: expiresAt}
+		return expiresAt, tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ent).Error
+	}
+
+	return expiresAt, tx.Model(&ent).Update("expires_at", expiresAt).Error
+}
@@ -0,0 +1,115 @@
+package db_test
+
+import (
+	"errors"
+	"path/filepath"
+	"taro-api/internal/lib/promo"
+	"taro-api/internal/storage"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRedeemPromoCodePerUserLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	s := newStorage(t, path)
+
+	if _, _, err := s.RegisterUser(42, "main", 0); err != nil {
+		t.Fatal(err)
+	}
+	_, err := s.CreatePromoCodes([]string{"TWICE"}, promo.Options{
+		RewardType:   promo.RewardBalance,
+		Amount:       5,
+		PerUserLimit: 2,
+	}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for seq := 1; seq <= 2; seq++ {
+		redemption, user, err := s.RedeemPromoCode(42, "twice")
+		if err != nil {
+			t.Fatalf("redemption %d: %v", seq, err)
+		}
+		if redemption.Seq != seq {
+			t.Errorf("redemption %d: seq = %d", seq, redemption.Seq)
+		}
+		if want := int64(5 * seq); user.Balance != want {
+			t.Errorf("redemption %d: balance = %d, want %d", seq, user.Balance, want)
+		}
+	}
+
+	if _, _, err := s.RedeemPromoCode(42, "TWICE"); !errors.Is(err, storage.ErrPromoAlreadyRedeemed) {
+		t.Fatalf("third redemption: err = %v, want ErrPromoAlreadyRedeemed", err)
+	}
+
+	// активация, посчитавшая прежние до вставки параллельной, получит тот же номер и не вставится
+	conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := conn.DB()
+	defer sqlDB.Close()
+
+	err = conn.Exec(`INSERT INTO promo_redemptions (id, promo_code_id, telegram_id, seq)
+		SELECT '00000000-0000-0000-0000-000000000001', promo_code_id, telegram_id, seq
+		FROM promo_redemptions WHERE seq = 2`).Error
+	if err == nil {
+		t.Error("redemption with a duplicate seq was inserted")
+	}
+}
+
+func TestNumberPromoRedemptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	// база до нумерации активаций: пользователь 42 активировал код дважды
+	conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Exec(`CREATE TABLE promo_redemptions (id text PRIMARY KEY, created_at datetime,
+		promo_code_id text, telegram_id integer, code text)`).Error
+	if err == nil {
+		err = conn.Exec(`INSERT INTO promo_redemptions (id, created_at, promo_code_id, telegram_id, code) VALUES
+			('00000000-0000-0000-0000-000000000002', '2024-01-02 00:00:00', 'p1', 42, 'A'),
+			('00000000-0000-0000-0000-000000000001', '2024-01-01 00:00:00', 'p1', 42, 'A'),
+			('00000000-0000-0000-0000-000000000003', '2024-01-01 00:00:00', 'p1', 43, 'A')`).Error
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := conn.DB()
+	sqlDB.Close()
+
+	newStorage(t, path)
+
+	conn, err = gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ = conn.DB()
+	defer sqlDB.Close()
+
+	var rows []struct {
+		ID  string
+		Seq int
+	}
+	if err := conn.Raw("SELECT id, seq FROM promo_redemptions ORDER BY id").Scan(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{
+		"00000000-0000-0000-0000-000000000001": 1,
+		"00000000-0000-0000-0000-000000000002": 2,
+		"00000000-0000-0000-0000-000000000003": 1,
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %+v", rows)
+	}
+	for _, row := range rows {
+		if row.Seq != want[row.ID] {
+			t.Errorf("redemption %s: seq = %d, want %d", row.ID, row.Seq, want[row.ID])
+		}
+	}
+}
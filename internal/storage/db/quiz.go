@@ -0,0 +1,197 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"taro-api/internal/lib/quiz"
+	"taro-api/internal/lib/tarot"
+	"taro-api/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuizAnswer - проверенный ответ на вопрос карточки
+type QuizAnswer struct {
+	Response string
+	Correct  bool
+	// Quality - оценка ответа по шкале SM-2
+	Quality int
+}
+
+// QuizOutcome - карточка после ответа и достижения, полученные этим ответом
+type QuizOutcome struct {
+	Item         quiz.Item
+	Achievements []Achievement
+}
+
+// GetOpenQuizQuestion - последний вопрос пользователя, на который он еще не ответил
+func (s *Storage) GetOpenQuizQuestion(telegramID int64) (*QuizQuestion, error) {
+	const op = "storage.db.GetOpenQuizQuestion"
+
+	var q QuizQuestion
+	err := s.db.Where("telegram_id = ? AND answered_at IS NULL", telegramID).
+		Order("created_at DESC").First(&q).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, storage.ErrQuizNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &q, nil
+}
+
+// GetQuizQuestion - вопрос пользователя по ID; чужой вопрос не находится
+func (s *Storage) GetQuizQuestion(id uuid.UUID, telegramID int64) (*QuizQuestion, error) {
+	const op = "storage.db.GetQuizQuestion"
+
+	var q QuizQuestion
+	err := s.db.Where("id = ? AND telegram_id = ?", id, telegramID).First(&q).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, storage.ErrQuizNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &q, nil
+}
+
+// CreateQuizQuestion - сохраняет вопрос зарегистрированного пользователя, ID заполняется при создании
+func (s *Storage) CreateQuizQuestion(q *QuizQuestion) error {
+	const op = "storage.db.CreateQuizQuestion"
+
+	var count int64
+	if err := s.db.Model(&User{}).Where("telegram_id = ?", q.TelegramID).Count(&count).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if count == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	if err := s.db.Create(q).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetQuizItems - карточки пользователя с расписанием повторений
+func (s *Storage) GetQuizItems(telegramID int64) ([]quiz.Item, error) {
+	const op = "storage.db.GetQuizItems"
+
+	items, err := quizItems(s.db, telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return items, nil
+}
+
+// AnswerQuiz - сохраняет проверенный ответ и переносит повторение карточки в одной транзакции.
+// Если ответ по старшему аркану сделал выученными все старшие арканы, выдает достижение
+func (s *Storage) AnswerQuiz(id uuid.UUID, telegramID int64, a QuizAnswer) (*QuizOutcome, error) {
+	const op = "storage.db.AnswerQuiz"
+
+	var outcome QuizOutcome
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var q QuizQuestion
+		err := tx.Where("id = ? AND telegram_id = ?", id, telegramID).First(&q).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return storage.ErrQuizNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// ответ сохраняется условно, чтобы повторная отправка не засчитала карточку дважды
+		res := tx.Model(&QuizQuestion{}).
+			Where("id = ? AND answered_at IS NULL", id).
+			Updates(map[string]any{"response": a.Response, "correct": a.Correct, "answered_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return storage.ErrQuizAnswered
+		}
+
+		qc := QuizCard{TelegramID: telegramID, Card: q.Card, Kind: q.Kind}
+		err = tx.Where("telegram_id = ? AND card = ? AND kind = ?", telegramID, q.Card, q.Kind).First(&qc).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		item := quiz.Review(qc.item(), a.Quality, now)
+		qc.Repetitions, qc.Interval, qc.Ease, qc.DueAt = item.Repetitions, item.Interval, item.Ease, item.DueAt
+		qc.Reviews++
+		if !a.Correct {
+			qc.Lapses++
+		}
+		if err := tx.Save(&qc).Error; err != nil {
+			return err
+		}
+		outcome.Item = item
+
+		card, _ := tarot.ByID(q.Card)
+		if card.Arcana != tarot.Major || !item.Mastered() {
+			return nil
+		}
+
+		items, err := quizItems(tx, telegramID)
+		if err != nil {
+			return err
+		}
+		if !quiz.MajorArcanaMastered(items) {
+			return nil
+		}
+
+		achievement := Achievement{TelegramID: telegramID, Code: quiz.AchievementMajorArcana}
+		res = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&achievement)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			outcome.Achievements = append(outcome.Achievements, achievement)
+		}
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, storage.ErrQuizNotFound), errors.Is(err, storage.ErrQuizAnswered):
+		return nil, err
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &outcome, nil
+}
+
+func (qc QuizCard) item() quiz.Item {
+	return quiz.Item{
+		Card:        qc.Card,
+		Kind:        quiz.Kind(qc.Kind),
+		Repetitions: qc.Repetitions,
+		Interval:    qc.Interval,
+		Ease:        qc.Ease,
+		DueAt:       qc.DueAt,
+	}
+}
+
+func quizItems(tx *gorm.DB, telegramID int64) ([]quiz.Item, error) {
+	var cards []QuizCard
+	if err := tx.Where("telegram_id = ?", telegramID).Order("due_at").Find(&cards).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]quiz.Item, 0, len(cards))
+	for _, c := range cards {
+		items = append(items, c.item())
+	}
+	return items, nil
+}
@@ -0,0 +1,109 @@
+package db_test
+
+import (
+	"errors"
+	"path/filepath"
+	"taro-api/internal/lib/quiz"
+	"taro-api/internal/lib/tarot"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAnswerQuiz(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	s := newStorage(t, path)
+
+	if _, _, err := s.RegisterUser(42, "main", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := conn.DB()
+	defer sqlDB.Close()
+
+	// выучены все старшие арканы, кроме одной карточки шута
+	for _, c := range tarot.Deck() {
+		if c.Arcana != tarot.Major {
+			continue
+		}
+		for _, k := range quiz.Kinds {
+			qc := db.QuizCard{TelegramID: 42, Card: c.ID, Kind: string(k), Repetitions: 3, Interval: 15,
+				Ease: 2.5, DueAt: time.Now().AddDate(0, 0, 15)}
+			if c.ID == "fool" && k == quiz.KindCardMeaning {
+				qc.Repetitions, qc.Interval = 2, 6
+			}
+			if err := conn.Create(&qc).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	newQuestion := func() *db.QuizQuestion {
+		t.Helper()
+		q := &db.QuizQuestion{TelegramID: 42, Kind: string(quiz.KindCardMeaning), Format: string(quiz.FormatChoice),
+			Card: "fool", Options: []db.ReadingCard{{Card: "fool"}, {Card: "magician"}}}
+		if err := s.CreateQuizQuestion(q); err != nil {
+			t.Fatal(err)
+		}
+		return q
+	}
+	answer := db.QuizAnswer{Response: "0", Correct: true, Quality: quiz.QualityCorrect}
+
+	q := newQuestion()
+	if _, err := s.AnswerQuiz(q.ID, 43, answer); !errors.Is(err, storage.ErrQuizNotFound) {
+		t.Errorf("answer of another user: err = %v, want ErrQuizNotFound", err)
+	}
+
+	outcome, err := s.AnswerQuiz(q.ID, 42, answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Item.Repetitions != 3 || outcome.Item.Interval != 15 {
+		t.Errorf("item = %+v, want 3 repetitions in 15 days", outcome.Item)
+	}
+	if len(outcome.Achievements) != 1 || outcome.Achievements[0].Code != quiz.AchievementMajorArcana {
+		t.Errorf("achievements = %+v, want %s", outcome.Achievements, quiz.AchievementMajorArcana)
+	}
+
+	// повторная отправка не засчитывает карточку дважды
+	if _, err := s.AnswerQuiz(q.ID, 42, answer); !errors.Is(err, storage.ErrQuizAnswered) {
+		t.Errorf("second answer: err = %v, want ErrQuizAnswered", err)
+	}
+
+	// следующий верный ответ не выдает достижение повторно
+	outcome, err = s.AnswerQuiz(newQuestion().ID, 42, answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Item.Repetitions != 4 || len(outcome.Achievements) != 0 {
+		t.Errorf("outcome = %+v, want 4 repetitions without achievements", outcome)
+	}
+
+	var card db.QuizCard
+	if err := conn.Where("telegram_id = 42 AND card = 'fool' AND kind = ?", quiz.KindCardMeaning).First(&card).Error; err != nil {
+		t.Fatal(err)
+	}
+	if card.Reviews != 2 || card.Lapses != 0 {
+		t.Errorf("card = %d reviews, %d lapses; want 2, 0", card.Reviews, card.Lapses)
+	}
+
+	var achievements int64
+	if err := conn.Model(&db.Achievement{}).Where("telegram_id = 42").Count(&achievements).Error; err != nil {
+		t.Fatal(err)
+	}
+	if achievements != 1 {
+		t.Errorf("achievements = %d, want 1", achievements)
+	}
+
+	if err := s.CreateQuizQuestion(&db.QuizQuestion{TelegramID: 43, Card: "fool"}); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("question for unknown user: err = %v, want ErrUserNotFound", err)
+	}
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"taro-api/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateReading - сохраняет расклад, ID заполняется при создании
+func (s *Storage) CreateReading(r *Reading) error {
+	const op = "storage.db.CreateReading"
+
+	if err := s.db.Create(r).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetReading - расклад по ID
+func (s *Storage) GetReading(id uuid.UUID) (*Reading, error) {
+	const op = "storage.db.GetReading"
+
+	var r Reading
+	err := s.db.Where("id = ?", id).First(&r).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, storage.ErrReadingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &r, nil
+}
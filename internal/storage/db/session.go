@@ -0,0 +1,212 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"taro-api/internal/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateSession - создает сессию пользователя
+func (s *Storage) CreateSession(session *Session) error {
+	const op = "storage.db.CreateSession"
+
+	blocked, err := s.IsUserBlocked(session.TelegramID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if blocked {
+		return storage.ErrUserBlocked
+	}
+
+	if err := s.db.Create(session).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// errRefreshReused - refresh токен уже был заменен: его предъявил кто-то еще
+var errRefreshReused = errors.New("refresh token reused")
+
+// RotateSession - меняет refresh токен активной сессии на новый. Токен одноразовый: из
+// параллельных замен одним токеном проходит одна, а повторное предъявление уже замененного
+// токена отзывает всю сессию (ErrSessionNotFound в обоих случаях)
+func (s *Storage) RotateSession(refreshHash, newRefreshHash string, expiresAt time.Time) (*Session, error) {
+	const op = "storage.db.RotateSession"
+
+	var session Session
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("refresh_hash = ? AND revoked_at IS NULL AND expires_at > ?", refreshHash, time.Now()).
+			First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = tx.Select("id").Where("previous_refresh_hash = ? AND revoked_at IS NULL", refreshHash).
+				First(&session).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return storage.ErrSessionNotFound
+			}
+			if err != nil {
+				return err
+			}
+			return errRefreshReused
+		}
+		if err != nil {
+			return err
+		}
+
+		var user User
+		err = tx.Select("blocked").Where("telegram_id = ?", session.TelegramID).First(&user).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if user.Blocked {
+			return storage.ErrUserBlocked
+		}
+
+		// условие на старый хэш: параллельная замена тем же токеном не пройдет
+		res := tx.Model(&Session{}).
+			Where("id = ? AND refresh_hash = ? AND revoked_at IS NULL", session.ID, refreshHash).
+			Updates(map[string]any{
+				"refresh_hash":          newRefreshHash,
+				"previous_refresh_hash": refreshHash,
+				"expires_at":            expiresAt,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errRefreshReused
+		}
+
+		session.RefreshHash = newRefreshHash
+		session.PreviousRefreshHash = refreshHash
+		session.ExpiresAt = expiresAt
+
+		return nil
+	})
+
+	if errors.Is(err, errRefreshReused) {
+		// отзыв вне транзакции замены: ее ошибка откатила бы и его
+		if err := s.RevokeSession(session.ID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return nil, storage.ErrSessionNotFound
+	}
+	if errors.Is(err, storage.ErrSessionNotFound) || errors.Is(err, storage.ErrUserBlocked) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &session, nil
+}
+
+// RevokeSession - отзывает сессию
+func (s *Storage) RevokeSession(id uuid.UUID) error {
+	const op = "storage.db.RevokeSession"
+
+	if err := s.db.Model(&Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions - отзывает все сессии пользователя
+func (s *Storage) RevokeUserSessions(telegramID int64) error {
+	const op = "storage.db.RevokeUserSessions"
+
+	if err := s.db.Model(&Session{}).
+		Where("telegram_id = ? AND revoked_at IS NULL", telegramID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SessionAccess - проверяет, что сессия не отозвана и не истекла, а пользователь не заблокирован
+func (s *Storage) SessionAccess(id uuid.UUID) (storage.Access, error) {
+	const op = "storage.db.SessionAccess"
+
+	var access storage.Access
+	err := s.db.Raw(`SELECT true AS allowed, COALESCE(users.language, '') AS language
+		FROM sessions
+		LEFT JOIN users ON users.telegram_id = sessions.telegram_id
+		WHERE sessions.id = ? AND sessions.revoked_at IS NULL AND sessions.expires_at > ?
+			AND COALESCE(users.blocked, false) = false
+		LIMIT 1`, id, time.Now()).Scan(&access).Error
+	if err != nil {
+		return storage.Access{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return access, nil
+}
+
+// UserAccess - проверяет, что пользователь не заблокирован; незарегистрированный пользователь
+// допускается
+func (s *Storage) UserAccess(telegramID int64) (storage.Access, error) {
+	const op = "storage.db.UserAccess"
+
+	var user User
+	err := s.db.Model(&User{}).Select("blocked", "language").Where("telegram_id = ?", telegramID).
+		Limit(1).Scan(&user).Error
+	if err != nil {
+		return storage.Access{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return storage.Access{Allowed: !user.Blocked, Language: user.Language}, nil
+}
+
+// IsUserBlocked - проверяет, заблокирован ли пользователь
+func (s *Storage) IsUserBlocked(telegramID int64) (bool, error) {
+	const op = "storage.db.IsUserBlocked"
+
+	var blocked bool
+	err := s.db.Raw("SELECT EXISTS(SELECT 1 FROM users WHERE telegram_id = ? AND blocked = true) AS found",
+		telegramID).Scan(&blocked).Error
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return blocked, nil
+}
+
+// SetUserBlocked - блокирует или разблокирует пользователя, при блокировке отзывает его сессии
+func (s *Storage) SetUserBlocked(telegramID int64, blocked bool) error {
+	const op = "storage.db.SetUserBlocked"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&User{}).Where("telegram_id = ?", telegramID).Update("blocked", blocked)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return storage.ErrUserNotFound
+		}
+
+		if !blocked {
+			return nil
+		}
+
+		return tx.Model(&Session{}).
+			Where("telegram_id = ? AND revoked_at IS NULL", telegramID).
+			Update("revoked_at", time.Now()).Error
+	})
+
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
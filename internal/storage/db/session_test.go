@@ -0,0 +1,50 @@
+package db_test
+
+import (
+	"errors"
+	"path/filepath"
+	"taro-api/internal/storage"
+	"taro-api/internal/storage/db"
+	"testing"
+	"time"
+)
+
+func TestRotateSessionSingleUse(t *testing.T) {
+	s := newStorage(t, filepath.Join(t.TempDir(), "test.db"))
+
+	if _, _, err := s.RegisterUser(42, "main", 0); err != nil {
+		t.Fatal(err)
+	}
+	session := &db.Session{TelegramID: 42, RefreshHash: "first", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := s.CreateSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := s.RotateSession("first", "second", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.ID != session.ID || rotated.RefreshHash != "second" {
+		t.Errorf("rotated = %s %q, want %s %q", rotated.ID, rotated.RefreshHash, session.ID, "second")
+	}
+
+	// замененный токен предъявлен снова: его украли, сессия отзывается целиком
+	if _, err := s.RotateSession("first", "stolen", time.Now().Add(time.Hour)); !errors.Is(err, storage.ErrSessionNotFound) {
+		t.Fatalf("reused token: err = %v, want ErrSessionNotFound", err)
+	}
+	if _, err := s.RotateSession("second", "third", time.Now().Add(time.Hour)); !errors.Is(err, storage.ErrSessionNotFound) {
+		t.Errorf("current token after reuse: err = %v, want ErrSessionNotFound", err)
+	}
+
+	access, err := s.SessionAccess(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if access.Allowed {
+		t.Error("session is still active after refresh token reuse")
+	}
+
+	if _, err := s.RotateSession("unknown", "other", time.Now().Add(time.Hour)); !errors.Is(err, storage.ErrSessionNotFound) {
+		t.Errorf("unknown token: err = %v, want ErrSessionNotFound", err)
+	}
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"taro-api/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+// SettingsUpdate - изменения настроек пользователя, nil - поле не меняется
+type SettingsUpdate struct {
+	DisplayName     *string
+	Language        *string
+	Timezone        *string
+	BirthDate       *string
+	NotifyDaily     *bool
+	NotifyDailyTime *string
+	NotifyNews      *bool
+	PreferredDeck   *string
+}
+
+// GetUserLanguage - язык, выбранный пользователем, пусто - не выбран или пользователя нет
+func (s *Storage) GetUserLanguage(telegramID int64) (string, error) {
+	const op = "storage.db.GetUserLanguage"
+
+	var language string
+	err := s.db.Model(&User{}).Select("language").Where("telegram_id = ?", telegramID).
+		Limit(1).Scan(&language).Error
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return language, nil
+}
+
+// GetUserSettings - пользователь с профилем и настройками
+func (s *Storage) GetUserSettings(telegramID int64) (*User, error) {
+	const op = "storage.db.GetUserSettings"
+
+	var user User
+	err := s.db.Where("telegram_id = ?", telegramID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, storage.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &user, nil
+}
+
+// UpdateUserSettings - меняет заданные поля настроек и возвращает пользователя с новыми значениями
+func (s *Storage) UpdateUserSettings(telegramID int64, upd SettingsUpdate) (*User, error) {
+	const op = "storage.db.UpdateUserSettings"
+
+	changes := make(map[string]any)
+	set := func(column string, ok bool, value any) {
+		if ok {
+			changes[column] = value
+		}
+	}
+	set("display_name", upd.DisplayName != nil, deref(upd.DisplayName))
+	set("language", upd.Language != nil, deref(upd.Language))
+	set("timezone", upd.Timezone != nil, deref(upd.Timezone))
+	set("birth_date", upd.BirthDate != nil, deref(upd.BirthDate))
+	set("notify_daily", upd.NotifyDaily != nil, deref(upd.NotifyDaily))
+	set("notify_daily_time", upd.NotifyDailyTime != nil, deref(upd.NotifyDailyTime))
+	set("notify_news", upd.NotifyNews != nil, deref(upd.NotifyNews))
+	set("preferred_deck", upd.PreferredDeck != nil, deref(upd.PreferredDeck))
+
+	var user User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("telegram_id = ?", telegramID).First(&user).Error; err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		return tx.Model(&user).Updates(changes).Error
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, storage.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &user, nil
+}
+
+func deref[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
@@ -13,15 +13,302 @@ type User struct {
 	ID                   uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	CreatedAt            time.Time `json:"created_at"`
 	UpdatedAt            time.Time `json:"updated_at"`
-	TelegramID           int64     `gorm:"unique_index" json:"telegram_id"`
+	TelegramID           int64     `gorm:"uniqueIndex" json:"telegram_id"`
 	Balance              int64     `json:"balance"`
 	Role                 string    `json:"role"`
 	PhotoURL             string    `json:"photo_url"`
 	ReferrerID           int64     `json:"referrer,omitempty"`
 	Referrals            *[]User   `gorm:"foreignKey:ReferrerID;references:TelegramID" json:"referrals,omitempty"`
 	ReferralBonusApplied bool      `json:"referral_bonus_applied"`
+	Blocked              bool      `json:"blocked"`
+	// Bot - имя бота, через который пользователь пришел впервые
+	Bot string `gorm:"index" json:"bot"`
+	// Language - язык, выбранный пользователем в настройках, пусто - язык Telegram
+	Language string `json:"language"`
+	// Профиль и настройки Mini App
+	DisplayName     string `json:"display_name"`
+	Timezone        string `json:"timezone"`
+	BirthDate       string `json:"birth_date"` // YYYY-MM-DD, пусто - не указана
+	NotifyDaily     bool   `gorm:"default:true" json:"notify_daily"`
+	NotifyDailyTime string `gorm:"default:09:00" json:"notify_daily_time"`
+	NotifyNews      bool   `gorm:"default:true" json:"notify_news"`
+	PreferredDeck   string `json:"preferred_deck"`
+	// Серия дней подряд с тренировкой в чтении раскладов и последний такой день (YYYY-MM-DD
+	// в часовом поясе пользователя)
+	PracticeStreak     int    `json:"practice_streak"`
+	PracticeBestStreak int    `json:"practice_best_streak"`
+	PracticeDay        string `json:"practice_day"`
+	// Профиль Telegram, обновляется из init data не чаще раза в несколько минут
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	Username         string     `gorm:"index" json:"username"`
+	LanguageCode     string     `json:"language_code"`
+	IsPremium        bool       `json:"is_premium"`
+	TelegramPhotoURL string     `json:"telegram_photo_url"`
+	ProfileSyncedAt  *time.Time `json:"profile_synced_at,omitempty"`
+	// BotBlocked - пользователь заблокировал бота, рассылки его пропускают до следующего /start
+	BotBlocked   bool       `json:"bot_blocked"`
+	BotBlockedAt *time.Time `json:"bot_blocked_at,omitempty"`
+	// Entitlements - действующие доступы, загружаются вместе с пользователем
+	Entitlements []Entitlement `gorm:"foreignKey:TelegramID;references:TelegramID" json:"entitlements,omitempty"`
 }
 
+// UsernameChange - смена username в Telegram, по истории админы находят пользователя по старому имени
+type UsernameChange struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	TelegramID  int64     `gorm:"index" json:"telegram_id"`
+	OldUsername string    `gorm:"index" json:"old_username"`
+	NewUsername string    `json:"new_username"`
+}
+
+// Session - сессия пользователя, выданная в обмен на init data
+type Session struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	TelegramID  int64     `gorm:"index" json:"telegram_id"`
+	RefreshHash string    `gorm:"uniqueIndex" json:"-"`
+	// PreviousRefreshHash - refresh токен до последней замены: его повторное предъявление
+	// означает, что токен украден, и сессия отзывается
+	PreviousRefreshHash string `gorm:"index" json:"-"`
+	UserAgent           string `json:"user_agent"`
+	// Снимок профиля Telegram на момент входа, попадает в токены доступа
+	FirstName    string     `json:"first_name"`
+	LastName     string     `json:"last_name"`
+	Username     string     `json:"username"`
+	LanguageCode string     `json:"language_code"`
+	IsPremium    bool       `json:"is_premium"`
+	Bot          string     `json:"bot"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IdempotencyKey - ключ Idempotency-Key с отпечатком запроса и ответом на него
+type IdempotencyKey struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	TelegramID int64     `gorm:"uniqueIndex:idx_idempotency_user_key" json:"telegram_id"`
+	Key        string    `gorm:"uniqueIndex:idx_idempotency_user_key" json:"key"`
+	// Fingerprint - хэш метода, пути и тела запроса
+	Fingerprint string `json:"fingerprint"`
+	// Completed - ответ сохранен; до этого запрос считается выполняющимся
+	Completed   bool                `json:"completed"`
+	Status      int                 `json:"status"`
+	ContentType string              `json:"content_type"`
+	Header      map[string][]string `gorm:"serializer:json" json:"-"`
+	Body        []byte              `json:"-"`
+	ExpiresAt   time.Time           `gorm:"index" json:"expires_at"`
+}
+
+// PromoCode - промокод или подарочный код
+type PromoCode struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Code      string    `gorm:"uniqueIndex" json:"code"`
+	// Batch - выпуск, в котором создан код
+	Batch string `gorm:"index" json:"batch"`
+	// RewardType - promo.RewardBalance или promo.RewardEntitlement
+	RewardType  string `json:"reward_type"`
+	Amount      int64  `json:"amount"`
+	Entitlement string `json:"entitlement"`
+	// EntitlementDays - срок доступа, 0 - навсегда
+	EntitlementDays int `json:"entitlement_days"`
+	// MaxRedemptions - 0 - без ограничения
+	MaxRedemptions int        `json:"max_redemptions"`
+	PerUserLimit   int        `json:"per_user_limit"`
+	Redemptions    int        `json:"redemptions"`
+	ValidFrom      *time.Time `json:"valid_from,omitempty"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty"`
+	CreatedBy      int64      `json:"created_by"`
+}
+
+// PromoRedemption - активация кода пользователем, награда сохраняется на момент активации
+type PromoRedemption struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	PromoCodeID uuid.UUID `gorm:"type:uuid;index;uniqueIndex:idx_promo_redemption_seq" json:"promo_code_id"`
+	TelegramID  int64     `gorm:"index;uniqueIndex:idx_promo_redemption_seq" json:"telegram_id"`
+	// Seq - номер активации кода этим пользователем, с 1; уникальный индекс не дает
+	// параллельным активациям превысить PerUserLimit
+	Seq              int        `gorm:"uniqueIndex:idx_promo_redemption_seq" json:"seq"`
+	Code             string     `json:"code"`
+	RewardType       string     `json:"reward_type"`
+	Amount           int64      `json:"amount"`
+	Entitlement      string     `json:"entitlement"`
+	EntitlementUntil *time.Time `json:"entitlement_until,omitempty"`
+}
+
+// Entitlement - доступ пользователя к платной возможности
+type Entitlement struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	TelegramID int64     `gorm:"uniqueIndex:idx_entitlement_user_name" json:"telegram_id"`
+	Name       string    `gorm:"uniqueIndex:idx_entitlement_user_name" json:"name"`
+	// ExpiresAt - nil - бессрочно
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Статусы рассылки
+const (
+	BroadcastDraft     = "draft"
+	BroadcastRunning   = "running"
+	BroadcastPaused    = "paused"
+	BroadcastCancelled = "cancelled"
+	BroadcastDone      = "done"
+)
+
+// Статусы доставки получателю. DeliverySending - отправка начата, но результат не сохранен:
+// такому получателю сообщение повторно не отправляется, чтобы он не получил его дважды
+const (
+	DeliveryPending = "pending"
+	DeliverySending = "sending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+	DeliveryBlocked = "blocked"
+)
+
+// BroadcastSegment - кому отправляется рассылка, пустые условия не ограничивают
+type BroadcastSegment struct {
+	// Language - язык пользователя: выбранный в настройках, иначе язык Telegram
+	Language     string `json:"language"`
+	HasBalance   bool   `json:"has_balance"`
+	HasReferrals bool   `json:"has_referrals"`
+	// ActiveDays - заходил в Mini App за последние N дней, 0 - без ограничения
+	ActiveDays int `json:"active_days"`
+}
+
+// Broadcast - рассылка администратора: копия сообщения SourceMessageID из чата SourceChatID
+type Broadcast struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedBy int64     `json:"created_by"`
+	Bot       string    `json:"bot"`
+	// IncludeUntagged - отправлять и пользователям без бота (пришли до появления нескольких ботов)
+	IncludeUntagged bool             `json:"include_untagged"`
+	SourceChatID    int64            `json:"source_chat_id"`
+	SourceMessageID int              `json:"source_message_id"`
+	Segment         BroadcastSegment `gorm:"embedded;embeddedPrefix:segment_" json:"segment"`
+	Status          string           `gorm:"index" json:"status"`
+	Total           int              `json:"total"`
+	Sent            int              `json:"sent"`
+	Failed          int              `json:"failed"`
+	Blocked         int              `json:"blocked"`
+	StartedAt       *time.Time       `json:"started_at,omitempty"`
+	FinishedAt      *time.Time       `json:"finished_at,omitempty"`
+}
+
+// BroadcastRecipient - получатель рассылки и статус доставки ему
+type BroadcastRecipient struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	BroadcastID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_broadcast_recipient;index:idx_broadcast_status,priority:1" json:"broadcast_id"`
+	TelegramID  int64     `gorm:"uniqueIndex:idx_broadcast_recipient" json:"telegram_id"`
+	Status      string    `gorm:"index:idx_broadcast_status,priority:2" json:"status"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Reading - расклад пользователя: карты по позициям расклада
+type Reading struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	TelegramID int64     `gorm:"index" json:"telegram_id"`
+	// Spread - идентификатор расклада из tarot.Spreads
+	Spread   string        `json:"spread"`
+	Question string        `json:"question"`
+	Cards    []ReadingCard `gorm:"serializer:json" json:"cards"`
+	// Source - где сделан расклад: ReadingSourceBot или ReadingSourceApp
+	Source string `json:"source"`
+}
+
+// ReadingCard - карта на позиции расклада (позиции по порядку)
+type ReadingCard struct {
+	Card     string `json:"card"`
+	Reversed bool   `json:"reversed"`
+}
+
+// Источники раскладов
+const (
+	ReadingSourceBot = "bot"
+	ReadingSourceApp = "app"
+)
+
+// Practice - тренировочный расклад: пользователь толкует карты каждой позиции,
+// толкования оцениваются по ключевым словам значений
+type Practice struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// TelegramID - у пользователя не больше одного неотвеченного расклада
+	TelegramID int64 `gorm:"index;uniqueIndex:idx_practice_open,where:answered_at IS NULL" json:"telegram_id"`
+	// Spread и Question - идентификаторы расклада из tarot.Spreads и вопроса из practice
+	Spread   string        `json:"spread"`
+	Question string        `json:"question"`
+	Cards    []ReadingCard `gorm:"serializer:json" json:"cards"`
+	// Answers - толкования по позициям, Score - средняя оценка от 0 до 100
+	Answers    []string   `gorm:"serializer:json" json:"answers"`
+	Score      int        `json:"score"`
+	AnsweredAt *time.Time `json:"answered_at,omitempty"`
+}
+
+// PracticeCard - успехи пользователя в толковании карты и срок ее повторения
+type PracticeCard struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	TelegramID int64     `gorm:"uniqueIndex:idx_practice_card" json:"telegram_id"`
+	Card       string    `gorm:"uniqueIndex:idx_practice_card" json:"card"`
+	Attempts   int       `json:"attempts"`
+	Correct    int       `json:"correct"`
+	// Box - ящик Лейтнера, см. practice.Schedule
+	Box   int       `json:"box"`
+	DueAt time.Time `json:"due_at"`
+}
+
+// QuizQuestion - вопрос карточки, выданный пользователю. Хранится, чтобы ответ
+// проверялся по тому вопросу, который пользователь видел
+type QuizQuestion struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	TelegramID int64     `gorm:"index" json:"telegram_id"`
+	// Kind и Format - вид вопроса и формат ответа из quiz
+	Kind     string `json:"kind"`
+	Format   string `json:"format"`
+	Card     string `json:"card"`
+	Reversed bool   `json:"reversed"`
+	// Options и Answer - варианты ответа и номер верного, только для вопросов с выбором
+	Options []ReadingCard `gorm:"serializer:json" json:"options"`
+	Answer  int           `json:"answer"`
+	// Response - ответ пользователя: номер варианта или текст
+	Response   string     `json:"response"`
+	Correct    bool       `json:"correct"`
+	AnsweredAt *time.Time `json:"answered_at,omitempty"`
+}
+
+// QuizCard - карточка пользователя (вопросы одного вида по одной карте) и ее расписание SM-2
+type QuizCard struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	TelegramID  int64     `gorm:"uniqueIndex:idx_quiz_card" json:"telegram_id"`
+	Card        string    `gorm:"uniqueIndex:idx_quiz_card" json:"card"`
+	Kind        string    `gorm:"uniqueIndex:idx_quiz_card" json:"kind"`
+	Repetitions int       `json:"repetitions"`
+	// Interval - в днях, Ease - коэффициент легкости SM-2
+	Interval int       `json:"interval"`
+	Ease     float64   `json:"ease"`
+	DueAt    time.Time `json:"due_at"`
+	Reviews  int       `json:"reviews"`
+	Lapses   int       `json:"lapses"`
+}
+
+// Achievement - полученное пользователем достижение, каждое выдается один раз
+type Achievement struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	TelegramID int64     `gorm:"uniqueIndex:idx_achievement" json:"telegram_id"`
+	Code       string    `gorm:"uniqueIndex:idx_achievement" json:"code"`
+}
 
 // BeforeCreate - генерируем UUIDv4 для новой записи
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -33,6 +320,84 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// BeforeCreate - генерируем UUIDv4 для новой сессии
+func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	if s.TelegramID == 0 || s.RefreshHash == "" {
+		err = errors.New("can't save invalid data")
+	}
+	return
+}
+
+// BeforeCreate - генерируем UUIDv4 для записи истории
+func (c *UsernameChange) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return
+}
+
+// BeforeCreate - генерируем UUIDv4 для ключа идемпотентности
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) (err error) {
+	k.ID = uuid.New()
+	if k.TelegramID == 0 || k.Key == "" {
+		err = errors.New("can't save invalid data")
+	}
+	return
+}
+
+// BeforeCreate - генерируем UUIDv4 для промокода
+func (p *PromoCode) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.New()
+	if p.Code == "" {
+		err = errors.New("can't save invalid data")
+	}
+	return
+}
+
+// BeforeCreate - генерируем UUIDv4 для активации
+func (r *PromoRedemption) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
+
+// BeforeCreate - генерируем UUIDv4 для доступа
+func (e *Entitlement) BeforeCreate(tx *gorm.DB) (err error) {
+	e.ID = uuid.New()
+	return
+}
+
+// BeforeCreate - генерируем UUIDv4 для рассылки
+func (b *Broadcast) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return
+}
+
+// BeforeCreate - генерируем UUIDv4 для расклада
+func (r *Reading) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	if r.TelegramID == 0 || len(r.Cards) == 0 {
+		err = errors.New("can't save invalid data")
+	}
+	return
+}
+
+// BeforeCreate - генерируем UUIDv4 для тренировочного расклада
+func (p *Practice) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.New()
+	if p.TelegramID == 0 || len(p.Cards) == 0 {
+		err = errors.New("can't save invalid data")
+	}
+	return
+}
+
+// BeforeCreate - генерируем UUIDv4 для вопроса карточки
+func (q *QuizQuestion) BeforeCreate(tx *gorm.DB) (err error) {
+	q.ID = uuid.New()
+	if q.TelegramID == 0 || q.Card == "" {
+		err = errors.New("can't save invalid data")
+	}
+	return
+}
+
 // IsValid - валидация данных пользователя
 func (u *User) IsValid() bool {
 	if u.TelegramID == 0 || u.Balance < 0 {
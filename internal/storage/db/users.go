@@ -0,0 +1,193 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"taro-api/internal/storage"
+	"taro-api/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Размер вознаграждения для реферера
+	inviteBonusAmount int64 = 5
+	// Размер вознаграждения для реферала
+	referralBonusAmount int64 = 10
+	// Сколько после регистрации можно указать пригласившего через ClaimReferral
+	referralClaimWindow = 7 * 24 * time.Hour
+)
+
+// GetUser - возвращает пользователя с рефералами, ничего не создавая
+func (s *Storage) GetUser(telegramID int64) (*User, error) {
+	const op = "storage.db.GetUser"
+
+	user, err := loadUser(s.db, telegramID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, storage.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// RegisterUser - создает пользователя, если его еще нет, и помечает ботом, через который он пришел.
+// Реферальный бонус начисляется только при создании; неподходящий пригласивший игнорируется,
+// чтобы устаревшая ссылка не мешала регистрации. created - создан ли пользователь этим вызовом
+func (s *Storage) RegisterUser(telegramID int64, bot string, referrerID int64) (*User, bool, error) {
+	const op = "storage.db.RegisterUser"
+
+	var created bool
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user User
+		err := tx.Where("telegram_id = ?", telegramID).First(&user).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// параллельная регистрация могла успеть создать пользователя после проверки выше
+		user = User{TelegramID: telegramID, Bot: bot}
+		res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "telegram_id"}},
+			DoNothing: true,
+		}).Create(&user)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+
+		if referrerID == 0 {
+			return nil
+		}
+
+		err = applyReferral(tx, &user, referrerID)
+		if errors.Is(err, storage.ErrReferralInvalid) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := loadUser(s.db, telegramID)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, created, nil
+}
+
+// ClaimReferral - указывает пригласившего для уже зарегистрированного пользователя и начисляет
+// бонусы обоим; доступно один раз и только в первые дни после регистрации
+func (s *Storage) ClaimReferral(telegramID, referrerID int64) (*User, error) {
+	const op = "storage.db.ClaimReferral"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user User
+		err := tx.Where("telegram_id = ?", telegramID).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return storage.ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if user.ReferrerID != 0 || user.ReferralBonusApplied ||
+			time.Since(user.CreatedAt) > referralClaimWindow {
+			return storage.ErrReferralUnavailable
+		}
+
+		return applyReferral(tx, &user, referrerID)
+	})
+
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound),
+		errors.Is(err, storage.ErrReferralInvalid),
+		errors.Is(err, storage.ErrReferralUnavailable):
+		return nil, err
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := loadUser(s.db, telegramID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// applyReferral - привязывает пользователя к пригласившему и начисляет бонусы обоим
+func applyReferral(tx *gorm.DB, user *User, referrerID int64) error {
+	if referrerID == user.TelegramID {
+		return storage.ErrReferralInvalid
+	}
+
+	var referrer User
+	err := tx.Select("id", "referrer_id").Where("telegram_id = ?", referrerID).First(&referrer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return storage.ErrReferralInvalid
+	}
+	if err != nil {
+		return err
+	}
+	// взаимные приглашения дали бы бонусы по кругу
+	if referrer.ReferrerID == user.TelegramID {
+		return storage.ErrReferralInvalid
+	}
+
+	if err := creditBalance(tx, referrerID, inviteBonusAmount); err != nil {
+		return err
+	}
+	if err := creditBalance(tx, user.TelegramID, referralBonusAmount); err != nil {
+		return err
+	}
+
+	return tx.Model(user).Updates(map[string]any{
+		"referrer_id":            referrerID,
+		"referral_bonus_applied": true,
+	}).Error
+}
+
+// creditBalance - начисляет бонус на баланс внутри транзакции tx; все начисления
+// (реферальные, промокоды) идут через нее, чтобы откатываться вместе с остальными изменениями
+func creditBalance(tx *gorm.DB, telegramID, amount int64) error {
+	res := tx.Model(&User{}).
+		Where("telegram_id = ?", telegramID).
+		UpdateColumn("balance", gorm.Expr("balance + ?", amount))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return storage.ErrUserNotFound
+	}
+	return nil
+}
+
+// loadUser - пользователь с рефералами, действующими доступами и ссылкой на прокси аватара
+func loadUser(tx *gorm.DB, telegramID int64) (*User, error) {
+	var user User
+	err := tx.Preload("Referrals").
+		Preload("Entitlements", "expires_at IS NULL OR expires_at > ?", time.Now()).
+		Where("telegram_id = ?", telegramID).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	user.PhotoURL = utils.SumStrings("/photo/", strconv.FormatInt(telegramID, 10))
+
+	return &user, nil
+}
@@ -0,0 +1,131 @@
+package db_test
+
+import (
+	"context"
+	"path/filepath"
+	"taro-api/internal/storage/db"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newStorage(t *testing.T, path string) *db.Storage {
+	t.Helper()
+
+	storage, err := db.New(context.Background(), path)
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	t.Cleanup(func() { storage.CloseDatabaseConnection() })
+
+	return storage
+}
+
+// countUsers - число записей пользователя в базе по path
+func countUsers(t *testing.T, path string, telegramID int64) int64 {
+	t.Helper()
+
+	conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := conn.DB()
+	defer sqlDB.Close()
+
+	var n int64
+	if err := conn.Table("users").Where("telegram_id = ?", telegramID).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRegisterUserUnique(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	storage := newStorage(t, path)
+
+	for i, wantNew := range []bool{true, false} {
+		user, isNew, err := storage.RegisterUser(42, "main", 0)
+		if err != nil {
+			t.Fatalf("RegisterUser %d: %v", i, err)
+		}
+		if isNew != wantNew || user.TelegramID != 42 {
+			t.Errorf("RegisterUser %d: telegram_id %d, new %v; want 42, %v", i, user.TelegramID, isNew, wantNew)
+		}
+	}
+
+	// вторую запись не создаст и регистрация, проверившая отсутствие пользователя до вставки первой
+	conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := conn.DB()
+	defer sqlDB.Close()
+
+	err = conn.Exec(`INSERT INTO users (id, telegram_id, role) VALUES ('00000000-0000-0000-0000-000000000001', 42, 'user')`).Error
+	if err == nil {
+		t.Error("duplicate telegram_id was inserted")
+	}
+	if n := countUsers(t, path, 42); n != 1 {
+		t.Errorf("%d user rows, want 1", n)
+	}
+}
+
+func TestDedupeUsers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	// база до уникального индекса: у пользователя 42 две записи, начисления попадали в обе
+	conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Exec(`CREATE TABLE users (id text PRIMARY KEY, created_at datetime, updated_at datetime,
+		telegram_id integer, balance integer, role text, referrer_id integer,
+		referral_bonus_applied numeric, blocked numeric, bot_blocked numeric)`).Error
+	if err == nil {
+		err = conn.Exec(`INSERT INTO users (id, created_at, telegram_id, balance, role, referrer_id,
+			referral_bonus_applied, blocked, bot_blocked) VALUES
+			('00000000-0000-0000-0000-000000000002', '2024-01-01 10:00:01', 42, 10, 'user', 7, true, true, false),
+			('00000000-0000-0000-0000-000000000001', '2024-01-01 10:00:00', 42, 15, 'user', 0, false, false, false),
+			('00000000-0000-0000-0000-000000000003', '2024-01-01 10:00:00', 43, 3, 'user', 0, false, false, true)`).Error
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := conn.DB()
+	sqlDB.Close()
+
+	newStorage(t, path)
+
+	if n := countUsers(t, path, 42); n != 1 {
+		t.Fatalf("%d rows for a duplicated user, want 1", n)
+	}
+	if n := countUsers(t, path, 43); n != 1 {
+		t.Fatalf("%d rows for a unique user, want 1", n)
+	}
+
+	conn, err = gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ = conn.DB()
+	defer sqlDB.Close()
+
+	var users []db.User
+	if err := conn.Order("telegram_id").Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("users = %+v", users)
+	}
+
+	// остается самая ранняя запись с суммой балансов и флагами обеих
+	merged := users[0]
+	if merged.ID.String() != "00000000-0000-0000-0000-000000000001" || merged.Balance != 25 ||
+		merged.ReferrerID != 7 || !merged.ReferralBonusApplied || !merged.Blocked || merged.BotBlocked {
+		t.Errorf("merged user = %+v, want the earliest row with balance 25, referrer 7, bonus and blocked", merged)
+	}
+	if other := users[1]; other.Balance != 3 || !other.BotBlocked {
+		t.Errorf("unique user = %+v, want unchanged", other)
+	}
+}
@@ -4,5 +4,59 @@ import "errors"
 
 // Возможные ошибки
 var (
-	ErrUserNotFound = errors.New("User not found")
+	ErrUserNotFound    = errors.New("User not found")
+	ErrSessionNotFound = errors.New("Session not found")
+	ErrUserBlocked     = errors.New("User is blocked")
+	// ErrReferralInvalid - пригласивший не найден, это сам пользователь или его собственный реферал
+	ErrReferralInvalid = errors.New("Referral is invalid")
+	// ErrReferralUnavailable - бонус уже получен или срок, когда его можно получить, прошел
+	ErrReferralUnavailable = errors.New("Referral is unavailable")
+	// Ошибки активации промокодов
+	ErrPromoNotFound        = errors.New("Promo code not found")
+	ErrPromoInactive        = errors.New("Promo code is not active")
+	ErrPromoExhausted       = errors.New("Promo code is exhausted")
+	ErrPromoAlreadyRedeemed = errors.New("Promo code is already redeemed")
+	ErrPromoExists          = errors.New("Promo code already exists")
+	ErrBroadcastNotFound    = errors.New("Broadcast not found")
+	ErrReadingNotFound      = errors.New("Reading not found")
+	ErrPracticeNotFound     = errors.New("Practice not found")
+	ErrQuizNotFound         = errors.New("Quiz question not found")
+	// ErrPracticeAnswered - на тренировочный расклад уже ответили
+	ErrPracticeAnswered = errors.New("Practice is already answered")
+	// ErrQuizAnswered - на вопрос карточки уже ответили
+	ErrQuizAnswered = errors.New("Quiz question is already answered")
+	// ErrBroadcastState - действие недоступно в текущем статусе рассылки
+	ErrBroadcastState = errors.New("Broadcast is in another state")
+	// ErrIdempotencyMismatch - ключ идемпотентности уже использован с другим запросом
+	ErrIdempotencyMismatch = errors.New("Idempotency key is used with another request")
+	// ErrIdempotencyInProgress - запрос с этим ключом еще выполняется
+	ErrIdempotencyInProgress = errors.New("Idempotency key is in progress")
 )
+
+// TelegramProfile - данные профиля Telegram из init data
+type TelegramProfile struct {
+	FirstName    string
+	LastName     string
+	Username     string
+	LanguageCode string
+	IsPremium    bool
+	PhotoURL     string
+}
+
+// Access - результат проверки доступа вместе с языком, выбранным пользователем в настройках:
+// язык читается тем же запросом, чтобы не ходить за ним в базу отдельно
+type Access struct {
+	// Allowed - сессия действительна и пользователь не заблокирован
+	Allowed bool
+	// Language - язык из настроек, пусто - не выбран или пользователя еще нет
+	Language string
+}
+
+// IdempotentResponse - сохраненный ответ на запрос с ключом идемпотентности
+type IdempotentResponse struct {
+	Status      int
+	ContentType string
+	// Header - заголовки, выставленные обработчиком (например, Deprecation)
+	Header map[string][]string
+	Body   []byte
+}